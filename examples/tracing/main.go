@@ -0,0 +1,101 @@
+//
+// k2hdkc_go
+//
+// Copyright 2018 Yahoo Japan Corporation.
+//
+// Go driver for k2hdkc that is a highly available and scalable distributed
+// KVS clustering system. For k2hdkc, see
+// https://github.com/yahoojapan/k2hdkc for the details.
+//
+// For the full copyright and license information, please view
+// the license file that was distributed with this source code.
+//
+// AUTHOR:   Hirotaka Wakabayashi
+// CREATE:   Fri, 16 Oct 2026
+// REVISION:
+//
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/yahoojapan/k2hdkc_go/k2hdkc"
+	"github.com/yahoojapan/k2hdkc_go/k2hdkc/k2hdkctest"
+)
+
+// logTracer is an adapter which writes the spans to a logger.
+// An adapter of a tracing library starts its own span in Start instead.
+type logTracer struct {
+	log *log.Logger
+}
+
+type logSpan struct {
+	log   *log.Logger
+	name  string
+	depth int
+	start time.Time
+	attrs map[string]interface{}
+}
+
+type depthKey struct{}
+
+// Start starts a span as a child of the span in ctx.
+func (t *logTracer) Start(ctx context.Context, name string) (context.Context, k2hdkc.Span) {
+	depth, _ := ctx.Value(depthKey{}).(int)
+	s := &logSpan{log: t.log, name: name, depth: depth, start: time.Now(), attrs: make(map[string]interface{})}
+	return context.WithValue(ctx, depthKey{}, depth+1), s
+}
+
+// SetAttributes keeps the attributes until the span ends.
+func (s *logSpan) SetAttributes(attrs ...k2hdkc.Attribute) {
+	for _, a := range attrs {
+		s.attrs[a.Key] = a.Value
+	}
+}
+
+// End writes the span.
+func (s *logSpan) End(err error) {
+	keys := make([]string, 0, len(s.attrs))
+	for k := range s.attrs {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	var b strings.Builder
+	for _, k := range keys {
+		fmt.Fprintf(&b, " %v=%v", k, s.attrs[k])
+	}
+	s.log.Printf("%v%v %v%v err=%v", strings.Repeat("  ", s.depth), s.name, time.Since(s.start), b.String(), err)
+}
+
+func main() {
+	// the fake server works without a k2hdkc cluster.
+	c := k2hdkctest.NewServer().NewClient()
+	defer c.Close()
+
+	ctx := k2hdkc.WithTracer(context.Background(), &logTracer{log: log.New(os.Stdout, "", 0)})
+	if err := c.SetValueContext(ctx, "hello", []byte("world")); err != nil {
+		fmt.Fprintf(os.Stderr, "c.SetValueContext(hello) returned %v\n", err)
+		return
+	}
+	v, err := c.GetValueContext(ctx, "hello")
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "c.GetValueContext(hello) returned %v\n", err)
+		return
+	}
+	fmt.Println(string(v))
+}
+
+// Local Variables:
+// c-basic-offset: 4
+// tab-width: 4
+// indent-tabs-mode: t
+// End:
+// vim600: noexpandtab sw=4 ts=4 fdm=marker
+// vim<600: noexpandtab sw=4 ts=4
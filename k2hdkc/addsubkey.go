@@ -17,19 +17,11 @@
 
 package k2hdkc
 
-import (
-	// #cgo CFLAGS: -g -O2 -Wall -Wextra -Wno-unused-variable -Wno-unused-parameter -I. -I/usr/include/k2hdkc
-	// #cgo LDFLAGS: -L/usr/lib -lk2hdkc
-	// #include <stdlib.h>
-	// #include "k2hdkc.h"
-	// #include "k2hmacro.h"
-	"C"
-)
 import (
 	"bytes"
+	"context"
 	"errors"
 	"fmt"
-	"unsafe"
 )
 
 // AddSubKey holds C.k2hdkc_pm_set_subkey_wa arguments and a *AddSubKeyResult.
@@ -50,9 +42,9 @@ func (r *AddSubKey) String() string {
 
 // AddSubKeyResult holds the result of AddSubKey.Execute().
 type AddSubKeyResult struct {
-	ok         C._Bool
-	resCode    C.dkcres_type_t // response
-	subResCode C.dkcres_type_t // response(details)
+	ok         bool
+	resCode    ResCode // response
+	subResCode SubCode // response(details)
 }
 
 // String returns a text representation of the object.
@@ -137,45 +129,43 @@ func NewAddSubKey(k interface{}, sk interface{}, sv interface{}) (*AddSubKey, er
 	return c, nil
 }
 
+// SetEncPass sets the pass member.
+func (r *AddSubKey) SetEncPass(s string) {
+	r.pass = s
+}
+
+// SetExpire sets the expire member.
+func (r *AddSubKey) SetExpire(t int64) {
+	r.expire = t
+}
+
 // Execute calls the C.k2hdkc_pm_set_subkey_wa function that adds subkey to the k2hdkc cluster.
 func (r *AddSubKey) Execute(s *Session) (bool, error) {
 	// zero r.sval is ok though z.sval == nil is not ok.
 	if r.key == nil || len(r.key) == 0 || r.skey == nil || len(r.skey) == 0 || r.sval == nil || r.result == nil {
 		return false, fmt.Errorf("some required members nil, r.key %v, r.skey %v, r.sval %v, r.result %v", r.key, r.skey, r.sval, r.result)
 	}
-	cKey := C.CBytes(r.key)
-	defer C.free(unsafe.Pointer(cKey))
-	cSkey := C.CBytes(r.skey)
-	defer C.free(unsafe.Pointer(cSkey))
-	cSval := C.CBytes(r.sval)
-	defer C.free(unsafe.Pointer(cSval))
-	cPass := C.CString(r.pass)
-	defer C.free(unsafe.Pointer(cPass))
-	var expire *C.time_t
-	// WARNING: You can't set zero expire.
-	if r.expire != 0 {
-		expire = (*C.time_t)(&r.expire)
-	}
-	r.result.ok = C.k2hdkc_pm_set_subkey_wa(
-		s.handler,
-		(*C.uchar)(cKey),
-		(C.size_t)(len(r.key)),
-		(*C.uchar)(cSkey),
-		(C.size_t)(len(r.skey)),
-		(*C.uchar)(cSval),
-		(C.size_t)(len(r.sval)),
-		(C._Bool)(r.attr),
-		cPass,
-		expire)
-	r.result.resCode = C.k2hdkc_get_res_code(s.handler)
-	r.result.subResCode = C.k2hdkc_get_res_subcode(s.handler)
-
+	res := s.do(&Request{
+		Op:     OpAddSubKey,
+		Key:    r.key,
+		SubKey: r.skey,
+		Val:    r.sval,
+		Attr:   r.attr,
+		Pass:   r.pass,
+		Expire: r.expire,
+	})
+	r.result.ok, r.result.resCode, r.result.subResCode = res.OK, res.Code, res.SubCode
 	if r.result.ok == false {
-		return false, fmt.Errorf("C.k2hdkc_pm_set_subkey_wa %v", r.result.ok)
+		return false, resError("C.k2hdkc_pm_set_subkey_wa", res)
 	}
 	return true, nil
 }
 
+// ExecuteContext calls Execute in a goroutine and returns the context error if the context is done before Execute returns.
+func (r *AddSubKey) ExecuteContext(ctx context.Context, s *Session) (bool, error) {
+	return executeContext(ctx, s, r)
+}
+
 // Result returns the pointer of AddSubKeyResult that has the result of Execute method.
 // nil returns if no result exists.
 func (r *AddSubKey) Result() *AddSubKeyResult {
@@ -195,9 +185,17 @@ func (r *AddSubKeyResult) Bool() bool {
 
 // Error returns the errno of C.k2hdkc_pm_set_subkey_wa in string format.
 func (r *AddSubKeyResult) Error() string {
-	return fmt.Sprintf("%v %v",
-		C.GoString(C.str_dkcres_result_type(r.resCode)),
-		C.GoString(C.str_dkcres_subcode_type(r.subResCode)))
+	return fmt.Sprintf("%v %v", r.resCode, r.subResCode)
+}
+
+// Code returns the result code of the request.
+func (r *AddSubKeyResult) Code() ResCode {
+	return r.resCode
+}
+
+// SubCode returns the sub code of the request.
+func (r *AddSubKeyResult) SubCode() SubCode {
+	return r.subResCode
 }
 
 // Local Variables:
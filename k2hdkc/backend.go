@@ -0,0 +1,156 @@
+//
+// k2hdkc_go
+//
+// Copyright 2018 Yahoo Japan Corporation.
+//
+// Go driver for k2hdkc that is a highly available and scalable distributed
+// KVS clustering system. For k2hdkc, see
+// https://github.com/yahoojapan/k2hdkc for the details.
+//
+// For the full copyright and license information, please view
+// the license file that was distributed with this source code.
+//
+// AUTHOR:   Hirotaka Wakabayashi
+// CREATE:   Fri, 16 Oct 2026
+// REVISION:
+//
+
+package k2hdkc
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Op is the operation of a Request.
+type Op uint8
+
+// The operations which a Backend executes. Each operation corresponds to a Command of this package.
+const (
+	OpGet Op = iota + 1
+	OpSet
+	OpRemove
+	OpRename
+	OpGetSubKeys
+	OpSetSubKeys
+	OpAddSubKey
+	OpRemoveSubKey
+	OpClearSubKeys
+	OpSetAll
+	OpGetAttrs
+	OpCasInit
+	OpCasGet
+	OpCasSet
+	OpCasIncrement
+	OpCasDecrement
+	OpQueuePush
+	OpQueuePop
+	OpQueueRemove
+)
+
+var opText = map[Op]string{
+	OpGet:          "Get",
+	OpSet:          "Set",
+	OpRemove:       "Remove",
+	OpRename:       "Rename",
+	OpGetSubKeys:   "GetSubKeys",
+	OpSetSubKeys:   "SetSubKeys",
+	OpAddSubKey:    "AddSubKey",
+	OpRemoveSubKey: "RemoveSubKey",
+	OpClearSubKeys: "ClearSubKeys",
+	OpSetAll:       "SetAll",
+	OpGetAttrs:     "GetAttrs",
+	OpCasInit:      "CasInit",
+	OpCasGet:       "CasGet",
+	OpCasSet:       "CasSet",
+	OpCasIncrement: "CasIncrement",
+	OpCasDecrement: "CasDecrement",
+	OpQueuePush:    "QueuePush",
+	OpQueuePop:     "QueuePop",
+	OpQueueRemove:  "QueueRemove",
+}
+
+// String returns the name of the operation.
+func (o Op) String() string {
+	if s, ok := opText[o]; ok {
+		return s
+	}
+	return fmt.Sprintf("Op(%d)", (uint8)(o))
+}
+
+// Request holds the arguments of an operation. Each operation uses the members which its command has.
+type Request struct {
+	Op           Op
+	Key          []byte   // key, old key of OpRename or key of a key queue.
+	Val          []byte   // value or new cas value.
+	Old          []byte   // old cas value of OpCasSet.
+	NewKey       []byte   // new key of OpRename.
+	ParentKey    []byte   // parent key of OpRename.
+	SubKey       []byte   // subkey of OpAddSubKey and OpRemoveSubKey.
+	SubKeys      [][]byte // subkey list of OpSetSubKeys and OpSetAll.
+	Prefix       []byte   // queue prefix.
+	Pass         string   // passphrase to encrypt the value.
+	Expire       int64    // expiration time in seconds. zero means no expiration.
+	CasType      CasType  // length of the cas value of OpCasGet.
+	Count        int64    // number of values OpQueueRemove removes.
+	Fifo         bool     // queue order.
+	KeyQueue     bool     // true if the queue holds keys and values.
+	Attr         bool     // true if the attributes are checked.
+	RmSubKeyList bool     // true if OpSet removes the subkey list.
+	Nest         bool     // true if OpRemoveSubKey removes the subkeys of the subkey.
+}
+
+// String returns a text representation of the object.
+func (r *Request) String() string {
+	return fmt.Sprintf("[%v, %v, %v, %v]", r.Op, r.Key, r.Prefix, r.SubKey)
+}
+
+// Response holds the result of an operation.
+type Response struct {
+	OK         bool
+	Code       ResCode
+	SubCode    SubCode
+	RawCode    uint64   // dkcres_type_t result code of the library. other backends may leave it 0.
+	RawSubCode uint64   // dkcres_type_t sub code of the library. other backends may leave it 0.
+	Key        []byte   // key popped from a key queue.
+	Val        []byte   // value or cas value.
+	SubKeys    [][]byte // subkey list of OpGetSubKeys.
+	Attrs      []*Attr  // attributes of OpGetAttrs.
+}
+
+// String returns a text representation of the object.
+func (r *Response) String() string {
+	return fmt.Sprintf("[%v, %v, %v]", r.OK, r.Code, r.SubCode)
+}
+
+// Backend opens connections with a k2hdkc cluster. A Session executes commands on a Conn of the Backend of its Client.
+// The native backend, which calls the k2hdkc C library, is used if the Client has no Backend.
+// Open returns an *OpenError if it failed to connect and connecting again may succeed.
+type Backend interface {
+	Open(c *Client) (Conn, error)
+}
+
+// Conn is a connection with a k2hdkc cluster. A Session calls Do from one goroutine at a time.
+type Conn interface {
+	Do(req *Request) *Response
+	Close() error
+}
+
+// defaultBackend is the native backend if this package is built with cgo, otherwise nil.
+var defaultBackend Backend
+
+// DefaultBackend returns the native backend. It returns nil if this package is built without cgo or with the nok2hdkc tag.
+func DefaultBackend() Backend {
+	return defaultBackend
+}
+
+// ErrNoBackend is returned by NewSession if the Client has no Backend and the native backend is unavailable.
+var ErrNoBackend = errors.New("k2hdkc: no backend is available. build with cgo and without the nok2hdkc tag or set a Backend")
+
+// Local Variables:
+// c-basic-offset: 4
+// tab-width: 4
+// indent-tabs-mode: t
+// End:
+// vim600: noexpandtab sw=4 ts=4 fdm=marker
+// vim<600: noexpandtab sw=4 ts=4
@@ -0,0 +1,235 @@
+//
+// k2hdkc_go
+//
+// Copyright 2018 Yahoo Japan Corporation.
+//
+// Go driver for k2hdkc that is a highly available and scalable distributed
+// KVS clustering system. For k2hdkc, see
+// https://github.com/yahoojapan/k2hdkc for the details.
+//
+// For the full copyright and license information, please view
+// the license file that was distributed with this source code.
+//
+// AUTHOR:   Hirotaka Wakabayashi
+// CREATE:   Fri, 16 Oct 2026
+// REVISION:
+//
+
+package k2hdkc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// BatchPolicy defines what a Batch does when a command fails.
+type BatchPolicy uint8
+
+// BatchStopOnError means the commands after the first failure are skipped.
+// BatchContinueOnError means all commands are executed.
+const (
+	BatchStopOnError BatchPolicy = iota
+	BatchContinueOnError
+)
+
+// ErrBatchSkipped is the error of a command which a Batch skipped after a failure.
+var ErrBatchSkipped = errors.New("k2hdkc: skipped after a failure in the batch")
+
+// BatchOutcome holds the result of a command in a Batch.
+type BatchOutcome struct {
+	Command  Command
+	OK       bool
+	Err      error
+	Duration time.Duration
+}
+
+// String returns a text representation of the object.
+func (o BatchOutcome) String() string {
+	return fmt.Sprintf("[%v, %v, %v, %v]", o.Command, o.OK, o.Err, o.Duration)
+}
+
+// BatchResult holds the outcomes of the commands in the order they were added.
+type BatchResult struct {
+	Outcomes  []BatchOutcome
+	Duration  time.Duration // time to execute all commands.
+	Succeeded int
+	Failed    int
+	Skipped   int
+}
+
+// String returns a text representation of the object.
+func (r *BatchResult) String() string {
+	return fmt.Sprintf("[%v, %v, %v, %v]", r.Succeeded, r.Failed, r.Skipped, r.Duration)
+}
+
+// Err returns the error of the first failed command in the order they were added, or nil.
+func (r *BatchResult) Err() error {
+	for _, o := range r.Outcomes {
+		if !o.OK && o.Err != ErrBatchSkipped {
+			return o.Err
+		}
+	}
+	return nil
+}
+
+// Batch executes commands on pooled sessions. Commands run in order on one session
+// unless SetParallelism spreads them over more sessions.
+type Batch struct {
+	client      *Client
+	cmds        []Command
+	policy      BatchPolicy
+	parallelism int
+}
+
+// NewBatch returns the pointer to an empty Batch of the client.
+func NewBatch(c *Client) *Batch {
+	return &Batch{client: c, policy: BatchStopOnError, parallelism: 1}
+}
+
+// String returns a text representation of the object.
+func (b *Batch) String() string {
+	return fmt.Sprintf("[%v, %v, %v]", len(b.cmds), b.policy, b.parallelism)
+}
+
+// Add adds the commands.
+func (b *Batch) Add(cmds ...Command) *Batch {
+	b.cmds = append(b.cmds, cmds...)
+	return b
+}
+
+// Len returns the number of commands.
+func (b *Batch) Len() int {
+	return len(b.cmds)
+}
+
+// SetPolicy sets what the batch does when a command fails. BatchStopOnError is the default.
+func (b *Batch) SetPolicy(p BatchPolicy) *Batch {
+	b.policy = p
+	return b
+}
+
+// SetParallelism sets the number of sessions the commands run on. The order of commands is kept only if it's 1.
+func (b *Batch) SetParallelism(n int) *Batch {
+	if n > 0 {
+		b.parallelism = n
+	}
+	return b
+}
+
+// Run executes the commands. The error returns only if no session is available. The
+// failures of the commands are in the outcomes. A command which failed to get a
+// session has the error in its outcome and the commands after it are skipped.
+func (b *Batch) Run(ctx context.Context) (*BatchResult, error) {
+	start := time.Now()
+	res := &BatchResult{Outcomes: make([]BatchOutcome, len(b.cmds))}
+	for i, cmd := range b.cmds {
+		res.Outcomes[i] = BatchOutcome{Command: cmd, Err: ErrBatchSkipped}
+	}
+	if len(b.cmds) == 0 {
+		return res, nil
+	}
+	n := b.parallelism
+	if n > len(b.cmds) {
+		n = len(b.cmds)
+	}
+	idx := make(chan int, len(b.cmds))
+	for i := range b.cmds {
+		idx <- i
+	}
+	close(idx)
+
+	var (
+		wg      sync.WaitGroup
+		mu      sync.Mutex
+		stopped bool
+		opened  bool // true if a command has run on a session.
+		openErr error
+	)
+	stop := func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return stopped
+	}
+	for w := 0; w < n; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			var s *Session
+			defer func() {
+				if s != nil {
+					b.client.releaseSession(s, true)
+				}
+			}()
+			for i := range idx {
+				if stop() {
+					return
+				}
+				t := time.Now()
+				var oerr error
+				err := b.client.executeWith(ctx, b.cmds[i], func(ctx context.Context, cmd Command) (bool, bool, error) {
+					oerr = nil
+					if s == nil {
+						var err error
+						if s, err = b.client.acquireSessionTraced(ctx); err != nil {
+							oerr = fmt.Errorf("failed to create a session. %w", err)
+							return false, false, oerr
+						}
+					}
+					ok, err := b.client.executeOn(ctx, s, cmd)
+					if !reusable(ok, err) {
+						b.client.releaseSession(s, false)
+						s = nil
+					}
+					return ok, true, err
+				})
+				ok := err == nil
+				res.Outcomes[i] = BatchOutcome{Command: b.cmds[i], OK: ok, Err: err, Duration: time.Since(t)}
+				if oerr != nil {
+					// the command has failed without a session and the rest are skipped.
+					mu.Lock()
+					if openErr == nil {
+						openErr = err
+					}
+					stopped = true
+					mu.Unlock()
+					return
+				}
+				mu.Lock()
+				opened = true
+				mu.Unlock()
+				if !ok && (b.policy == BatchStopOnError || isContextError(err)) {
+					mu.Lock()
+					stopped = true
+					mu.Unlock()
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	for _, o := range res.Outcomes {
+		switch {
+		case o.OK:
+			res.Succeeded++
+		case o.Err == ErrBatchSkipped:
+			res.Skipped++
+		default:
+			res.Failed++
+		}
+	}
+	res.Duration = time.Since(start)
+	if !opened && openErr != nil {
+		return res, openErr
+	}
+	return res, nil
+}
+
+// Local Variables:
+// c-basic-offset: 4
+// tab-width: 4
+// indent-tabs-mode: t
+// End:
+// vim600: noexpandtab sw=4 ts=4 fdm=marker
+// vim<600: noexpandtab sw=4 ts=4
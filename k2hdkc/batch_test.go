@@ -0,0 +1,146 @@
+//
+// k2hdkc_go
+//
+// Copyright 2018 Yahoo Japan Corporation.
+//
+// Go driver for k2hdkc that is a highly available and scalable distributed
+// KVS clustering system. For k2hdkc, see
+// https://github.com/yahoojapan/k2hdkc for the details.
+//
+// For the full copyright and license information, please view
+// the license file that was distributed with this source code.
+//
+// AUTHOR:   Hirotaka Wakabayashi
+// CREATE:   Fri, 16 Oct 2026
+// REVISION:
+//
+
+package k2hdkc_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"testing"
+
+	"github.com/yahoojapan/k2hdkc_go/k2hdkc"
+	"github.com/yahoojapan/k2hdkc_go/k2hdkc/k2hdkctest"
+)
+
+// batchSets returns n Set commands of the keys batch0, batch1 and so on.
+func batchSets(t *testing.T, n int) []k2hdkc.Command {
+	cmds := make([]k2hdkc.Command, n)
+	for i := range cmds {
+		cmd, err := k2hdkc.NewSet(fmt.Sprintf("batch%d", i), "v")
+		if err != nil {
+			t.Fatalf("k2hdkc.NewSet() = %v", err)
+		}
+		cmds[i] = cmd
+	}
+	return cmds
+}
+
+// TestBatchRun tests all commands of a batch succeed.
+func TestBatchRun(t *testing.T) {
+	s := k2hdkctest.NewServer()
+	c := s.NewClient()
+	defer c.Close()
+
+	for _, n := range []int{1, 4} {
+		s.Reset()
+		res, err := k2hdkc.NewBatch(c).Add(batchSets(t, 10)...).SetParallelism(n).Run(context.Background())
+		if err != nil || res.Succeeded != 10 || res.Failed != 0 || res.Skipped != 0 || res.Err() != nil {
+			t.Errorf("parallelism %v: Run() = %v, %v", n, res, err)
+		}
+		for i := 0; i < 10; i++ {
+			if v, err := c.GetValue(fmt.Sprintf("batch%d", i)); err != nil || string(v) != "v\x00" {
+				t.Errorf("c.GetValue(batch%d) = %q, %v", i, v, err)
+			}
+		}
+	}
+}
+
+// TestBatchPolicy tests commands after a failure are skipped or executed by the policy.
+func TestBatchPolicy(t *testing.T) {
+	in := k2hdkctest.NewInjector(k2hdkctest.NewServer())
+	in.Add(k2hdkctest.Fault{Op: k2hdkc.OpSet, Key: regexp.MustCompile("^batch3"), Fail: true, SubCode: k2hdkc.SubCodeInternal})
+	c := in.NewClient()
+	defer c.Close()
+
+	res, err := k2hdkc.NewBatch(c).Add(batchSets(t, 6)...).Run(context.Background())
+	if err != nil || res.Succeeded != 3 || res.Failed != 1 || res.Skipped != 2 {
+		t.Fatalf("BatchStopOnError: Run() = %v, %v", res, err)
+	}
+	if !errors.Is(res.Err(), k2hdkc.ErrInternal) || res.Outcomes[3].Err != res.Err() {
+		t.Errorf("res.Err() = %v, want the error of the 4th command", res.Err())
+	}
+	if res.Outcomes[5].Err != k2hdkc.ErrBatchSkipped {
+		t.Errorf("res.Outcomes[5].Err = %v, want ErrBatchSkipped", res.Outcomes[5].Err)
+	}
+
+	res, err = k2hdkc.NewBatch(c).Add(batchSets(t, 6)...).SetPolicy(k2hdkc.BatchContinueOnError).Run(context.Background())
+	if err != nil || res.Succeeded != 5 || res.Failed != 1 || res.Skipped != 0 {
+		t.Errorf("BatchContinueOnError: Run() = %v, %v", res, err)
+	}
+}
+
+// TestBatchSessionError tests a batch replaces a broken session and continues.
+func TestBatchSessionError(t *testing.T) {
+	in := k2hdkctest.NewInjector(k2hdkctest.NewServer())
+	in.Add(k2hdkctest.Fault{Op: k2hdkc.OpSet, After: 2, Times: 1, Drop: true})
+	c := in.NewClient()
+	defer c.Close()
+
+	res, err := k2hdkc.NewBatch(c).Add(batchSets(t, 5)...).SetPolicy(k2hdkc.BatchContinueOnError).Run(context.Background())
+	if err != nil || res.Succeeded != 4 || res.Failed != 1 {
+		t.Errorf("Run() = %v, %v", res, err)
+	}
+	if !errors.Is(res.Outcomes[2].Err, k2hdkc.ErrCommunication) {
+		t.Errorf("res.Outcomes[2].Err = %v, want ErrCommunication", res.Outcomes[2].Err)
+	}
+}
+
+// TestBatchNoSession tests Run returns an error if no session is available.
+func TestBatchNoSession(t *testing.T) {
+	in := k2hdkctest.NewInjector(k2hdkctest.NewServer()).FailOpen(100)
+	c := in.NewClient()
+	defer c.Close()
+
+	res, err := k2hdkc.NewBatch(c).Add(batchSets(t, 3)...).Run(context.Background())
+	if !errors.Is(err, k2hdkctest.ErrInjected) || res.Failed != 1 || res.Skipped != 2 {
+		t.Errorf("Run() = %v, %v, want ErrInjected", res, err)
+	}
+	if !errors.Is(res.Outcomes[0].Err, k2hdkctest.ErrInjected) {
+		t.Errorf("res.Outcomes[0].Err = %v, want ErrInjected", res.Outcomes[0].Err)
+	}
+}
+
+// TestBatchReopenError tests the command which fails to get a new session has the error in its outcome.
+func TestBatchReopenError(t *testing.T) {
+	in := k2hdkctest.NewInjector(k2hdkctest.NewServer())
+	in.Add(k2hdkctest.Fault{Op: k2hdkc.OpSet, After: 2, Times: 1, Drop: true})
+	c := in.NewClient()
+	defer c.Close()
+
+	// the batch takes the idle session and fails to open another after it's dropped.
+	if err := c.SetValue("batch", []byte("v")); err != nil {
+		t.Fatalf("c.SetValue(batch, v) = %v", err)
+	}
+	in.FailOpen(100)
+	res, err := k2hdkc.NewBatch(c).Add(batchSets(t, 4)...).SetPolicy(k2hdkc.BatchContinueOnError).Run(context.Background())
+	if err != nil || res.Succeeded != 1 || res.Failed != 2 || res.Skipped != 1 {
+		t.Errorf("Run() = %v, %v", res, err)
+	}
+	if !errors.Is(res.Outcomes[2].Err, k2hdkctest.ErrInjected) {
+		t.Errorf("res.Outcomes[2].Err = %v, want ErrInjected", res.Outcomes[2].Err)
+	}
+}
+
+// Local Variables:
+// c-basic-offset: 4
+// tab-width: 4
+// indent-tabs-mode: t
+// End:
+// vim600: noexpandtab sw=4 ts=4 fdm=marker
+// vim<600: noexpandtab sw=4 ts=4
@@ -0,0 +1,355 @@
+//
+// k2hdkc_go
+//
+// Copyright 2018 Yahoo Japan Corporation.
+//
+// Go driver for k2hdkc that is a highly available and scalable distributed
+// KVS clustering system. For k2hdkc, see
+// https://github.com/yahoojapan/k2hdkc for the details.
+//
+// For the full copyright and license information, please view
+// the license file that was distributed with this source code.
+//
+// AUTHOR:   Hirotaka Wakabayashi
+// CREATE:   Fri, 16 Oct 2026
+// REVISION:
+//
+
+package k2hdkc
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// BreakerState is the state of a Breaker.
+type BreakerState uint8
+
+// BreakerClosed means requests are sent.
+// BreakerOpen means requests fail fast with a BreakerOpenError.
+// BreakerHalfOpen means a few requests are sent to probe the recovery.
+const (
+	BreakerClosed BreakerState = iota
+	BreakerOpen
+	BreakerHalfOpen
+)
+
+var breakerStateText = map[BreakerState]string{
+	BreakerClosed:   "closed",
+	BreakerOpen:     "open",
+	BreakerHalfOpen: "half-open",
+}
+
+// String returns the name of the state.
+func (s BreakerState) String() string {
+	return breakerStateText[s]
+}
+
+// ErrBreakerOpen matches the errors which a Client returns without sending the request
+// because its Breaker is open.
+var ErrBreakerOpen = errors.New("k2hdkc: circuit breaker is open")
+
+// BreakerOpenError is returned instead of executing a command while the Breaker is open.
+type BreakerOpenError struct {
+	State      BreakerState
+	RetryAfter time.Duration // time until the breaker moves to half-open.
+}
+
+// Error returns the text of the error.
+func (e *BreakerOpenError) Error() string {
+	return fmt.Sprintf("%v. state %v, retry after %v", ErrBreakerOpen, e.State, e.RetryAfter)
+}
+
+// Is returns true if target is ErrBreakerOpen.
+func (e *BreakerOpenError) Is(target error) bool {
+	return target == ErrBreakerOpen
+}
+
+// Default settings of a Breaker.
+const (
+	defaultBreakerMaxFailures = 5
+	defaultBreakerOpenTimeout = 5 * time.Second
+	defaultBreakerProbes      = 1
+)
+
+// Breaker is a circuit breaker which stops a Client from sending requests while the
+// chmpx slave or the cluster is failing. It counts failed session opens and session errors,
+// such as no-server or timeout. Failures of the request itself, such as no data, are not counted.
+// A Breaker is safe for concurrent use by multiple goroutines.
+type Breaker struct {
+	mu          sync.Mutex
+	maxFailures int           // consecutive failures which trip the breaker. zero disables it.
+	rate        float64       // failure rate which trips the breaker. zero disables it.
+	minRequests int           // requests needed before the rate is checked.
+	interval    time.Duration // period the counts are cleared while closed. zero means never.
+	openTimeout time.Duration // time the breaker stays open.
+	probes      int           // requests sent in half-open.
+	onChange    func(from, to BreakerState)
+	now         func() time.Time
+
+	state       BreakerState
+	gen         uint64    // incremented at each state change or count reset.
+	changed     time.Time // time of the last state change or the count reset.
+	requests    int
+	failures    int
+	consecutive int
+	inflight    int // probes in flight.
+	succeeded   int // probes which succeeded.
+}
+
+// NewBreaker returns the pointer to a Breaker which trips after 5 consecutive failures and
+// probes the recovery 5 seconds later.
+func NewBreaker() *Breaker {
+	return &Breaker{
+		maxFailures: defaultBreakerMaxFailures,
+		openTimeout: defaultBreakerOpenTimeout,
+		probes:      defaultBreakerProbes,
+		now:         time.Now,
+		changed:     time.Now(),
+	}
+}
+
+// String returns a text representation of the object.
+func (b *Breaker) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return fmt.Sprintf("[%v, %v, %v, %v]", b.state, b.requests, b.failures, b.consecutive)
+}
+
+// SetMaxFailures sets the number of consecutive failures which trips the breaker.
+// Zero disables the check.
+func (b *Breaker) SetMaxFailures(n int) *Breaker {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.maxFailures = n
+	return b
+}
+
+// SetFailureRate sets the rate of failures from 0 to 1 which trips the breaker once
+// minRequests requests have been counted. Zero disables the check.
+func (b *Breaker) SetFailureRate(rate float64, minRequests int) *Breaker {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.rate = rate
+	b.minRequests = minRequests
+	return b
+}
+
+// SetInterval sets the period the counts are cleared while the breaker is closed.
+// Zero, the default, means the counts are cleared only when the state changes.
+func (b *Breaker) SetInterval(d time.Duration) *Breaker {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.interval = d
+	return b
+}
+
+// SetOpenTimeout sets the time the breaker stays open before it moves to half-open.
+func (b *Breaker) SetOpenTimeout(d time.Duration) *Breaker {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.openTimeout = d
+	return b
+}
+
+// SetProbes sets the number of requests sent in half-open. The breaker closes if all of them
+// succeed and opens again if one of them fails.
+func (b *Breaker) SetProbes(n int) *Breaker {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if n > 0 {
+		b.probes = n
+	}
+	return b
+}
+
+// OnStateChange sets a function called after the state changes.
+// It must not block since requests wait for it.
+func (b *Breaker) OnStateChange(f func(from, to BreakerState)) *Breaker {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.onChange = f
+	return b
+}
+
+// State returns the current state.
+func (b *Breaker) State() BreakerState {
+	b.mu.Lock()
+	from := b.state
+	b.refresh()
+	to, f := b.state, b.onChange
+	b.mu.Unlock()
+	notify(f, from, to)
+	return to
+}
+
+// Reset closes the breaker and clears the counts.
+func (b *Breaker) Reset() {
+	b.mu.Lock()
+	from := b.state
+	b.setState(BreakerClosed)
+	f := b.onChange
+	b.mu.Unlock()
+	notify(f, from, BreakerClosed)
+}
+
+// allow returns an error if the request must not be sent. A nil error means the caller must
+// call done with the generation returned and the result of the request.
+func (b *Breaker) allow() (uint64, error) {
+	b.mu.Lock()
+	from := b.state
+	b.refresh()
+	var err error
+	switch b.state {
+	case BreakerOpen:
+		err = &BreakerOpenError{State: b.state, RetryAfter: b.changed.Add(b.openTimeout).Sub(b.now())}
+	case BreakerHalfOpen:
+		if b.inflight+b.succeeded >= b.probes {
+			err = &BreakerOpenError{State: b.state}
+		} else {
+			b.inflight++
+		}
+	default:
+		b.requests++
+	}
+	gen, to, f := b.gen, b.state, b.onChange
+	b.mu.Unlock()
+	notify(f, from, to)
+	return gen, err
+}
+
+// done records the result of a request which allow permitted in the generation gen.
+// failed is true if the request failed because of chmpx. ignored is true if the result
+// says nothing about chmpx, for example the context was canceled.
+// Results of the previous generations are not recorded.
+func (b *Breaker) done(gen uint64, failed bool, ignored bool) {
+	b.mu.Lock()
+	from := b.state
+	if gen == b.gen {
+		switch b.state {
+		case BreakerHalfOpen:
+			b.inflight--
+			switch {
+			case ignored:
+			case failed:
+				b.setState(BreakerOpen)
+			default:
+				if b.succeeded++; b.succeeded >= b.probes {
+					b.setState(BreakerClosed)
+				}
+			}
+		case BreakerClosed:
+			switch {
+			case ignored:
+				b.requests--
+			case failed:
+				b.failures++
+				b.consecutive++
+				if b.tripped() {
+					b.setState(BreakerOpen)
+				}
+			default:
+				b.consecutive = 0
+			}
+		}
+	}
+	to, f := b.state, b.onChange
+	b.mu.Unlock()
+	notify(f, from, to)
+}
+
+// tripped returns true if the counts exceed the thresholds.
+// NOTICE b.mu must be locked.
+func (b *Breaker) tripped() bool {
+	if b.maxFailures > 0 && b.consecutive >= b.maxFailures {
+		return true
+	}
+	return b.rate > 0 && b.requests >= b.minRequests && float64(b.failures) >= b.rate*float64(b.requests)
+}
+
+// refresh moves an open breaker to half-open after the timeout and clears the counts of a
+// closed breaker after the interval.
+// NOTICE b.mu must be locked.
+func (b *Breaker) refresh() {
+	switch b.state {
+	case BreakerOpen:
+		if !b.now().Before(b.changed.Add(b.openTimeout)) {
+			b.setState(BreakerHalfOpen)
+		}
+	case BreakerClosed:
+		if b.interval > 0 && !b.now().Before(b.changed.Add(b.interval)) {
+			b.setState(BreakerClosed)
+		}
+	}
+}
+
+// setState changes the state and starts a new generation with clear counts.
+// NOTICE b.mu must be locked.
+func (b *Breaker) setState(s BreakerState) {
+	b.state = s
+	b.gen++
+	b.changed = b.now()
+	b.requests = 0
+	b.failures = 0
+	b.consecutive = 0
+	b.inflight = 0
+	b.succeeded = 0
+}
+
+// notify calls f if the state has changed.
+func notify(f func(from, to BreakerState), from, to BreakerState) {
+	if f != nil && from != to {
+		f(from, to)
+	}
+}
+
+// SetBreaker sets the circuit breaker of the client. nil, the default, disables it.
+func (c *Client) SetBreaker(b *Breaker) *Client {
+	c.breaker = b
+	return c
+}
+
+// Breaker returns the circuit breaker of the client or nil.
+func (c *Client) Breaker() *Breaker {
+	return c.breaker
+}
+
+// guard runs an attempt of a command through the circuit breaker of the client.
+func (c *Client) guard(run func() (bool, bool, error)) (bool, bool, error) {
+	b := c.breaker
+	if b == nil {
+		return run()
+	}
+	gen, err := b.allow()
+	if err != nil {
+		return false, false, err
+	}
+	ok, sent, err := run()
+	failed := !ok && isBreakerFailure(err)
+	// a response of the cluster means chmpx works even if the request failed. The other
+	// errors, such as ErrPoolClosed, say nothing about chmpx.
+	var re *ResError
+	ignored := !ok && !failed && (isContextError(err) || !errors.As(err, &re))
+	b.done(gen, failed, ignored)
+	return ok, sent, err
+}
+
+// isBreakerFailure returns true if err means the chmpx slave or the cluster is failing.
+// Errors of the request itself, such as validation errors and ErrPoolClosed, are not failures.
+func isBreakerFailure(err error) bool {
+	if isOpenError(err) {
+		return true
+	}
+	var re *ResError
+	return errors.As(err, &re) && re.Temporary()
+}
+
+// Local Variables:
+// c-basic-offset: 4
+// tab-width: 4
+// indent-tabs-mode: t
+// End:
+// vim600: noexpandtab sw=4 ts=4 fdm=marker
+// vim<600: noexpandtab sw=4 ts=4
@@ -0,0 +1,158 @@
+//
+// k2hdkc_go
+//
+// Copyright 2018 Yahoo Japan Corporation.
+//
+// Go driver for k2hdkc that is a highly available and scalable distributed
+// KVS clustering system. For k2hdkc, see
+// https://github.com/yahoojapan/k2hdkc for the details.
+//
+// For the full copyright and license information, please view
+// the license file that was distributed with this source code.
+//
+// AUTHOR:   Hirotaka Wakabayashi
+// CREATE:   Fri, 16 Oct 2026
+// REVISION:
+//
+
+package k2hdkc_test
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/yahoojapan/k2hdkc_go/k2hdkc"
+	"github.com/yahoojapan/k2hdkc_go/k2hdkc/k2hdkctest"
+)
+
+// breakerStates records the state changes of a breaker.
+type breakerStates struct {
+	mu      sync.Mutex
+	changes []string
+}
+
+func (s *breakerStates) record(from, to k2hdkc.BreakerState) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.changes = append(s.changes, fmt.Sprintf("%v>%v", from, to))
+}
+
+func (s *breakerStates) String() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return fmt.Sprint(s.changes)
+}
+
+// TestBreakerTrip tests the breaker trips, probes and closes.
+func TestBreakerTrip(t *testing.T) {
+	in := k2hdkctest.NewInjector(k2hdkctest.NewServer())
+	states := &breakerStates{}
+	b := k2hdkc.NewBreaker().SetMaxFailures(3).SetOpenTimeout(20 * time.Millisecond).OnStateChange(states.record)
+	c := in.NewClient().SetBreaker(b)
+	defer c.Close()
+	if err := c.SetValue("breaker", []byte("v")); err != nil {
+		t.Fatalf("c.SetValue(breaker) = %v", err)
+	}
+
+	for i := 0; i < 10; i++ {
+		if _, err := c.GetValue("nokey"); !errors.Is(err, k2hdkc.ErrNotFound) {
+			t.Fatalf("c.GetValue(nokey) = %v, want ErrNotFound", err)
+		}
+	}
+	if b.State() != k2hdkc.BreakerClosed {
+		t.Fatalf("missing keys tripped the breaker")
+	}
+
+	in.Add(k2hdkctest.Fault{Op: k2hdkc.OpGet, Fail: true, SubCode: k2hdkc.SubCodeNoServer})
+	for i := 0; i < 3; i++ {
+		if _, err := c.GetValue("breaker"); !errors.Is(err, k2hdkc.ErrNoServer) {
+			t.Fatalf("c.GetValue(breaker) = %v, want ErrNoServer", err)
+		}
+	}
+	var oe *k2hdkc.BreakerOpenError
+	if _, err := c.GetValue("breaker"); !errors.Is(err, k2hdkc.ErrBreakerOpen) || !errors.As(err, &oe) || oe.State != k2hdkc.BreakerOpen {
+		t.Fatalf("c.GetValue(breaker) = %v, want ErrBreakerOpen", err)
+	}
+	if n := in.Calls(k2hdkc.OpGet); n != 13 {
+		t.Errorf("in.Calls(OpGet) = %v, want 13", n)
+	}
+
+	time.Sleep(30 * time.Millisecond)
+	if _, err := c.GetValue("breaker"); !errors.Is(err, k2hdkc.ErrNoServer) || b.State() != k2hdkc.BreakerOpen {
+		t.Fatalf("the failed probe = %v, state %v, want open", err, b.State())
+	}
+	time.Sleep(30 * time.Millisecond)
+	in.Clear()
+	if v, err := c.GetValue("breaker"); err != nil || string(v) != "v" || b.State() != k2hdkc.BreakerClosed {
+		t.Fatalf("the probe = %q, %v, state %v, want closed", v, err, b.State())
+	}
+	want := "[closed>open open>half-open half-open>open open>half-open half-open>closed]"
+	if got := states.String(); got != want {
+		t.Errorf("state changes = %v, want %v", got, want)
+	}
+}
+
+// TestBreakerRate tests the breaker trips by the failure rate.
+func TestBreakerRate(t *testing.T) {
+	in := k2hdkctest.NewInjector(k2hdkctest.NewServer())
+	b := k2hdkc.NewBreaker().SetMaxFailures(0).SetFailureRate(0.5, 4)
+	c := in.NewClient().SetBreaker(b)
+	defer c.Close()
+
+	in.Add(k2hdkctest.Fault{Op: k2hdkc.OpSet, After: 1, Times: 1, Fail: true, SubCode: k2hdkc.SubCodeTimeout})
+	in.Add(k2hdkctest.Fault{Op: k2hdkc.OpSet, After: 2, Times: 1, Fail: true, SubCode: k2hdkc.SubCodeTimeout})
+	for i := 0; i < 3; i++ {
+		c.SetValue("rate", []byte("v"))
+	}
+	if b.State() != k2hdkc.BreakerClosed {
+		t.Fatalf("the breaker tripped before minRequests")
+	}
+	in.Add(k2hdkctest.Fault{Op: k2hdkc.OpSet, Fail: true, SubCode: k2hdkc.SubCodeTimeout})
+	c.SetValue("rate", []byte("v"))
+	if b.State() != k2hdkc.BreakerOpen {
+		t.Errorf("b.State() = %v, want open", b.State())
+	}
+	b.Reset()
+	if b.State() != k2hdkc.BreakerClosed {
+		t.Errorf("b.State() = %v after Reset, want closed", b.State())
+	}
+}
+
+// TestBreakerOpenFailure tests failed session opens trip the breaker and aren't retried while it's open.
+func TestBreakerOpenFailure(t *testing.T) {
+	in := k2hdkctest.NewInjector(k2hdkctest.NewServer()).FailOpen(100)
+	c := in.NewClient().SetBreaker(k2hdkc.NewBreaker().SetMaxFailures(2)).SetRetryPolicy(retryPolicy())
+	defer c.Close()
+
+	if err := c.SetValue("open", []byte("v")); !errors.Is(err, k2hdkc.ErrBreakerOpen) {
+		t.Errorf("c.SetValue(open) = %v, want ErrBreakerOpen", err)
+	}
+}
+
+// TestBreakerRequestError tests errors of the requests themselves don't trip the breaker.
+func TestBreakerRequestError(t *testing.T) {
+	c := k2hdkctest.NewServer().NewClient().SetBreaker(k2hdkc.NewBreaker().SetMaxFailures(1))
+	if _, err := c.GetValue("nokey"); !errors.Is(err, k2hdkc.ErrNotFound) {
+		t.Errorf("c.GetValue(nokey) = %v, want ErrNotFound", err)
+	}
+	c.Close()
+	for i := 0; i < 3; i++ {
+		if _, err := c.GetValue("key"); !errors.Is(err, k2hdkc.ErrPoolClosed) {
+			t.Errorf("c.GetValue(key) of a closed client = %v, want ErrPoolClosed", err)
+		}
+	}
+	if s := c.Breaker().State(); s != k2hdkc.BreakerClosed {
+		t.Errorf("c.Breaker().State() = %v, want closed", s)
+	}
+}
+
+// Local Variables:
+// c-basic-offset: 4
+// tab-width: 4
+// indent-tabs-mode: t
+// End:
+// vim600: noexpandtab sw=4 ts=4 fdm=marker
+// vim<600: noexpandtab sw=4 ts=4
@@ -17,19 +17,11 @@
 
 package k2hdkc
 
-import (
-	// #cgo CFLAGS: -g -O2 -Wall -Wextra -Wno-unused-variable -Wno-unused-parameter -I. -I/usr/include/k2hdkc
-	// #cgo LDFLAGS: -L/usr/lib -lk2hdkc
-	// #include <stdlib.h>
-	// #include "k2hdkc.h"
-	// #include "k2hmacro.h"
-	"C"
-)
 import (
 	"bytes"
+	"context"
 	"errors"
 	"fmt"
-	"unsafe"
 )
 
 // CasGet holds arguments for C.k2hdkc_pm_cas{8,16,32,64}_get and a pointer of CasGetResult.
@@ -49,9 +41,9 @@ func (r *CasGet) String() string {
 // CasGetResult holds the result of CasGet.Execute().
 type CasGetResult struct {
 	val        []byte
-	ok         C._Bool
-	resCode    C.dkcres_type_t // response
-	subResCode C.dkcres_type_t // response(details)
+	ok         bool
+	resCode    ResCode // response
+	subResCode SubCode // response(details)
 }
 
 // String returns a text representation of the object.
@@ -134,98 +126,25 @@ func (r *CasGet) Execute(s *Session) (bool, error) {
 	if r.key == nil || len(r.key) == 0 || r.result == nil {
 		return false, fmt.Errorf("some required members nil, r.key %v, r.result %v", r.key, r.result)
 	}
-	cKey := C.CBytes(r.key)
-	defer C.free(cKey)
-	cPass := C.CString(r.pass)
-	defer C.free(unsafe.Pointer(cPass))
 	switch r.vlen {
 	default:
 		return false, fmt.Errorf("unsupported data format %T", r.vlen)
-	case 8:
-		{
-			// bool k2hdkc_pm_cas8_get_wa(k2hdkc_chmpx_h handle, const unsigned char* pkey, size_t keylength, const char* encpass, uint8_t* pval)
-			var cVal C.uint8_t
-			r.result.ok = C.k2hdkc_pm_cas8_get_wa(
-				s.handler,
-				(*C.uchar)(cKey),
-				C.size_t(len(r.key)),
-				cPass,
-				&cVal)
-			// See https://golang.org/pkg/unsafe/#Pointer
-			defer C.free(unsafe.Pointer(uintptr(unsafe.Pointer(&cVal))))
-
-			r.result.resCode = C.k2hdkc_get_res_code(s.handler)
-			r.result.subResCode = C.k2hdkc_get_res_subcode(s.handler)
-			if !r.result.ok {
-				return false, errors.New("C.k2hdkc_pm_cas8_get_wa returned false")
-			}
-			r.result.val = make([]byte, 1)
-			r.result.val[0] = (uint8)(cVal)
-		}
-	case 16:
-		{
-			var cVal C.uint16_t
-			r.result.ok = C.k2hdkc_pm_cas16_get_wa(
-				s.handler,
-				(*C.uchar)(cKey),
-				C.size_t(len(r.key)),
-				cPass,
-				&cVal)
-			defer C.free(unsafe.Pointer(uintptr(unsafe.Pointer(&cVal))))
-			r.result.resCode = C.k2hdkc_get_res_code(s.handler)
-			r.result.subResCode = C.k2hdkc_get_res_subcode(s.handler)
-			if !r.result.ok {
-				return false, errors.New("C.k2hdkc_pm_cas16_get_wa returned false")
-			}
-			r.result.val = make([]byte, 2)
-			for i := 0; i < 2; i++ {
-				r.result.val[i] = (uint8)(cVal >> (uint16)(8*i)) // OK
-			}
-		}
-	case 32:
-		{
-			var cVal C.uint32_t
-			r.result.ok = C.k2hdkc_pm_cas32_get_wa(
-				s.handler,
-				(*C.uchar)(cKey),
-				C.size_t(len(r.key)),
-				cPass,
-				&cVal)
-			defer C.free(unsafe.Pointer(uintptr(unsafe.Pointer(&cVal))))
-			r.result.resCode = C.k2hdkc_get_res_code(s.handler)
-			r.result.subResCode = C.k2hdkc_get_res_subcode(s.handler)
-			if !r.result.ok {
-				return false, errors.New("C.k2hdkc_pm_cas32_get_wa returned false")
-			}
-			r.result.val = make([]byte, 4)
-			for i := 0; i < 4; i++ {
-				r.result.val[i] = (uint8)(cVal >> (uint32)(8*i)) // OK
-			}
-		}
-	case 64:
-		{
-			var cVal C.uint64_t
-			r.result.ok = C.k2hdkc_pm_cas64_get_wa(
-				s.handler,
-				(*C.uchar)(cKey),
-				C.size_t(len(r.key)),
-				cPass,
-				&cVal)
-			defer C.free(unsafe.Pointer(uintptr(unsafe.Pointer(&cVal))))
-			r.result.resCode = C.k2hdkc_get_res_code(s.handler)
-			r.result.subResCode = C.k2hdkc_get_res_subcode(s.handler)
-			if !r.result.ok {
-				return false, errors.New("C.k2hdkc_pm_cas64_get_wa returned false")
-			}
-			r.result.val = make([]byte, 8)
-			for i := 0; i < 8; i++ {
-				r.result.val[i] = (uint8)(cVal >> (uint64)(8*i)) // OK
-			}
-		}
+	case 8, 16, 32, 64:
 	}
+	res := s.do(&Request{Op: OpCasGet, Key: r.key, Pass: r.pass, CasType: (CasType)(r.vlen)})
+	r.result.ok, r.result.resCode, r.result.subResCode = res.OK, res.Code, res.SubCode
+	if !r.result.ok {
+		return false, resError(fmt.Sprintf("C.k2hdkc_pm_cas%d_get_wa", r.vlen), res)
+	}
+	r.result.val = res.Val
 	return true, nil
 }
 
+// ExecuteContext calls Execute in a goroutine and returns the context error if the context is done before Execute returns.
+func (r *CasGet) ExecuteContext(ctx context.Context, s *Session) (bool, error) {
+	return executeContext(ctx, s, r)
+}
+
 // Result returns the pointer of CasGetResult that has the result of Execute method.
 func (r *CasGet) Result() *CasGetResult {
 	if r.result != nil {
@@ -252,9 +171,17 @@ func (r *CasGetResult) Bool() bool {
 
 // Error returns the errno of C.k2hdkc_pm_cas{8,16,32,64}_get_wa in string format.
 func (r *CasGetResult) Error() string {
-	return fmt.Sprintf("%v %v",
-		C.GoString(C.str_dkcres_result_type(r.resCode)),
-		C.GoString(C.str_dkcres_subcode_type(r.subResCode)))
+	return fmt.Sprintf("%v %v", r.resCode, r.subResCode)
+}
+
+// Code returns the result code of the request.
+func (r *CasGetResult) Code() ResCode {
+	return r.resCode
+}
+
+// SubCode returns the sub code of the request.
+func (r *CasGetResult) SubCode() SubCode {
+	return r.subResCode
 }
 
 // Local Variables:
@@ -17,19 +17,11 @@
 
 package k2hdkc
 
-import (
-	// #cgo CFLAGS: -g -O2 -Wall -Wextra -Wno-unused-variable -Wno-unused-parameter -I. -I/usr/include/k2hdkc
-	// #cgo LDFLAGS: -L/usr/lib -lk2hdkc
-	// #include <stdlib.h>
-	// #include "k2hdkc.h"
-	// #include "k2hmacro.h"
-	"C"
-)
 import (
 	"bytes"
+	"context"
 	"errors"
 	"fmt"
-	"unsafe"
 )
 
 // CasIncDec holds arguments for C.k2hdkc_pm_cas{8,16,32,64}_{in,de}crement and a pointer of CasIncDecResult.
@@ -48,9 +40,9 @@ func (r *CasIncDec) String() string {
 
 // CasIncDecResult holds the result of CasIncDec.Execute().
 type CasIncDecResult struct {
-	ok         C._Bool
-	resCode    C.dkcres_type_t // response
-	subResCode C.dkcres_type_t // response(details)
+	ok         bool
+	resCode    ResCode // response
+	subResCode SubCode // response(details)
 }
 
 // String returns a text representation of the object.
@@ -107,58 +99,23 @@ func (r *CasIncDec) SetExpire(t int64) {
 
 // Increment calls the C.k2hdkc_pm_cas_increment_wa function.
 func (r *CasIncDec) Increment(s *Session) (bool, error) {
-	if r.key == nil || len(r.key) == 0 || r.result == nil {
-		return false, fmt.Errorf("some required members nil, r.key %v, r.result %v", r.key, r.result)
-	}
-	cKey := C.CBytes(r.key)
-	defer C.free(cKey)
-	cPass := C.CString(r.pass)
-	defer C.free(unsafe.Pointer(cPass))
-	var expire *C.time_t // default
-	// WARNING: You can't set zero expire.
-	if r.expire != 0 {
-		expire = (*C.time_t)(&r.expire)
-	}
-
-	// bool k2hdkc_pm_cas_increment_wa(k2hdkc_chmpx_h handle, const unsigned char* pkey, size_t keylength, const char* encpass, const time_t* expire)
-	r.result.ok = C.k2hdkc_pm_cas_increment_wa(
-		s.handler,
-		(*C.uchar)(cKey),
-		C.size_t(len(r.key)),
-		cPass,
-		expire)
-	r.result.resCode = C.k2hdkc_get_res_code(s.handler)
-	r.result.subResCode = C.k2hdkc_get_res_subcode(s.handler)
-
-	if !r.result.ok {
-		return false, errors.New("C.k2hdkc_pm_cas_increment_wa returned false")
-	}
-	return true, nil
+	return r.execute(s, OpCasIncrement, "C.k2hdkc_pm_cas_increment_wa")
 }
 
 // Decrement calls the C.k2hdkc_pm_cas_decrement_wa function.
 func (r *CasIncDec) Decrement(s *Session) (bool, error) {
-	cKey := C.CBytes(r.key)
-	defer C.free(cKey)
-	cPass := C.CString(r.pass)
-	defer C.free(unsafe.Pointer(cPass))
-	var expire *C.time_t // default
-	// WARNING: You can't set zero expire.
-	if r.expire != 0 {
-		expire = (*C.time_t)(&r.expire)
-	}
-	// bool k2hdkc_pm_cas_decrement_wa(k2hdkc_chmpx_h handle, const unsigned char* pkey, size_t keylength, const char* encpass, const time_t* expire)
-	r.result.ok = C.k2hdkc_pm_cas_decrement_wa(
-		s.handler,
-		(*C.uchar)(cKey),
-		C.size_t(len(r.key)),
-		cPass,
-		expire)
-	r.result.resCode = C.k2hdkc_get_res_code(s.handler)
-	r.result.subResCode = C.k2hdkc_get_res_subcode(s.handler)
+	return r.execute(s, OpCasDecrement, "C.k2hdkc_pm_cas_decrement_wa")
+}
 
+// execute sends the increment or decrement request.
+func (r *CasIncDec) execute(s *Session, op Op, name string) (bool, error) {
+	if r.key == nil || len(r.key) == 0 || r.result == nil {
+		return false, fmt.Errorf("some required members nil, r.key %v, r.result %v", r.key, r.result)
+	}
+	res := s.do(&Request{Op: op, Key: r.key, Pass: r.pass, Expire: r.expire})
+	r.result.ok, r.result.resCode, r.result.subResCode = res.OK, res.Code, res.SubCode
 	if !r.result.ok {
-		return false, errors.New("C.k2hdkc_pm_cas_decrement_wa returned false")
+		return false, resError(name, res)
 	}
 	return true, nil
 }
@@ -171,6 +128,11 @@ func (r *CasIncDec) Execute(s *Session) (bool, error) {
 	return r.Decrement(s)
 }
 
+// ExecuteContext calls Execute in a goroutine and returns the context error if the context is done before Execute returns.
+func (r *CasIncDec) ExecuteContext(ctx context.Context, s *Session) (bool, error) {
+	return executeContext(ctx, s, r)
+}
+
 // Result returns the pointer of CasIncDecResult that has the result of Execute method.
 func (r *CasIncDec) Result() *CasIncDecResult {
 	if r.result != nil {
@@ -189,9 +151,17 @@ func (r *CasIncDecResult) Bool() bool {
 
 // Error returns the errno of C.k2hdkc_pm_cas_{in,de}crement_wa in string format.
 func (r *CasIncDecResult) Error() string {
-	return fmt.Sprintf("%v %v",
-		C.GoString(C.str_dkcres_result_type(r.resCode)),
-		C.GoString(C.str_dkcres_subcode_type(r.subResCode)))
+	return fmt.Sprintf("%v %v", r.resCode, r.subResCode)
+}
+
+// Code returns the result code of the request.
+func (r *CasIncDecResult) Code() ResCode {
+	return r.resCode
+}
+
+// SubCode returns the sub code of the request.
+func (r *CasIncDecResult) SubCode() SubCode {
+	return r.subResCode
 }
 
 // Local Variables:
@@ -17,19 +17,11 @@
 
 package k2hdkc
 
-import (
-	// #cgo CFLAGS: -g -O2 -Wall -Wextra -Wno-unused-variable -Wno-unused-parameter -I. -I/usr/include/k2hdkc
-	// #cgo LDFLAGS: -L/usr/lib -lk2hdkc
-	// #include <stdlib.h>
-	// #include "k2hdkc.h"
-	// #include "k2hmacro.h"
-	"C"
-)
 import (
 	"bytes"
+	"context"
 	"errors"
 	"fmt"
-	"unsafe"
 )
 
 // CasInit holds arguments for C.k2hdkc_pm_cas{8,16,32,64}_init and a pointer of CasInitResult.
@@ -48,9 +40,9 @@ func (r *CasInit) String() string {
 
 // CasInitResult holds the result of CasInit.Execute().
 type CasInitResult struct {
-	ok         C._Bool
-	resCode    C.dkcres_type_t // response
-	subResCode C.dkcres_type_t // response(details)
+	ok         bool
+	resCode    ResCode // response
+	subResCode SubCode // response(details)
 }
 
 // String returns a text representation of the object.
@@ -155,104 +147,24 @@ func (r *CasInit) Execute(s *Session) (bool, error) {
 	if r.key == nil || len(r.key) == 0 || r.result == nil {
 		return false, fmt.Errorf("some required members nil, r.key %v, r.result %v", r.key, r.result)
 	}
-	cKey := C.CBytes(r.key)
-	defer C.free(cKey)
-	cPass := C.CString(r.pass)
-	defer C.free(unsafe.Pointer(cPass))
-	var expire *C.time_t // default
-	// WARNING: You can't set zero expire.
-	if r.expire != 0 {
-		expire = (*C.time_t)(&r.expire)
-	}
 	switch len(r.val) {
 	default:
 		return false, fmt.Errorf("unsupported data format %T", r.val)
-	case 1:
-		{
-			// bool k2hdkc_pm_cas8_init_wa(k2hdkc_chmpx_h handle, const unsigned char* pkey, size_t keylength, uint8_t val, const char* encpass, const time_t* expire)
-			cVal := (C.uint8_t)((uint8)(r.val[0]))
-			r.result.ok = C.k2hdkc_pm_cas8_init_wa(
-				s.handler,
-				(*C.uchar)(cKey),
-				C.size_t(len(r.key)),
-				cVal,
-				cPass,
-				expire)
-			r.result.resCode = C.k2hdkc_get_res_code(s.handler)
-			r.result.subResCode = C.k2hdkc_get_res_subcode(s.handler)
-			if !r.result.ok {
-				return false, errors.New("C.k2hdkc_pm_cas8_init_wa returned false")
-			}
-		}
-	case 2:
-		{
-			// bool k2hdkc_pm_cas16_init_wa(k2hdkc_chmpx_h handle, const unsigned char* pkey, size_t keylength, uint16_t val, const char* encpass, const time_t* expire)
-			cVal := (C.uint16_t)(
-				((uint16)(r.val[0])) | // first
-					((uint16)(r.val[1]) << 8))
-			r.result.ok = C.k2hdkc_pm_cas16_init_wa(
-				s.handler,
-				(*C.uchar)(cKey),
-				C.size_t(len(r.key)),
-				(C.uint16_t)(cVal),
-				cPass,
-				expire)
-			r.result.resCode = C.k2hdkc_get_res_code(s.handler)
-			r.result.subResCode = C.k2hdkc_get_res_subcode(s.handler)
-			if !r.result.ok {
-				return false, errors.New("C.k2hdkc_pm_cas16_init_wa returned false")
-			}
-		}
-	case 4:
-		{
-			// bool k2hdkc_pm_cas64_init_wa(k2hdkc_chmpx_h handle, const unsigned char* pkey, size_t keylength, uint32_t val, const char* encpass, const time_t* expire)
-			cVal := (C.uint32_t)(
-				((uint32)(r.val[0])) | // first
-					((uint32)(r.val[1]) << 8) | // second
-					((uint32)(r.val[2]) << 16) | // 3rd
-					((uint32)(r.val[3]) << 24)) // 4th
-			r.result.ok = C.k2hdkc_pm_cas32_init_wa(
-				s.handler,
-				(*C.uchar)(cKey),
-				C.size_t(len(r.key)),
-				(C.uint32_t)(cVal),
-				cPass,
-				expire)
-			r.result.resCode = C.k2hdkc_get_res_code(s.handler)
-			r.result.subResCode = C.k2hdkc_get_res_subcode(s.handler)
-			if !r.result.ok {
-				return false, errors.New("C.k2hdkc_pm_cas32_init_wa returned false")
-			}
-		}
-	case 8:
-		{
-			// bool k2hdkc_pm_cas64_init_wa(k2hdkc_chmpx_h handle, const unsigned char* pkey, size_t keylength, uint64_t val, const char* encpass, const time_t* expire)
-			cVal := (C.uint64_t)(
-				((uint64)(r.val[0])) | // 1st
-					((uint64)(r.val[1]) << 8) | // 2nd
-					((uint64)(r.val[2]) << 16) | // 3rd
-					((uint64)(r.val[3]) << 24) | // 4th
-					((uint64)(r.val[4]) << 32) | // 5th
-					((uint64)(r.val[5]) << 40) | // 6th
-					((uint64)(r.val[6]) << 48) | // 7th
-					((uint64)(r.val[7]) << 56)) // 8th
-			r.result.ok = C.k2hdkc_pm_cas64_init_wa(
-				s.handler,
-				(*C.uchar)(cKey),
-				C.size_t(len(r.key)),
-				(C.uint64_t)(cVal),
-				cPass,
-				expire)
-			r.result.resCode = C.k2hdkc_get_res_code(s.handler)
-			r.result.subResCode = C.k2hdkc_get_res_subcode(s.handler)
-			if !r.result.ok {
-				return false, errors.New("C.k2hdkc_pm_cas64_init_wa returned false")
-			}
-		}
+	case 1, 2, 4, 8:
+	}
+	res := s.do(&Request{Op: OpCasInit, Key: r.key, Val: r.val, Pass: r.pass, Expire: r.expire})
+	r.result.ok, r.result.resCode, r.result.subResCode = res.OK, res.Code, res.SubCode
+	if !r.result.ok {
+		return false, resError(fmt.Sprintf("C.k2hdkc_pm_cas%d_init_wa", len(r.val)*8), res)
 	}
 	return true, nil
 }
 
+// ExecuteContext calls Execute in a goroutine and returns the context error if the context is done before Execute returns.
+func (r *CasInit) ExecuteContext(ctx context.Context, s *Session) (bool, error) {
+	return executeContext(ctx, s, r)
+}
+
 // Result returns the pointer of CasInitResult that has the result of Execute method.
 func (r *CasInit) Result() *CasInitResult {
 	if r.result != nil {
@@ -271,9 +183,17 @@ func (r *CasInitResult) Bool() bool {
 
 // Error returns the errno of C.k2hdkc_pm_cas{8,16,32,64}_init_wa in string format.
 func (r *CasInitResult) Error() string {
-	return fmt.Sprintf("%v %v",
-		C.GoString(C.str_dkcres_result_type(r.resCode)),
-		C.GoString(C.str_dkcres_subcode_type(r.subResCode)))
+	return fmt.Sprintf("%v %v", r.resCode, r.subResCode)
+}
+
+// Code returns the result code of the request.
+func (r *CasInitResult) Code() ResCode {
+	return r.resCode
+}
+
+// SubCode returns the sub code of the request.
+func (r *CasInitResult) SubCode() SubCode {
+	return r.subResCode
 }
 
 // Local Variables:
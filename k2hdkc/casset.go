@@ -17,19 +17,11 @@
 
 package k2hdkc
 
-import (
-	// #cgo CFLAGS: -g -O2 -Wall -Wextra -Wno-unused-variable -Wno-unused-parameter -I. -I/usr/include/k2hdkc
-	// #cgo LDFLAGS: -L/usr/lib -lk2hdkc
-	// #include <stdlib.h>
-	// #include "k2hdkc.h"
-	// #include "k2hmacro.h"
-	"C"
-)
 import (
 	"bytes"
+	"context"
 	"errors"
 	"fmt"
-	"unsafe"
 )
 
 // CasSet holds arguments for C.k2hdkc_pm_cas{8,16,32,64}_set and a pointer of CasSetResult.
@@ -50,9 +42,9 @@ func (r *CasSet) String() string {
 
 // CasSetResult holds the result of CasSet.Execute().
 type CasSetResult struct {
-	ok         C._Bool
-	resCode    C.dkcres_type_t // response
-	subResCode C.dkcres_type_t // response(details)
+	ok         bool
+	resCode    ResCode // response
+	subResCode SubCode // response(details)
 }
 
 // String returns a text representation of the object.
@@ -178,131 +170,38 @@ func (r *CasSet) Execute(s *Session) (bool, error) {
 	if r.key == nil || len(r.key) == 0 || r.result == nil {
 		return false, fmt.Errorf("some required members nil, r.key %v, r.result %v", r.key, r.result)
 	}
-	cKey := C.CBytes(r.key)
-	defer C.free(cKey)
-	cPass := C.CString(r.pass)
-	defer C.free(unsafe.Pointer(cPass))
-	var expire *C.time_t
-	// WARNING: You can't set zero expire.
-	if r.expire != 0 {
-		expire = (*C.time_t)(&r.expire)
-	}
 	// length of old and new must be same.
 	if len(r.old) != len(r.new) {
 		return false, fmt.Errorf("len(r.old) %v len(r.new) %v must be same", len(r.old), len(r.new))
 	}
-
 	switch len(r.old) {
 	default:
 		return false, fmt.Errorf("unsupported data format %T", r.old)
-	case 1:
-		{
-			// bool k2hdkc_pm_cas8_set_wa(k2hdkc_chmpx_h handle, const unsigned char* pkey, size_t keylength, uint8_t oldval, uint8_t newval, const char* encpass, const time_t* expire)
-			cOld := (C.uint8_t)((uint8)(r.old[0]))
-			cNew := (C.uint8_t)((uint8)(r.new[0]))
-			r.result.ok = C.k2hdkc_pm_cas8_set_wa(
-				s.handler,
-				(*C.uchar)(cKey),
-				C.size_t(len(r.key)),
-				cOld,
-				cNew,
-				cPass,
-				expire)
-			r.result.resCode = C.k2hdkc_get_res_code(s.handler)
-			r.result.subResCode = C.k2hdkc_get_res_subcode(s.handler)
-			if !r.result.ok {
-				return false, errors.New("C.k2hdkc_pm_cas8_set_wa returned")
-			}
-		}
-	case 2:
-		{
-			// bool k2hdkc_pm_cas16_set_wa(k2hdkc_chmpx_h handle, const unsigned char* pkey, size_t keylength, uint16_t oldval, uint16_t newval, const char* encpass, const time_t* expire)
-			cOld := (C.uint16_t)(
-				((uint16)(r.old[0])) | // first
-					((uint16)(r.old[1]) << 8))
-			cNew := (C.uint16_t)(
-				((uint16)(r.new[0])) | // first
-					((uint16)(r.new[1]) << 8))
-			r.result.ok = C.k2hdkc_pm_cas16_set_wa(
-				s.handler,
-				(*C.uchar)(cKey),
-				C.size_t(len(r.key)),
-				cOld,
-				cNew,
-				cPass,
-				expire)
-			r.result.resCode = C.k2hdkc_get_res_code(s.handler)
-			r.result.subResCode = C.k2hdkc_get_res_subcode(s.handler)
-			if !r.result.ok {
-				return false, errors.New("C.k2hdkc_pm_cas16_set_wa returned")
-			}
-		}
-	case 4:
-		{
-			// bool k2hdkc_pm_cas32_set_wa(k2hdkc_chmpx_h handle, const unsigned char* pkey, size_t keylength, uint32_t oldval, uint32_t newval, const char* encpass, const time_t* expire)
-			cOld := (C.uint32_t)(
-				((uint32)(r.old[0])) | // first
-					((uint32)(r.old[1]) << 8) | // second
-					((uint32)(r.old[2]) << 16) | // 3rd
-					((uint32)(r.old[3]) << 24)) // 4th
-			cNew := (C.uint32_t)(
-				((uint32)(r.new[0])) | // first
-					((uint32)(r.new[1]) << 8) | // second
-					((uint32)(r.new[2]) << 16) | // 3rd
-					((uint32)(r.new[3]) << 24)) // 4th
-			r.result.ok = C.k2hdkc_pm_cas32_set_wa(
-				s.handler,
-				(*C.uchar)(cKey),
-				C.size_t(len(r.key)),
-				cOld,
-				cNew,
-				cPass,
-				expire)
-			r.result.resCode = C.k2hdkc_get_res_code(s.handler)
-			r.result.subResCode = C.k2hdkc_get_res_subcode(s.handler)
-			if !r.result.ok {
-				return false, errors.New("C.k2hdkc_pm_cas32_set_wa returned")
-			}
-		}
-	case 8:
-		{
-			// bool k2hdkc_pm_cas64_set_wa(k2hdkc_chmpx_h handle, const unsigned char* pkey, size_t keylength, uint64_t oldval, uint64_t newval, const char* encpass, const time_t* expire)
-			cOld := (C.uint64_t)(
-				((uint64)(r.old[0])) | // 1st
-					((uint64)(r.old[1]) << 8) | // 2nd
-					((uint64)(r.old[2]) << 16) | // 3rd
-					((uint64)(r.old[3]) << 24) | // 4th
-					((uint64)(r.old[4]) << 32) | // 5th
-					((uint64)(r.old[5]) << 40) | // 6th
-					((uint64)(r.old[6]) << 48) | // 7th
-					((uint64)(r.old[7]) << 56)) // 8th
-			cNew := (C.uint64_t)(
-				((uint64)(r.new[0])) | // 1st
-					((uint64)(r.new[1]) << 8) | // 2nd
-					((uint64)(r.new[2]) << 16) | // 3rd
-					((uint64)(r.new[3]) << 24) | // 4th
-					((uint64)(r.new[4]) << 32) | // 5th
-					((uint64)(r.new[5]) << 40) | // 6th
-					((uint64)(r.new[6]) << 48) | // 7th
-					((uint64)(r.new[7]) << 56)) // 8th
-			r.result.ok = C.k2hdkc_pm_cas64_set_wa(
-				s.handler,
-				(*C.uchar)(cKey),
-				C.size_t(len(r.key)),
-				cOld,
-				cNew,
-				cPass,
-				expire)
-			r.result.resCode = C.k2hdkc_get_res_code(s.handler)
-			r.result.subResCode = C.k2hdkc_get_res_subcode(s.handler)
-			if !r.result.ok {
-				return false, errors.New("C.k2hdkc_pm_cas64_set_wa returned")
-			}
-		}
+	case 1, 2, 4, 8:
+	}
+	res := s.do(&Request{Op: OpCasSet, Key: r.key, Old: r.old, Val: r.new, Pass: r.pass, Expire: r.expire})
+	r.result.ok, r.result.resCode, r.result.subResCode = res.OK, res.Code, res.SubCode
+	if !r.result.ok {
+		return false, casSetError(fmt.Sprintf("C.k2hdkc_pm_cas%d_set_wa", len(r.old)*8), res)
 	}
 	return true, nil
 }
 
+// ExecuteContext calls Execute in a goroutine and returns the context error if the context is done before Execute returns.
+func (r *CasSet) ExecuteContext(ctx context.Context, s *Session) (bool, error) {
+	return executeContext(ctx, s, r)
+}
+
+// casSetError returns a ResError which wraps ErrCasConflict if the server returned no detail.
+// The server doesn't set any sub code when the current value differs from the old value.
+func casSetError(op string, res *Response) error {
+	err := resError(op, res)
+	if err.SubCode == SubCodeNothing || err.SubCode == SubCodeUnknown {
+		err.err = ErrCasConflict
+	}
+	return err
+}
+
 // Result returns the pointer of CasSetResult that has the result of Execute method.
 func (r *CasSet) Result() *CasSetResult {
 	if r.result != nil {
@@ -321,9 +220,17 @@ func (r *CasSetResult) Bool() bool {
 
 // Error returns the errno of C.k2hdkc_pm_cas{8,16,32,64}_set_wa in string format.
 func (r *CasSetResult) Error() string {
-	return fmt.Sprintf("%v %v",
-		C.GoString(C.str_dkcres_result_type(r.resCode)),
-		C.GoString(C.str_dkcres_subcode_type(r.subResCode)))
+	return fmt.Sprintf("%v %v", r.resCode, r.subResCode)
+}
+
+// Code returns the result code of the request.
+func (r *CasSetResult) Code() ResCode {
+	return r.resCode
+}
+
+// SubCode returns the sub code of the request.
+func (r *CasSetResult) SubCode() SubCode {
+	return r.subResCode
 }
 
 // Local Variables:
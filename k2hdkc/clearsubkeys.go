@@ -17,16 +17,9 @@
 
 package k2hdkc
 
-import (
-	// #cgo CFLAGS: -g -O2 -Wall -Wextra -Wno-unused-variable -Wno-unused-parameter -I. -I/usr/include/k2hdkc
-	// #cgo LDFLAGS: -L/usr/lib -lk2hdkc
-	// #include <stdlib.h>
-	// #include "k2hdkc.h"
-	// #include "k2hmacro.h"
-	"C"
-)
 import (
 	"bytes"
+	"context"
 	"errors"
 	"fmt"
 )
@@ -44,9 +37,9 @@ func (r *ClearSubKeys) String() string {
 
 // ClearSubKeysResult holds the result of ClearSubKeys.Execute().
 type ClearSubKeysResult struct {
-	ok         C._Bool
-	resCode    C.dkcres_type_t // response
-	subResCode C.dkcres_type_t // response(details)
+	ok         bool
+	resCode    ResCode // response
+	subResCode SubCode // response(details)
 }
 
 // String returns a text representation of the object.
@@ -92,24 +85,19 @@ func (r *ClearSubKeys) Execute(s *Session) (bool, error) {
 	if r.key == nil || len(r.key) == 0 || r.result == nil {
 		return false, fmt.Errorf("some required members nil, r.key %v, r.result %v", r.key, r.result)
 	}
-	cKey := C.CBytes(r.key)
-	defer C.free(cKey)
-
-	r.result.ok = C.k2hdkc_pm_set_subkeys(
-		s.handler,
-		(*C.uchar)(cKey),
-		C.size_t(len(r.key)),
-		nil,
-		0)
-	r.result.resCode = C.k2hdkc_get_res_code(s.handler)
-	r.result.subResCode = C.k2hdkc_get_res_subcode(s.handler)
-
+	res := s.do(&Request{Op: OpClearSubKeys, Key: r.key})
+	r.result.ok, r.result.resCode, r.result.subResCode = res.OK, res.Code, res.SubCode
 	if r.result.ok == false {
-		return false, fmt.Errorf("C.k2hdkc_pm_set_subkeys returned %v", r.result.ok)
+		return false, resError("C.k2hdkc_pm_set_subkeys", res)
 	}
 	return true, nil
 }
 
+// ExecuteContext calls Execute in a goroutine and returns the context error if the context is done before Execute returns.
+func (r *ClearSubKeys) ExecuteContext(ctx context.Context, s *Session) (bool, error) {
+	return executeContext(ctx, s, r)
+}
+
 // Result returns the pointer of ClearSubKeysResult that has the result of Execute method.
 func (r *ClearSubKeys) Result() *ClearSubKeysResult {
 	if r.result != nil {
@@ -128,9 +116,17 @@ func (r *ClearSubKeysResult) Bool() bool {
 
 // Error returns the errno of C.k2hdkc_pm_set_subkeys in string format.
 func (r *ClearSubKeysResult) Error() string {
-	return fmt.Sprintf("%v %v",
-		C.GoString(C.str_dkcres_result_type(r.resCode)),
-		C.GoString(C.str_dkcres_subcode_type(r.subResCode)))
+	return fmt.Sprintf("%v %v", r.resCode, r.subResCode)
+}
+
+// Code returns the result code of the request.
+func (r *ClearSubKeysResult) Code() ResCode {
+	return r.resCode
+}
+
+// SubCode returns the sub code of the request.
+func (r *ClearSubKeysResult) SubCode() SubCode {
+	return r.subResCode
 }
 
 // Local Variables:
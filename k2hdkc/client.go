@@ -18,27 +18,42 @@
 package k2hdkc
 
 import (
-	// #cgo CFLAGS: -g -O2 -Wall -Wextra -Wno-unused-variable  -Wno-unused-parameter -I. -I/usr/include/k2hdkc
-	// #cgo LDFLAGS: -L/usr/lib -lk2hdkc
-	// #include "k2hdkc.h"
-	"C"
-)
-import (
+	"context"
+	"errors"
 	"fmt"
 	"os"
+	"sync"
+	"time"
 )
 
 // Client holds settings to connect a chmpx slave server.
 // Client is responsible for logging messages to a log file.
-// Client is not responsible for managing request handlers with a k2hdkc cluster.
+// Client keeps request handlers with a k2hdkc cluster in a session pool, which is
+// safe for concurrent use by multiple goroutines.
 type Client struct {
-	file        string // the configuration of the chmpx
-	port        uint16 // the control port number of the chmpx
-	cuk         string // the cloud unique key string of the chmpx
-	rejoin      bool   // reconnect automatically when the connection with the chmpx
-	rejoinRetry bool   // retry count to reconnect automatically to the chmpx
-	cleanup     bool   // delete the unnecessary information file when leaving.
-	log         *K2hLog
+	file         string              // the configuration of the chmpx
+	port         uint16              // the control port number of the chmpx
+	cuk          string              // the cloud unique key string of the chmpx
+	rejoin       bool                // reconnect automatically when the connection with the chmpx
+	rejoinRetry  bool                // retry count to reconnect automatically to the chmpx
+	cleanup      bool                // delete the unnecessary information file when leaving.
+	minSession   int                 // the number of sessions kept open in the pool
+	maxSession   int                 // the maximum number of open sessions in the pool
+	maxIdleTime  time.Duration       // sessions idle longer than this are closed
+	validator    func(*Session) bool // checks a session before reusing it
+	log          *K2hLog
+	backend      Backend       // opens connections. the native k2hdkc library is used if nil.
+	codec        Codec         // codec of SetObject and GetObject. JSONCodec is used if nil.
+	keyEncoding  KeyEncoding   // encoding of string keys.
+	retry        *RetryPolicy  // retries failed commands if not nil.
+	breaker      *Breaker      // fails commands fast while chmpx is failing if not nil.
+	interceptors []Interceptor // called instead of executing commands.
+	metrics      *Metrics      // counts commands and sessions if not nil.
+	logger       Logger        // logs the messages instead of log if not nil.
+	hashKeys     bool          // true if keys are hashed in log messages.
+	logReleased  bool          // true if Close released the reference of log.
+	mu           sync.Mutex
+	pool         *sessionPool
 }
 
 // NewClient returns the pointer to a Client after initializing members.
@@ -55,7 +70,7 @@ func NewClient(f string, p uint16) *Client {
 		fmt.Fprintf(os.Stderr, "Please install the k2hdkc package at first")
 		return nil
 	}
-	log := K2hLogInstance()
+	log := K2hLogInstance().Retain()
 	return &Client{
 		file:        f,
 		port:        p,
@@ -63,19 +78,22 @@ func NewClient(f string, p uint16) *Client {
 		rejoin:      defaultAutoRejoin,
 		rejoinRetry: defaultAutoRejoinRetry,
 		cleanup:     defaultCleanup,
+		minSession:  defaultMinSession,
+		maxSession:  defaultMaxSession,
+		maxIdleTime: defaultMaxIdleTime,
 		log:         log,
 	}
 }
 
 // String returns a text representation of the object.
 func (c *Client) String() string {
-	return fmt.Sprintf("[%v, %v, %v, %v, %v, %v]", c.file, c.port, c.rejoin, c.rejoinRetry, c.cleanup, c.log)
+	return fmt.Sprintf("[%v, %v, %v, %v, %v, %v, %v, %v, %v]", c.file, c.port, c.rejoin, c.rejoinRetry, c.cleanup, c.minSession, c.maxSession, c.maxIdleTime, c.log)
 }
 
 // CreateSession returns the pointer to a session with chmpx which handler is open..
 func (c *Client) CreateSession() (*Session, error) {
-	if _, err := os.Stat(c.file); os.IsNotExist(err) {
-		return nil, fmt.Errorf("no %v exists", c.file)
+	if err := c.checkFile(); err != nil {
+		return nil, err
 	}
 	s, err := NewSession(c)
 	if s != nil {
@@ -91,6 +109,13 @@ func (c *Client) SetChmpxFile(f string) *Client {
 	return c
 }
 
+// SetBackend sets the Backend which opens connections of the sessions.
+// The native k2hdkc library is used by default. Use it before the first request.
+func (c *Client) SetBackend(b Backend) *Client {
+	c.backend = b
+	return c
+}
+
 // SetCtlPort sets a control port.
 func (c *Client) SetCtlPort(p uint16) *Client {
 	c.port = p
@@ -121,116 +146,311 @@ func (c *Client) SetCleanup(b bool) *Client {
 	return c
 }
 
-// SetLogger sets a new logger.
+// SetMinSessions sets the number of sessions the pool keeps open even if they are idle.
+// Pool settings take effect when the pool is created, which is the first request of the Client.
+func (c *Client) SetMinSessions(n int) *Client {
+	c.minSession = n
+	return c
+}
+
+// SetMaxSessions sets the maximum number of open sessions in the pool.
+// A request waits for a free session if all of them are in use.
+func (c *Client) SetMaxSessions(n int) *Client {
+	c.maxSession = n
+	return c
+}
+
+// SetMaxIdleTime sets the duration after which an idle session is closed.
+// Zero means idle sessions are never closed.
+func (c *Client) SetMaxIdleTime(d time.Duration) *Client {
+	c.maxIdleTime = d
+	return c
+}
+
+// SetSessionValidator sets a function which checks an idle session before reusing it.
+// The session is closed and another one is used if the function returns false.
+func (c *Client) SetSessionValidator(f func(*Session) bool) *Client {
+	c.validator = f
+	return c
+}
+
+// PoolStats returns statistics of the session pool.
+func (c *Client) PoolStats() PoolStats {
+	c.mu.Lock()
+	p := c.pool
+	c.mu.Unlock()
+	if p == nil {
+		return PoolStats{}
+	}
+	return p.stats()
+}
+
+// checkFile returns an error if the chmpx configuration file doesn't exist.
+// Backends other than the native one don't need the file.
+func (c *Client) checkFile() error {
+	if c.backend != nil {
+		return nil
+	}
+	if _, err := os.Stat(c.file); os.IsNotExist(err) {
+		return fmt.Errorf("no %v exists", c.file)
+	}
+	return nil
+}
+
+// getPool returns the session pool. The pool is created at the first call.
+func (c *Client) getPool() (*sessionPool, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.pool == nil {
+		if err := c.checkFile(); err != nil {
+			return nil, err
+		}
+		if c.log == nil {
+			c.log = K2hLogInstance().Retain()
+		}
+		c.pool = newSessionPool(c)
+	}
+	return c.pool, nil
+}
+
+// acquireSession borrows a session from the pool.
+// NOTICE You must call releaseSession() to give the session back.
+func (c *Client) acquireSession(ctx context.Context) (*Session, error) {
+	p, err := c.getPool()
+	if err != nil {
+		return nil, err
+	}
+	return p.get(ctx)
+}
+
+// acquireSessionTraced borrows a session from the pool in a span of the Tracer of ctx.
+func (c *Client) acquireSessionTraced(ctx context.Context) (*Session, error) {
+	ctx, span := TracerFromContext(ctx).Start(ctx, "k2hdkc.acquire")
+	s, err := c.acquireSession(ctx)
+	if err != nil {
+		c.Logger().Log(ctx, LevelWarn, "failed to acquire a session", Field{FieldError, err})
+	}
+	span.End(err)
+	return s, err
+}
+
+// releaseSession gives the session back to the pool.
+// The session is closed if ok is false. A busy session is given back after the abandoned C call returns.
+func (c *Client) releaseSession(s *Session, ok bool) {
+	c.mu.Lock()
+	p := c.pool
+	c.mu.Unlock()
+	if p != nil {
+		p.put(s, ok)
+	}
+}
+
+// SetLogger sets a new logger. The client holds a reference of l until Close,
+// and the caller keeps its own one. nil means K2hLogInstance.
 func (c *Client) SetLogger(l *K2hLog) *Client {
 	// Assuming user want to assign a new logger.
 	if c.log != nil {
 		c.log.Close()
 	}
 	if l != nil {
-		c.log = l
+		c.log = l.Retain()
 	} else {
-		c.log = K2hLogInstance()
+		c.log = K2hLogInstance().Retain()
 	}
 	return c
 }
 
-// SetLogFile changes the logger output file.
-func (c *Client) SetLogFile(f string) *Client {
-	if c.log == nil {
-		c.log = K2hLogInstance()
+// ownLog returns the logger of the client after replacing K2hLogInstance with a copy of it,
+// so changing it doesn't change the logger of other clients.
+func (c *Client) ownLog() *K2hLog {
+	shared := K2hLogInstance()
+	if c.log == nil || c.log == shared {
+		if c.log != nil {
+			c.log.Close()
+		}
+		c.log = shared.clone()
 	}
-	c.log.SetLogFile(f)
+	return c.log
+}
+
+// SetLogFile changes the logger output file of the client. The log files of the C libraries,
+// which are global in the process, are not changed. See K2hLog.BundleLibLog.
+func (c *Client) SetLogFile(f string) *Client {
+	c.ownLog().SetLogFile(f)
+	return c
+}
+
+// SetLogRotation rotates the logger output file by the size and the age. See K2hLog.SetLogRotation.
+func (c *Client) SetLogRotation(maxSize int64, maxAge time.Duration, backups int) *Client {
+	c.ownLog().SetLogRotation(maxSize, maxAge, backups)
 	return c
 }
 
-// SetLogSeverity changes the log serverity of k2hdkc_go and it's libraries.
+// SetLogSeverity changes the log serverity of the client and the k2hdkc library.
+// The severity of the library is global in the process.
 func (c *Client) SetLogSeverity(p logSeverity) *Client {
-	if c.log == nil {
-		c.log = K2hLogInstance()
-	}
-	c.log.SetLogSeverity(p)
-	c.log.SetLibLogSeverity(LibK2hdkc)
+	l := c.ownLog()
+	l.SetLogSeverity(p)
+	l.SetLibLogSeverity(LibK2hdkc)
 	return c
 }
 
-// SetLibLogSeverity changes the log serverity of libraries.
+// SetLibLogSeverity changes the log serverity of libraries, which is global in the process.
 func (c *Client) SetLibLogSeverity(p logSeverity) *Client {
 	if c.log == nil {
-		c.log = K2hLogInstance()
+		c.log = K2hLogInstance().Retain()
 	}
 	c.log.SetLibLogSeverity(p)
 	return c
 }
 
-// Close calls the K2hLogger.Close().
+// Close closes sessions in the pool and releases the reference of the K2hLog.
+// The log file is closed when no other client holds the K2hLog.
 // NOTICE You must call Close() to avoid leaking file descriptor.
 func (c *Client) Close() {
-	if c.log != nil {
+	c.mu.Lock()
+	p := c.pool
+	release := c.log != nil && !c.logReleased
+	c.logReleased = true
+	c.mu.Unlock()
+	if p != nil {
+		p.close()
+	}
+	if release {
 		c.log.Close()
 	}
 	return
 }
 
-// Send returns a pointer of a Command.
-func (c *Client) Send(cmd Command) (Command, error) {
-	if cmd != nil {
-		s, err := NewSession(c)
-		if s != nil {
-			defer s.Close()
-		}
+// execute runs the command on a session borrowed from the pool.
+// The session is given back to the pool after the command returns.
+func (c *Client) execute(ctx context.Context, cmd Command) (bool, error) {
+	err := c.executeWith(ctx, cmd, func(ctx context.Context, cmd Command) (bool, bool, error) {
+		s, err := c.acquireSessionTraced(ctx)
 		if err != nil {
-			return nil, fmt.Errorf("failed to create a session. %v", err)
+			return false, false, fmt.Errorf("failed to create a session. %w", err)
 		}
+		ok, err := c.executeOn(ctx, s, cmd)
+		// an abandoned session can be reused after the C call returns.
+		c.releaseSession(s, reusable(ok, err))
+		return ok, true, err
+	})
+	return err == nil, err
+}
 
-		ok, err := cmd.Execute(s)
+// executeWith runs the command through the interceptors, the retry policy and the circuit breaker
+// of the client in a span. attempt executes the command once and returns sent false if it failed
+// before the command was sent.
+func (c *Client) executeWith(ctx context.Context, cmd Command, attempt func(ctx context.Context, cmd Command) (bool, bool, error)) error {
+	return c.intercept(ctx, cmd, func(ctx context.Context, cmd Command) error {
+		ctx, span := startSpan(ctx, "k2hdkc."+commandName(cmd), cmd)
+		ok, err := c.withRetry(ctx, cmd, func() (bool, bool, error) {
+			return attempt(ctx, cmd)
+		})
+		err = commandError(cmd, ok, err)
+		endSpan(span, cmd, err)
+		return err
+	})
+}
+
+// executeOn runs the command on the session.
+// The execution is counted by the metrics of the client and traced by the Tracer of ctx.
+func (c *Client) executeOn(ctx context.Context, s *Session, cmd Command) (ok bool, err error) {
+	ctx, span := startSpan(ctx, "k2hdkc.execute", cmd)
+	defer func() {
+		endSpan(span, cmd, commandError(cmd, ok, err))
+	}()
+	start := time.Now()
+	if cc, isCC := cmd.(ContextCommand); isCC {
+		ok, err = cc.ExecuteContext(ctx, s)
+	} else {
+		ok, err = executeContext(ctx, s, cmd)
+	}
+	d := time.Since(start)
+	c.metrics.observe(cmd, d, ok, err)
+	if l := c.Logger(); !ok {
+		l.Log(ctx, LevelWarn, "command failed", append(c.commandFields(cmd, err), Field{FieldSession, s.ID()}, Field{FieldError, commandError(cmd, ok, err)})...)
+	} else if l.Enabled(ctx, LevelDebug) {
+		l.Log(ctx, LevelDebug, "command executed", append(c.commandFields(cmd, err), Field{FieldSession, s.ID()}, Field{"elapsed", d})...)
+	}
+	return ok, err
+}
+
+// reusable returns true if the session can be used again after a command returned ok and err.
+func reusable(ok bool, err error) bool {
+	return ok || isContextError(err) || !isSessionError(err)
+}
+
+// isSessionError returns true if err means the chmpx session may be broken.
+func isSessionError(err error) bool {
+	var re *ResError
+	if !errors.As(err, &re) {
+		return true
+	}
+	return re.Temporary() || re.SubCode == SubCodeUnknown
+}
+
+// Send returns a pointer of a Command.
+func (c *Client) Send(cmd Command) (Command, error) {
+	return c.SendContext(context.Background(), cmd)
+}
+
+// SendContext returns a pointer of a Command.
+// It returns the context error if the context is done before the command returns.
+func (c *Client) SendContext(ctx context.Context, cmd Command) (Command, error) {
+	if cmd != nil {
+		ok, err := c.execute(ctx, cmd)
 		if !ok || err != nil {
-			return nil, fmt.Errorf("cmd.Execute(s) returned ok %v err %v", ok, err)
+			return nil, fmt.Errorf("cmd.Execute(s) returned ok %v err %w", ok, err)
 		}
-
 		return cmd, err
 	}
 	return nil, fmt.Errorf("cmd is %v", nil)
 }
 
 // Set returns a pointer of SetResult.
-func (c *Client) Set(k string, v string) (*SetResult, error) {
-	cmd, err := NewSet(k, v)
+func (c *Client) Set(k string, v string, opts ...Option) (*SetResult, error) {
+	return c.SetContext(context.Background(), k, v, opts...)
+}
+
+// SetContext returns a pointer of SetResult.
+func (c *Client) SetContext(ctx context.Context, k string, v string, opts ...Option) (*SetResult, error) {
+	o := newOptions(opts)
+	cmd, err := NewSet(c.key(k), v)
 	if err != nil {
 		return nil, fmt.Errorf("NewSet(k, v) returned err %v", err)
 	}
-
-	s, err := NewSession(c)
-	if s != nil {
-		defer s.Close()
-	} else {
-		return nil, fmt.Errorf("failed to create a session. %v", err)
-	}
-
-	if ok, err := cmd.Execute(s); !ok {
-		c.log.Warnf("NewSet.Execute(s) returned ok %v err %v", ok, err)
+	cmd.SetEncPass(o.pass)
+	cmd.SetExpire(o.expire)
+	cmd.SetRmSubKeyList(o.rmSubKeyList)
+	if ok, err := c.execute(ctx, cmd); !ok {
+		if isContextError(err) {
+			// the abandoned command may still be writing the result.
+			return nil, err
+		}
 		return cmd.result, err
 	}
 	return cmd.result, nil
 }
 
 // Get returns a pointer of GetResult.
-func (c *Client) Get(k string) (*GetResult, error) {
-	cmd, err := NewGet(k)
-	if err != nil {
-		return nil, fmt.Errorf("NewGet(k, v) returned %v", err)
-	}
+func (c *Client) Get(k string, opts ...Option) (*GetResult, error) {
+	return c.GetContext(context.Background(), k, opts...)
+}
 
-	s, err := NewSession(c)
-	if s != nil {
-		defer s.Close()
-	}
+// GetContext returns a pointer of GetResult.
+func (c *Client) GetContext(ctx context.Context, k string, opts ...Option) (*GetResult, error) {
+	o := newOptions(opts)
+	cmd, err := NewGet(c.key(k))
 	if err != nil {
-		return nil, fmt.Errorf("failed to create a session. %v", err)
+		return nil, fmt.Errorf("NewGet(k, v) returned %v", err)
 	}
-
-	if ok, err := cmd.Execute(s); !ok {
-		c.log.Warnf("NewGet.Execute(s) returned ok %v err %v", ok, err)
+	cmd.SetEncPass(o.pass)
+	if ok, err := c.execute(ctx, cmd); !ok {
+		if isContextError(err) {
+			// the abandoned command may still be writing the result.
+			return nil, err
+		}
 		return cmd.result, err
 	}
 	return cmd.Result(), nil
@@ -238,21 +458,20 @@ func (c *Client) Get(k string) (*GetResult, error) {
 
 // GetSubKeys returns a pointer of GetSubKeysResult.
 func (c *Client) GetSubKeys(k string) (*GetSubKeysResult, error) {
-	cmd, err := NewGetSubKeys(k)
-	if err != nil {
-		return nil, fmt.Errorf("NewGetSubKeys(k) returned %v", err)
-	}
+	return c.GetSubKeysContext(context.Background(), k)
+}
 
-	s, err := NewSession(c)
-	if s != nil {
-		defer s.Close()
-	}
+// GetSubKeysContext returns a pointer of GetSubKeysResult.
+func (c *Client) GetSubKeysContext(ctx context.Context, k string) (*GetSubKeysResult, error) {
+	cmd, err := NewGetSubKeys(c.key(k))
 	if err != nil {
-		return nil, fmt.Errorf("failed to create a session. %v", err)
+		return nil, fmt.Errorf("NewGetSubKeys(k) returned %v", err)
 	}
-
-	if ok, err := cmd.Execute(s); !ok {
-		c.log.Warnf("NewGetSubKeys.Execute(s) returned ok %v err %v", ok, err)
+	if ok, err := c.execute(ctx, cmd); !ok {
+		if isContextError(err) {
+			// the abandoned command may still be writing the result.
+			return nil, err
+		}
 		return cmd.result, err
 	}
 	return cmd.result, nil
@@ -260,21 +479,20 @@ func (c *Client) GetSubKeys(k string) (*GetSubKeysResult, error) {
 
 // SetSubKeys returns a pointer of SetSubKeysResult.
 func (c *Client) SetSubKeys(k string, skeys []string) (*SetSubKeysResult, error) {
-	cmd, err := NewSetSubKeys(k, skeys)
-	if err != nil {
-		return nil, fmt.Errorf("NewSetSubKeys(k, skeys) returned %v", err)
-	}
+	return c.SetSubKeysContext(context.Background(), k, skeys)
+}
 
-	s, err := NewSession(c)
-	if s != nil {
-		defer s.Close()
-	}
+// SetSubKeysContext returns a pointer of SetSubKeysResult.
+func (c *Client) SetSubKeysContext(ctx context.Context, k string, skeys []string) (*SetSubKeysResult, error) {
+	cmd, err := NewSetSubKeys(c.key(k), c.keys(skeys))
 	if err != nil {
-		return nil, fmt.Errorf("failed to create a session. %v", err)
+		return nil, fmt.Errorf("NewSetSubKeys(k, skeys) returned %v", err)
 	}
-
-	if ok, err := cmd.Execute(s); !ok {
-		c.log.Warnf("NewSetSubKeys.Execute(s) returned ok %v err %v", ok, err)
+	if ok, err := c.execute(ctx, cmd); !ok {
+		if isContextError(err) {
+			// the abandoned command may still be writing the result.
+			return nil, err
+		}
 		return cmd.result, err
 	}
 	return cmd.result, nil
@@ -0,0 +1,435 @@
+//
+// k2hdkc_go
+//
+// Copyright 2018 Yahoo Japan Corporation.
+//
+// Go driver for k2hdkc that is a highly available and scalable distributed
+// KVS clustering system. For k2hdkc, see
+// https://github.com/yahoojapan/k2hdkc for the details.
+//
+// For the full copyright and license information, please view
+// the license file that was distributed with this source code.
+//
+// AUTHOR:   Hirotaka Wakabayashi
+// CREATE:   Fri, 16 Oct 2026
+// REVISION:
+//
+
+package k2hdkc
+
+import (
+	"context"
+	"fmt"
+)
+
+// do executes the cmd and returns the error if the cmd fails.
+func (c *Client) do(ctx context.Context, cmd Command) error {
+	ok, err := c.execute(ctx, cmd)
+	if !ok {
+		if err == nil {
+			err = fmt.Errorf("%T.Execute(s) returned ok %v", cmd, ok)
+		}
+		return err
+	}
+	return nil
+}
+
+// casBytes returns the little endian bytes of v in the length of ct.
+func casBytes(v uint64, ct CasType) ([]byte, error) {
+	var n int
+	switch ct {
+	default:
+		return nil, fmt.Errorf("ct %v must be any of CasType{8,16,32,64}", ct)
+	case CasType8:
+		n = 1
+	case CasType16:
+		n = 2
+	case CasType32:
+		n = 4
+	case CasType64:
+		n = 8
+	}
+	if n < 8 && v>>(uint(n)*8) != 0 {
+		return nil, fmt.Errorf("v %v overflows CasType%v", v, (uint8)(ct))
+	}
+	b := make([]byte, n)
+	for i := 0; i < n; i++ {
+		b[i] = (uint8)(v >> (uint64)(8*i))
+	}
+	return b, nil
+}
+
+// casUint64 returns the value of the little endian bytes.
+func casUint64(b []byte) uint64 {
+	var v uint64
+	for i := len(b) - 1; i >= 0; i-- {
+		v = v<<8 | (uint64)(b[i])
+	}
+	return v
+}
+
+// GetValue returns the value of the key. ErrNotFound returns if the key doesn't exist.
+func (c *Client) GetValue(k string, opts ...Option) ([]byte, error) {
+	return c.GetValueContext(context.Background(), k, opts...)
+}
+
+// GetValueContext returns the value of the key. ErrNotFound returns if the key doesn't exist.
+func (c *Client) GetValueContext(ctx context.Context, k string, opts ...Option) ([]byte, error) {
+	o := newOptions(opts)
+	cmd, err := NewGet(c.key(k))
+	if err != nil {
+		return nil, fmt.Errorf("NewGet(k) returned %v", err)
+	}
+	cmd.SetEncPass(o.pass)
+	if err := c.do(ctx, cmd); err != nil {
+		return nil, err
+	}
+	if r := cmd.Result(); r.SubCode() == SubCodeNoData {
+		return nil, &ResError{Op: "C.k2hdkc_pm_get_value_wp", Code: r.Code(), SubCode: r.SubCode()}
+	}
+	return cmd.Result().Bytes(), nil
+}
+
+// SetValue sets the value of the key.
+func (c *Client) SetValue(k string, v []byte, opts ...Option) error {
+	return c.SetValueContext(context.Background(), k, v, opts...)
+}
+
+// SetValueContext sets the value of the key.
+func (c *Client) SetValueContext(ctx context.Context, k string, v []byte, opts ...Option) error {
+	o := newOptions(opts)
+	cmd, err := NewSet(c.key(k), v)
+	if err != nil {
+		return fmt.Errorf("NewSet(k, v) returned %v", err)
+	}
+	cmd.SetEncPass(o.pass)
+	cmd.SetExpire(o.expire)
+	cmd.SetRmSubKeyList(o.rmSubKeyList)
+	return c.do(ctx, cmd)
+}
+
+// Remove removes the key.
+func (c *Client) Remove(k string) error {
+	return c.RemoveContext(context.Background(), k)
+}
+
+// RemoveContext removes the key.
+func (c *Client) RemoveContext(ctx context.Context, k string) error {
+	cmd, err := NewRemove(c.key(k))
+	if err != nil {
+		return fmt.Errorf("NewRemove(k) returned %v", err)
+	}
+	return c.do(ctx, cmd)
+}
+
+// Rename renames the old key to the new key. WithParentKey replaces the key in the subkey list of the parent key.
+func (c *Client) Rename(oldKey string, newKey string, opts ...Option) error {
+	return c.RenameContext(context.Background(), oldKey, newKey, opts...)
+}
+
+// RenameContext renames the old key to the new key. WithParentKey replaces the key in the subkey list of the parent key.
+func (c *Client) RenameContext(ctx context.Context, oldKey string, newKey string, opts ...Option) error {
+	o := newOptions(opts)
+	cmd, err := NewRename(c.key(oldKey), c.key(newKey))
+	if err != nil {
+		return fmt.Errorf("NewRename(oldKey, newKey) returned %v", err)
+	}
+	if o.parentKey != "" {
+		if ok, err := cmd.SetParentKey(c.key(o.parentKey)); !ok {
+			return fmt.Errorf("SetParentKey(p) returned %v", err)
+		}
+	}
+	cmd.SetEncPass(o.pass)
+	cmd.SetExpire(o.expire)
+	return c.do(ctx, cmd)
+}
+
+// AddSubKey sets the value of the subkey and adds the subkey to the subkey list of the key.
+func (c *Client) AddSubKey(k string, sk string, sv []byte, opts ...Option) error {
+	return c.AddSubKeyContext(context.Background(), k, sk, sv, opts...)
+}
+
+// AddSubKeyContext sets the value of the subkey and adds the subkey to the subkey list of the key.
+func (c *Client) AddSubKeyContext(ctx context.Context, k string, sk string, sv []byte, opts ...Option) error {
+	o := newOptions(opts)
+	cmd, err := NewAddSubKey(c.key(k), c.key(sk), sv)
+	if err != nil {
+		return fmt.Errorf("NewAddSubKey(k, sk, sv) returned %v", err)
+	}
+	cmd.SetEncPass(o.pass)
+	cmd.SetExpire(o.expire)
+	return c.do(ctx, cmd)
+}
+
+// RemoveSubKey removes the subkey from the subkey list of the key. WithNest removes the subkeys of the subkey too.
+func (c *Client) RemoveSubKey(k string, sk string, opts ...Option) error {
+	return c.RemoveSubKeyContext(context.Background(), k, sk, opts...)
+}
+
+// RemoveSubKeyContext removes the subkey from the subkey list of the key. WithNest removes the subkeys of the subkey too.
+func (c *Client) RemoveSubKeyContext(ctx context.Context, k string, sk string, opts ...Option) error {
+	o := newOptions(opts)
+	cmd, err := NewRemoveSubKey(c.key(k), c.key(sk))
+	if err != nil {
+		return fmt.Errorf("NewRemoveSubKey(k, sk) returned %v", err)
+	}
+	cmd.SetNest(o.nest)
+	return c.do(ctx, cmd)
+}
+
+// ClearSubKeys removes all subkeys of the key.
+func (c *Client) ClearSubKeys(k string) error {
+	return c.ClearSubKeysContext(context.Background(), k)
+}
+
+// ClearSubKeysContext removes all subkeys of the key.
+func (c *Client) ClearSubKeysContext(ctx context.Context, k string) error {
+	cmd, err := NewClearSubKeys(c.key(k))
+	if err != nil {
+		return fmt.Errorf("NewClearSubKeys(k) returned %v", err)
+	}
+	return c.do(ctx, cmd)
+}
+
+// SetAll sets the value and the subkey list of the key.
+func (c *Client) SetAll(k string, v []byte, skeys []string, opts ...Option) error {
+	return c.SetAllContext(context.Background(), k, v, skeys, opts...)
+}
+
+// SetAllContext sets the value and the subkey list of the key.
+func (c *Client) SetAllContext(ctx context.Context, k string, v []byte, skeys []string, opts ...Option) error {
+	o := newOptions(opts)
+	cmd, err := NewSetAll(c.key(k), v, c.keys(skeys))
+	if err != nil {
+		return fmt.Errorf("NewSetAll(k, v, skeys) returned %v", err)
+	}
+	cmd.SetEncPass(o.pass)
+	cmd.SetExpire(o.expire)
+	return c.do(ctx, cmd)
+}
+
+// GetAttrs returns the attributes of the key in text format.
+func (c *Client) GetAttrs(k string) (map[string]string, error) {
+	return c.GetAttrsContext(context.Background(), k)
+}
+
+// GetAttrsContext returns the attributes of the key in text format.
+func (c *Client) GetAttrsContext(ctx context.Context, k string) (map[string]string, error) {
+	cmd, err := NewGetAttrs(c.key(k))
+	if err != nil {
+		return nil, fmt.Errorf("NewGetAttrs(k) returned %v", err)
+	}
+	if err := c.do(ctx, cmd); err != nil {
+		return nil, err
+	}
+	return cmd.Result().String(), nil
+}
+
+// GetSubKeyList returns the subkeys of the key decoded in the KeyEncoding of the client.
+// ErrNotFound returns if the key doesn't exist.
+func (c *Client) GetSubKeyList(k string) ([]string, error) {
+	return c.GetSubKeyListContext(context.Background(), k)
+}
+
+// GetSubKeyListContext returns the subkeys of the key decoded in the KeyEncoding of the client.
+// ErrNotFound returns if the key doesn't exist.
+func (c *Client) GetSubKeyListContext(ctx context.Context, k string) ([]string, error) {
+	cmd, err := NewGetSubKeys(c.key(k))
+	if err != nil {
+		return nil, fmt.Errorf("NewGetSubKeys(k) returned %v", err)
+	}
+	if err := c.do(ctx, cmd); err != nil {
+		return nil, err
+	}
+	return cmd.Result().Keys(c.KeyEncoding()), nil
+}
+
+// CasInit initializes the cas value of the key. WithCasType sets the length of the value.
+func (c *Client) CasInit(k string, v uint64, opts ...Option) error {
+	return c.CasInitContext(context.Background(), k, v, opts...)
+}
+
+// CasInitContext initializes the cas value of the key. WithCasType sets the length of the value.
+func (c *Client) CasInitContext(ctx context.Context, k string, v uint64, opts ...Option) error {
+	o := newOptions(opts)
+	val, err := casBytes(v, o.casType)
+	if err != nil {
+		return err
+	}
+	cmd, err := NewCasInitWithValue(c.key(k), val)
+	if err != nil {
+		return fmt.Errorf("NewCasInitWithValue(k, v) returned %v", err)
+	}
+	cmd.SetEncPass(o.pass)
+	cmd.SetExpire(o.expire)
+	return c.do(ctx, cmd)
+}
+
+// CasGet returns the cas value of the key. WithCasType sets the length of the value.
+func (c *Client) CasGet(k string, opts ...Option) (uint64, error) {
+	return c.CasGetContext(context.Background(), k, opts...)
+}
+
+// CasGetContext returns the cas value of the key. WithCasType sets the length of the value.
+func (c *Client) CasGetContext(ctx context.Context, k string, opts ...Option) (uint64, error) {
+	o := newOptions(opts)
+	if _, err := casBytes(0, o.casType); err != nil {
+		return 0, err
+	}
+	cmd, err := NewCasGet(c.key(k))
+	if err != nil {
+		return 0, fmt.Errorf("NewCasGet(k) returned %v", err)
+	}
+	cmd.SetEncPass(o.pass)
+	cmd.SetValueLen((uint8)(o.casType))
+	if err := c.do(ctx, cmd); err != nil {
+		return 0, err
+	}
+	return casUint64(cmd.Result().Bytes()), nil
+}
+
+// CasSet swaps the cas value of the key if the current value is the old value. ErrCasConflict returns if it differs.
+func (c *Client) CasSet(k string, oldVal uint64, newVal uint64, opts ...Option) error {
+	return c.CasSetContext(context.Background(), k, oldVal, newVal, opts...)
+}
+
+// CasSetContext swaps the cas value of the key if the current value is the old value. ErrCasConflict returns if it differs.
+func (c *Client) CasSetContext(ctx context.Context, k string, oldVal uint64, newVal uint64, opts ...Option) error {
+	o := newOptions(opts)
+	old, err := casBytes(oldVal, o.casType)
+	if err != nil {
+		return err
+	}
+	new, err := casBytes(newVal, o.casType)
+	if err != nil {
+		return err
+	}
+	cmd, err := NewCasSet(c.key(k), old, new)
+	if err != nil {
+		return fmt.Errorf("NewCasSet(k, o, n) returned %v", err)
+	}
+	cmd.SetEncPass(o.pass)
+	cmd.SetExpire(o.expire)
+	return c.do(ctx, cmd)
+}
+
+// CasIncrement increments the cas value of the key.
+func (c *Client) CasIncrement(k string, opts ...Option) error {
+	return c.casIncDec(context.Background(), k, true, opts)
+}
+
+// CasIncrementContext increments the cas value of the key.
+func (c *Client) CasIncrementContext(ctx context.Context, k string, opts ...Option) error {
+	return c.casIncDec(ctx, k, true, opts)
+}
+
+// CasDecrement decrements the cas value of the key.
+func (c *Client) CasDecrement(k string, opts ...Option) error {
+	return c.casIncDec(context.Background(), k, false, opts)
+}
+
+// CasDecrementContext decrements the cas value of the key.
+func (c *Client) CasDecrementContext(ctx context.Context, k string, opts ...Option) error {
+	return c.casIncDec(ctx, k, false, opts)
+}
+
+// casIncDec increments the cas value of the key if incr is true, otherwise decrements it.
+func (c *Client) casIncDec(ctx context.Context, k string, incr bool, opts []Option) error {
+	o := newOptions(opts)
+	cmd, err := NewCasIncDec(c.key(k), incr)
+	if err != nil {
+		return fmt.Errorf("NewCasIncDec(k, i) returned %v", err)
+	}
+	cmd.SetEncPass(o.pass)
+	cmd.SetExpire(o.expire)
+	return c.do(ctx, cmd)
+}
+
+// QueuePush pushes the value to the queue of the prefix. WithLifo pushes the value to the head of the queue.
+func (c *Client) QueuePush(p string, v []byte, opts ...Option) error {
+	return c.QueuePushContext(context.Background(), p, v, opts...)
+}
+
+// QueuePushContext pushes the value to the queue of the prefix. WithLifo pushes the value to the head of the queue.
+func (c *Client) QueuePushContext(ctx context.Context, p string, v []byte, opts ...Option) error {
+	o := newOptions(opts)
+	cmd, err := NewQueuePush(c.key(p), v)
+	if err != nil {
+		return fmt.Errorf("NewQueuePush(p, v) returned %v", err)
+	}
+	return c.queuePush(ctx, cmd, o)
+}
+
+// KeyQueuePush pushes the key and the value to the queue of the prefix. QueuePop with WithKeyQueue pops them.
+func (c *Client) KeyQueuePush(p string, k string, v []byte, opts ...Option) error {
+	return c.KeyQueuePushContext(context.Background(), p, k, v, opts...)
+}
+
+// KeyQueuePushContext pushes the key and the value to the queue of the prefix. QueuePop with WithKeyQueue pops them.
+func (c *Client) KeyQueuePushContext(ctx context.Context, p string, k string, v []byte, opts ...Option) error {
+	o := newOptions(opts)
+	cmd, err := NewQueuePushWithKey(c.key(p), v, c.key(k))
+	if err != nil {
+		return fmt.Errorf("NewQueuePushWithKey(p, v, k) returned %v", err)
+	}
+	return c.queuePush(ctx, cmd, o)
+}
+
+// queuePush applies the options to the cmd and executes it.
+func (c *Client) queuePush(ctx context.Context, cmd *QueuePush, o *options) error {
+	cmd.UseFifo(o.fifo)
+	cmd.SetEncPass(o.pass)
+	cmd.SetExpire(o.expire)
+	return c.do(ctx, cmd)
+}
+
+// QueuePop pops a value from the queue of the prefix. The key is nil unless WithKeyQueue is set.
+// ErrNotFound returns if the queue is empty.
+func (c *Client) QueuePop(p string, opts ...Option) (key []byte, val []byte, err error) {
+	return c.QueuePopContext(context.Background(), p, opts...)
+}
+
+// QueuePopContext pops a value from the queue of the prefix. The key is nil unless WithKeyQueue is set.
+// ErrNotFound returns if the queue is empty.
+func (c *Client) QueuePopContext(ctx context.Context, p string, opts ...Option) (key []byte, val []byte, err error) {
+	o := newOptions(opts)
+	cmd, err := NewQueuePopWithKeyQueue(c.key(p), o.keyQueue)
+	if err != nil {
+		return nil, nil, fmt.Errorf("NewQueuePopWithKeyQueue(p, kq) returned %v", err)
+	}
+	cmd.UseFifo(o.fifo)
+	cmd.SetEncPass(o.pass)
+	if err := c.do(ctx, cmd); err != nil {
+		return nil, nil, err
+	}
+	r := cmd.Result()
+	if len(r.ValBytes()) == 0 && r.SubCode() == SubCodeNoData {
+		return nil, nil, &ResError{Op: "C.k2hdkc_pm_q_pop_wp", Code: r.Code(), SubCode: r.SubCode()}
+	}
+	return r.KeyBytes(), r.ValBytes(), nil
+}
+
+// QueueRemove removes n values from the queue of the prefix.
+func (c *Client) QueueRemove(p string, n int64, opts ...Option) error {
+	return c.QueueRemoveContext(context.Background(), p, n, opts...)
+}
+
+// QueueRemoveContext removes n values from the queue of the prefix.
+func (c *Client) QueueRemoveContext(ctx context.Context, p string, n int64, opts ...Option) error {
+	o := newOptions(opts)
+	cmd, err := NewQueueRemoveWithKeyQueue(c.key(p), n, o.keyQueue)
+	if err != nil {
+		return fmt.Errorf("NewQueueRemoveWithKeyQueue(p, n, kq) returned %v", err)
+	}
+	cmd.UseFifo(o.fifo)
+	cmd.SetEncPass(o.pass)
+	return c.do(ctx, cmd)
+}
+
+// Local Variables:
+// c-basic-offset: 4
+// tab-width: 4
+// indent-tabs-mode: t
+// End:
+// vim600: noexpandtab sw=4 ts=4 fdm=marker
+// vim<600: noexpandtab sw=4 ts=4
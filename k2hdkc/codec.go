@@ -0,0 +1,178 @@
+//
+// k2hdkc_go
+//
+// Copyright 2018 Yahoo Japan Corporation.
+//
+// Go driver for k2hdkc that is a highly available and scalable distributed
+// KVS clustering system. For k2hdkc, see
+// https://github.com/yahoojapan/k2hdkc for the details.
+//
+// For the full copyright and license information, please view
+// the license file that was distributed with this source code.
+//
+// AUTHOR:   Hirotaka Wakabayashi
+// CREATE:   Fri, 16 Oct 2026
+// REVISION:
+//
+
+package k2hdkc
+
+import (
+	"bytes"
+	"context"
+	"encoding/gob"
+	"encoding/json"
+	"fmt"
+)
+
+// Codec converts Go values to values in the k2hdkc cluster and back.
+type Codec interface {
+	Marshal(v interface{}) ([]byte, error)
+	Unmarshal(data []byte, v interface{}) error
+}
+
+var (
+	// JSONCodec marshals values with encoding/json. It's the default codec of a Client.
+	JSONCodec Codec = jsonCodec{}
+	// GobCodec marshals values with encoding/gob.
+	GobCodec Codec = gobCodec{}
+	// RawCodec saves a string or []byte as it is and reads it into a *string or *[]byte.
+	RawCodec Codec = rawCodec{}
+	// StringCodec saves a string or []byte with the terminating NUL which NewSet appends to a
+	// string and the C clients expect. It reads into a *string or *[]byte without the NUL.
+	StringCodec Codec = stringCodec{}
+)
+
+// jsonCodec implements Codec with encoding/json.
+type jsonCodec struct{}
+
+func (jsonCodec) Marshal(v interface{}) ([]byte, error)      { return json.Marshal(v) }
+func (jsonCodec) Unmarshal(data []byte, v interface{}) error { return json.Unmarshal(data, v) }
+func (jsonCodec) String() string                             { return "json" }
+
+// gobCodec implements Codec with encoding/gob.
+type gobCodec struct{}
+
+func (gobCodec) Marshal(v interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(v); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (gobCodec) Unmarshal(data []byte, v interface{}) error {
+	return gob.NewDecoder(bytes.NewReader(data)).Decode(v)
+}
+
+func (gobCodec) String() string { return "gob" }
+
+// rawCodec implements Codec which doesn't convert bytes.
+type rawCodec struct{}
+
+func (rawCodec) Marshal(v interface{}) ([]byte, error) {
+	switch v := v.(type) {
+	case []byte:
+		return append([]byte{}, v...), nil
+	case string:
+		return []byte(v), nil
+	}
+	return nil, fmt.Errorf("unsupported data format %T", v)
+}
+
+func (rawCodec) Unmarshal(data []byte, v interface{}) error {
+	switch v := v.(type) {
+	case *[]byte:
+		*v = append([]byte{}, data...)
+		return nil
+	case *string:
+		*v = string(data)
+		return nil
+	}
+	return fmt.Errorf("unsupported data format %T", v)
+}
+
+func (rawCodec) String() string { return "raw" }
+
+// stringCodec implements Codec of NUL terminated strings.
+type stringCodec struct{}
+
+func (stringCodec) Marshal(v interface{}) ([]byte, error) {
+	b, err := rawCodec{}.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	return append(b, 0), nil
+}
+
+func (stringCodec) Unmarshal(data []byte, v interface{}) error {
+	return rawCodec{}.Unmarshal(trimNul(data), v)
+}
+
+func (stringCodec) String() string { return "string" }
+
+// trimNul returns the bytes without the terminating NUL if it exists.
+func trimNul(b []byte) []byte {
+	if n := len(b); n > 0 && b[n-1] == 0 {
+		return b[:n-1]
+	}
+	return b
+}
+
+// SetCodec sets the default codec of SetObject and GetObject. JSONCodec is the default.
+func (c *Client) SetCodec(codec Codec) *Client {
+	c.codec = codec
+	return c
+}
+
+// codecOf returns the codec of the options or the default codec of the client.
+func (c *Client) codecOf(o *options) Codec {
+	if o.codec != nil {
+		return o.codec
+	}
+	if c.codec != nil {
+		return c.codec
+	}
+	return JSONCodec
+}
+
+// SetObject marshals the value with the codec and sets it to the key. WithCodec overrides the codec of the client.
+func (c *Client) SetObject(k string, v interface{}, opts ...Option) error {
+	return c.SetObjectContext(context.Background(), k, v, opts...)
+}
+
+// SetObjectContext marshals the value with the codec and sets it to the key. WithCodec overrides the codec of the client.
+func (c *Client) SetObjectContext(ctx context.Context, k string, v interface{}, opts ...Option) error {
+	o := newOptions(opts)
+	val, err := c.codecOf(o).Marshal(v)
+	if err != nil {
+		return fmt.Errorf("Marshal(v) returned %v", err)
+	}
+	return c.SetValueContext(ctx, k, val, opts...)
+}
+
+// GetObject gets the value of the key and unmarshals it into the destination. ErrNotFound returns if the key doesn't exist.
+func (c *Client) GetObject(k string, dst interface{}, opts ...Option) error {
+	return c.GetObjectContext(context.Background(), k, dst, opts...)
+}
+
+// GetObjectContext gets the value of the key and unmarshals it into the destination. ErrNotFound returns if the key doesn't exist.
+func (c *Client) GetObjectContext(ctx context.Context, k string, dst interface{}, opts ...Option) error {
+	o := newOptions(opts)
+	val, err := c.GetValueContext(ctx, k, opts...)
+	if err != nil {
+		return err
+	}
+	if err := c.codecOf(o).Unmarshal(val, dst); err != nil {
+		return fmt.Errorf("Unmarshal(val, dst) returned %v", err)
+	}
+	return nil
+}
+
+// Local Variables:
+// c-basic-offset: 4
+// tab-width: 4
+// indent-tabs-mode: t
+// End:
+// vim600: noexpandtab sw=4 ts=4 fdm=marker
+// vim<600: noexpandtab sw=4 ts=4
@@ -0,0 +1,113 @@
+//
+// k2hdkc_go
+//
+// Copyright 2018 Yahoo Japan Corporation.
+//
+// Go driver for k2hdkc that is a highly available and scalable distributed
+// KVS clustering system. For k2hdkc, see
+// https://github.com/yahoojapan/k2hdkc for the details.
+//
+// For the full copyright and license information, please view
+// the license file that was distributed with this source code.
+//
+// AUTHOR:   Hirotaka Wakabayashi
+// CREATE:   Fri, 16 Oct 2026
+// REVISION:
+//
+
+package k2hdkc_test
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/yahoojapan/k2hdkc_go/k2hdkc"
+	"github.com/yahoojapan/k2hdkc_go/k2hdkc/k2hdkctest"
+)
+
+type codecPerson struct {
+	Name string
+	Age  int
+}
+
+// TestCodecObject tests SetObject and GetObject with each codec.
+func TestCodecObject(t *testing.T) {
+	s := k2hdkctest.NewServer()
+	c := s.NewClient()
+	defer c.Close()
+
+	want := codecPerson{Name: "k2hdkc", Age: 3}
+	for _, codec := range []k2hdkc.Codec{k2hdkc.JSONCodec, k2hdkc.GobCodec} {
+		if err := c.SetObject("person", want, k2hdkc.WithCodec(codec)); err != nil {
+			t.Fatalf("c.SetObject(person, %v, %v) = %v", want, codec, err)
+		}
+		var got codecPerson
+		if err := c.GetObject("person", &got, k2hdkc.WithCodec(codec)); err != nil || got != want {
+			t.Errorf("c.GetObject(person, &got, %v) = %v, got %v, want %v", codec, err, got, want)
+		}
+	}
+	var got codecPerson
+	if err := c.GetObject("nobody", &got); !errors.Is(err, k2hdkc.ErrNotFound) {
+		t.Errorf("c.GetObject(nobody, &got) = %v, want ErrNotFound", err)
+	}
+}
+
+// TestCodecString tests the NUL convention is up to the codec.
+func TestCodecString(t *testing.T) {
+	s := k2hdkctest.NewServer()
+	c := s.NewClient().SetCodec(k2hdkc.RawCodec)
+	defer c.Close()
+
+	if err := c.SetObject("raw", "abc"); err != nil {
+		t.Fatalf("c.SetObject(raw, abc) = %v", err)
+	}
+	if v, _ := c.GetValue("raw"); !bytes.Equal(v, []byte("abc")) {
+		t.Errorf("the raw value = %q, want abc", v)
+	}
+	if r, err := c.Get("raw"); err != nil || r.String() != "abc" {
+		t.Errorf("c.Get(raw).String() = %q, %v, want abc", r.String(), err)
+	}
+	if err := c.SetObject("str", "abc", k2hdkc.WithCodec(k2hdkc.StringCodec)); err != nil {
+		t.Fatalf("c.SetObject(str, abc) = %v", err)
+	}
+	if v, _ := c.GetValue("str"); !bytes.Equal(v, []byte("abc\x00")) {
+		t.Errorf("the string value = %q, want abc with NUL", v)
+	}
+	var got string
+	if err := c.GetObject("str", &got, k2hdkc.WithCodec(k2hdkc.StringCodec)); err != nil || got != "abc" {
+		t.Errorf("c.GetObject(str, &got) = %v, got %q, want abc", err, got)
+	}
+	if err := c.SetObject("num", 1); err == nil {
+		t.Errorf("c.SetObject(num, 1) with RawCodec = nil, want an error")
+	}
+}
+
+// TestCodecQueuePopString tests the strings of a popped key and value lose only a terminating NUL.
+func TestCodecQueuePopString(t *testing.T) {
+	s := k2hdkctest.NewServer()
+	c := s.NewClient()
+	defer c.Close()
+
+	if err := c.KeyQueuePush("kq", "key", []byte("abc")); err != nil {
+		t.Fatalf("c.KeyQueuePush(kq, key, abc) = %v", err)
+	}
+	cmd, err := k2hdkc.NewQueuePopWithKeyQueue(k2hdkc.EncodeKey("kq", c.KeyEncoding()), true)
+	if err != nil {
+		t.Fatalf("k2hdkc.NewQueuePopWithKeyQueue(kq, true) = %v", err)
+	}
+	if _, err := c.Send(cmd); err != nil {
+		t.Fatalf("c.Send(cmd) = %v", err)
+	}
+	if r := cmd.Result(); r.KeyString() != "key" || r.ValString() != "abc" {
+		t.Errorf("cmd.Result() = (%q, %q), want key and abc", r.KeyString(), r.ValString())
+	}
+}
+
+// Local Variables:
+// c-basic-offset: 4
+// tab-width: 4
+// indent-tabs-mode: t
+// End:
+// vim600: noexpandtab sw=4 ts=4 fdm=marker
+// vim<600: noexpandtab sw=4 ts=4
@@ -17,6 +17,11 @@
 
 package k2hdkc
 
+import (
+	"context"
+	"errors"
+)
+
 // Result is the interface of getting a result of a Command.
 type Result interface {
 	Bool() bool
@@ -29,6 +34,54 @@ type Command interface {
 	String() string
 }
 
+// ContextCommand is the interface of commands which can be canceled by a context.
+// All commands in this package implement ContextCommand.
+type ContextCommand interface {
+	Command
+	ExecuteContext(ctx context.Context, s *Session) (bool, error)
+}
+
+// executeContext calls cmd.Execute(s) in a goroutine and returns ctx.Err() if the context is done before it returns.
+// A C call can't be interrupted. The session stays busy until the abandoned call really returns,
+// so the session pool doesn't reuse it and the result of cmd must not be used.
+func executeContext(ctx context.Context, s *Session, cmd Command) (bool, error) {
+	if s == nil {
+		return false, errors.New("session is nil")
+	}
+	if err := ctx.Err(); err != nil {
+		return false, err
+	}
+	// waits for a call abandoned before.
+	if err := s.wait(ctx); err != nil {
+		return false, err
+	}
+	if ctx.Done() == nil {
+		// context.Background() never be canceled.
+		return cmd.Execute(s)
+	}
+	var ok bool
+	var err error
+	done := s.begin()
+	go func() {
+		defer close(done)
+		ok, err = cmd.Execute(s)
+	}()
+	select {
+	case <-done:
+		return ok, err
+	case <-ctx.Done():
+		if s.client != nil {
+			s.client.Logger().Log(ctx, LevelWarn, "command abandoned", Field{FieldCommand, commandName(cmd)}, Field{FieldSession, s.ID()}, Field{FieldError, ctx.Err()})
+		}
+		return false, ctx.Err()
+	}
+}
+
+// isContextError returns true if err is caused by a canceled context or an exceeded deadline.
+func isContextError(err error) bool {
+	return errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded)
+}
+
 // Local Variables:
 // c-basic-offset: 4
 // tab-width: 4
@@ -0,0 +1,183 @@
+//
+// k2hdkc_go
+//
+// Copyright 2018 Yahoo Japan Corporation.
+//
+// Go driver for k2hdkc that is a highly available and scalable distributed
+// KVS clustering system. For k2hdkc, see
+// https://github.com/yahoojapan/k2hdkc for the details.
+//
+// For the full copyright and license information, please view
+// the license file that was distributed with this source code.
+//
+// AUTHOR:   Hirotaka Wakabayashi
+// CREATE:   Fri, 16 Oct 2026
+// REVISION:
+//
+
+package k2hdkc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+const (
+	defaultConsumerConcurrency = 1
+	defaultConsumerMinBackoff  = 10 * time.Millisecond
+	defaultConsumerMaxBackoff  = time.Second
+)
+
+// Message is a value popped by a Consumer. Key is nil unless the queue is a key queue.
+type Message struct {
+	Prefix string
+	Key    []byte
+	Val    []byte
+}
+
+// String returns a text representation of the object.
+func (m Message) String() string {
+	return fmt.Sprintf("[%v, %v, %v]", m.Prefix, m.Key, m.Val)
+}
+
+// Consumer pops values from the queue of a prefix with workers and delivers them to a handler or a channel.
+// Popping removes a value from the queue. A value whose handler fails is logged and lost.
+type Consumer struct {
+	client      *Client
+	prefix      string
+	opts        []Option
+	concurrency int
+	minBackoff  time.Duration
+	maxBackoff  time.Duration
+}
+
+// NewConsumer returns the pointer to a Consumer of the queue of the prefix.
+// WithFifo, WithLifo, WithKeyQueue and WithPass set how values are popped.
+func NewConsumer(c *Client, prefix string, opts ...Option) *Consumer {
+	return &Consumer{
+		client:      c,
+		prefix:      prefix,
+		opts:        opts,
+		concurrency: defaultConsumerConcurrency,
+		minBackoff:  defaultConsumerMinBackoff,
+		maxBackoff:  defaultConsumerMaxBackoff,
+	}
+}
+
+// String returns a text representation of the object.
+func (cs *Consumer) String() string {
+	return fmt.Sprintf("[%v, %v, %v, %v]", cs.prefix, cs.concurrency, cs.minBackoff, cs.maxBackoff)
+}
+
+// SetConcurrency sets the number of workers.
+func (cs *Consumer) SetConcurrency(n int) *Consumer {
+	if n > 0 {
+		cs.concurrency = n
+	}
+	return cs
+}
+
+// SetBackoff sets the wait time of a worker after it finds the queue empty. The wait time
+// doubles from min up to max while the queue is empty.
+func (cs *Consumer) SetBackoff(min time.Duration, max time.Duration) *Consumer {
+	if min > 0 {
+		cs.minBackoff = min
+	}
+	if max >= cs.minBackoff {
+		cs.maxBackoff = max
+	}
+	return cs
+}
+
+// Run delivers values to the handler until the context is done. Run returns after
+// the workers finish the values they have popped. The handlers are called with a
+// context which isn't canceled by the shutdown.
+func (cs *Consumer) Run(ctx context.Context, handler func(context.Context, Message) error) error {
+	if handler == nil {
+		return errors.New("handler is nil")
+	}
+	hctx := detachedContext{ctx}
+	cs.run(ctx, func(m Message) {
+		if err := handler(hctx, m); err != nil {
+			cs.client.Logger().Log(ctx, LevelWarn, "handler failed", Field{"prefix", m.Prefix}, cs.client.keyField(m.Key), Field{FieldError, err})
+		}
+	})
+	return ctx.Err()
+}
+
+// Start delivers values through the returned channel until the context is done.
+// The channel is closed after the workers deliver the values they have popped,
+// so keep receiving until it's closed.
+func (cs *Consumer) Start(ctx context.Context) <-chan Message {
+	ch := make(chan Message)
+	go func() {
+		defer close(ch)
+		cs.run(ctx, func(m Message) {
+			ch <- m
+		})
+	}()
+	return ch
+}
+
+// run starts the workers and waits for them.
+func (cs *Consumer) run(ctx context.Context, deliver func(Message)) {
+	var wg sync.WaitGroup
+	for i := 0; i < cs.concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			cs.work(ctx, deliver)
+		}()
+	}
+	wg.Wait()
+}
+
+// work pops values until the context is done.
+func (cs *Consumer) work(ctx context.Context, deliver func(Message)) {
+	// a pop which has started completes even if the context is done, not to lose the value.
+	pctx := detachedContext{ctx}
+	backoff := time.Duration(0)
+	for ctx.Err() == nil {
+		key, val, err := cs.client.QueuePopContext(pctx, cs.prefix, cs.opts...)
+		if err == nil {
+			backoff = 0
+			deliver(Message{Prefix: cs.prefix, Key: key, Val: val})
+			continue
+		}
+		if !errors.Is(err, ErrNotFound) {
+			cs.client.Logger().Log(ctx, LevelWarn, "failed to pop a message", Field{"prefix", cs.prefix}, Field{FieldError, err})
+		}
+		if backoff == 0 {
+			backoff = cs.minBackoff
+		} else if backoff *= 2; backoff > cs.maxBackoff {
+			backoff = cs.maxBackoff
+		}
+		t := time.NewTimer(backoff)
+		select {
+		case <-t.C:
+		case <-ctx.Done():
+			t.Stop()
+		}
+	}
+}
+
+// detachedContext keeps the values of the parent but is never canceled.
+type detachedContext struct {
+	parent context.Context
+}
+
+func (detachedContext) Deadline() (time.Time, bool)         { return time.Time{}, false }
+func (detachedContext) Done() <-chan struct{}               { return nil }
+func (detachedContext) Err() error                          { return nil }
+func (c detachedContext) Value(key interface{}) interface{} { return c.parent.Value(key) }
+
+// Local Variables:
+// c-basic-offset: 4
+// tab-width: 4
+// indent-tabs-mode: t
+// End:
+// vim600: noexpandtab sw=4 ts=4 fdm=marker
+// vim<600: noexpandtab sw=4 ts=4
@@ -0,0 +1,105 @@
+//
+// k2hdkc_go
+//
+// Copyright 2018 Yahoo Japan Corporation.
+//
+// Go driver for k2hdkc that is a highly available and scalable distributed
+// KVS clustering system. For k2hdkc, see
+// https://github.com/yahoojapan/k2hdkc for the details.
+//
+// For the full copyright and license information, please view
+// the license file that was distributed with this source code.
+//
+// AUTHOR:   Hirotaka Wakabayashi
+// CREATE:   Fri, 16 Oct 2026
+// REVISION:
+//
+
+package k2hdkc_test
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/yahoojapan/k2hdkc_go/k2hdkc"
+	"github.com/yahoojapan/k2hdkc_go/k2hdkc/k2hdkctest"
+)
+
+// TestConsumerRun tests the handler receives all values and Run drains the workers.
+func TestConsumerRun(t *testing.T) {
+	s := k2hdkctest.NewServer()
+	c := s.NewClient()
+	defer c.Close()
+
+	want := []string{"1", "2", "3", "4", "5"}
+	for _, v := range want {
+		if err := c.QueuePush("q", []byte(v)); err != nil {
+			t.Fatalf("c.QueuePush(q, %v) = %v", v, err)
+		}
+	}
+	var mu sync.Mutex
+	var got []string
+	ctx, cancel := context.WithCancel(context.Background())
+	cs := k2hdkc.NewConsumer(c, "q").SetConcurrency(3).SetBackoff(time.Millisecond, 5*time.Millisecond)
+	err := cs.Run(ctx, func(hctx context.Context, m k2hdkc.Message) error {
+		mu.Lock()
+		defer mu.Unlock()
+		got = append(got, string(m.Val))
+		if len(got) == len(want) {
+			cancel()
+		}
+		if hctx.Err() != nil {
+			t.Errorf("the handler context is canceled")
+		}
+		return nil
+	})
+	if err != context.Canceled {
+		t.Errorf("cs.Run(ctx, handler) = %v, want context.Canceled", err)
+	}
+	sort.Strings(got)
+	if len(got) != len(want) {
+		t.Fatalf("the handler received %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("the handler received %v, want %v", got, want)
+		}
+	}
+}
+
+// TestConsumerStart tests values are delivered through the channel in the FIFO order of a key queue.
+func TestConsumerStart(t *testing.T) {
+	s := k2hdkctest.NewServer()
+	c := s.NewClient()
+	defer c.Close()
+
+	for _, k := range []string{"a", "b"} {
+		if err := c.KeyQueuePush("kq", k, []byte(k)); err != nil {
+			t.Fatalf("c.KeyQueuePush(kq, %v, %v) = %v", k, k, err)
+		}
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	ch := k2hdkc.NewConsumer(c, "kq", k2hdkc.WithKeyQueue(), k2hdkc.WithFifo()).Start(ctx)
+	for _, want := range []string{"a", "b"} {
+		m := <-ch
+		if string(m.Val) != want || k2hdkc.DecodeKey(m.Key, c.KeyEncoding()) != want {
+			t.Errorf("<-ch = %v, want %v", m, want)
+		}
+	}
+	cancel()
+	for m := range ch {
+		t.Errorf("<-ch = %v after the queue is empty", m)
+	}
+}
+
+// Local Variables:
+// c-basic-offset: 4
+// tab-width: 4
+// indent-tabs-mode: t
+// End:
+// vim600: noexpandtab sw=4 ts=4 fdm=marker
+// vim<600: noexpandtab sw=4 ts=4
@@ -16,6 +16,10 @@
 //
 
 // Package k2hdkc implements a k2hdkc client.
+//
+// Sessions execute commands on a Backend. The native backend calls the k2hdkc C library
+// and is built with cgo unless the nok2hdkc build tag is set. The k2hdkctest package
+// provides an in-memory Backend for tests.
 package k2hdkc
 
 // Local Variables:
@@ -0,0 +1,176 @@
+//
+// k2hdkc_go
+//
+// Copyright 2018 Yahoo Japan Corporation.
+//
+// Go driver for k2hdkc that is a highly available and scalable distributed
+// KVS clustering system. For k2hdkc, see
+// https://github.com/yahoojapan/k2hdkc for the details.
+//
+// For the full copyright and license information, please view
+// the license file that was distributed with this source code.
+//
+// AUTHOR:   Hirotaka Wakabayashi
+// CREATE:   Fri, 16 Oct 2026
+// REVISION:
+//
+
+// Package election implements leader election on a k2hdkc cluster.
+//
+// Candidates of an election campaign for a lease based mutex of the lock package.
+// The holder of the mutex is the leader. The leader renews its lease in the
+// background and steps down before the lease expires if it can't renew it, for
+// example while chmpx rejoins, so two leaders never overlap longer than the lease.
+// Init creates the keys of the election once before candidates campaign.
+package election
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/yahoojapan/k2hdkc_go/k2hdkc"
+	"github.com/yahoojapan/k2hdkc_go/k2hdkc/lock"
+)
+
+const defaultObserveInterval = time.Second
+
+// Leader holds the identity and the fencing token of a leader. The zero Leader means no leader.
+type Leader struct {
+	ID    string
+	Token uint64
+}
+
+// String returns a text representation of the object.
+func (l Leader) String() string {
+	return fmt.Sprintf("[%v, %v]", l.ID, l.Token)
+}
+
+// Election is a candidate of the election of the name.
+type Election struct {
+	id       string
+	mutex    *lock.Mutex
+	mu       sync.Mutex
+	interval time.Duration
+}
+
+// Init creates the keys of the election of the name unless they exist. Call it once
+// before any candidate campaigns. See lock.Init.
+func Init(ctx context.Context, c *k2hdkc.Client, name string) error {
+	return lock.Init(ctx, c, name)
+}
+
+// New returns the pointer to a candidate of the election of the name. The id identifies the candidate.
+// The options set the lease of the leader.
+func New(c *k2hdkc.Client, name string, id string, opts ...lock.Option) *Election {
+	opts = append(opts, lock.WithOwner(id))
+	return &Election{
+		id:       id,
+		mutex:    lock.NewMutex(c, name, opts...),
+		interval: defaultObserveInterval,
+	}
+}
+
+// String returns a text representation of the object.
+func (e *Election) String() string {
+	return fmt.Sprintf("[%v, %v]", e.id, e.mutex)
+}
+
+// SetObserveInterval sets the interval Observe reads the leader.
+func (e *Election) SetObserveInterval(d time.Duration) *Election {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if d > 0 {
+		e.interval = d
+	}
+	return e
+}
+
+// Campaign waits until the candidate becomes the leader or the context is done.
+// A candidate which has lost the leadership can campaign again without Resign.
+// lock.ErrNotInitialized returns if Init hasn't been called for the name.
+func (e *Election) Campaign(ctx context.Context) error {
+	return e.mutex.Lock(ctx)
+}
+
+// Resign gives up the leadership.
+func (e *Election) Resign() error {
+	return e.ResignContext(context.Background())
+}
+
+// ResignContext gives up the leadership. lock.ErrLeaseLost returns if the leadership has been lost.
+func (e *Election) ResignContext(ctx context.Context) error {
+	return e.mutex.UnlockContext(ctx)
+}
+
+// IsLeader returns true if the candidate is the leader and its lease hasn't been lost.
+func (e *Election) IsLeader() bool {
+	ch := e.mutex.Lost()
+	if ch == nil {
+		return false
+	}
+	select {
+	case <-ch:
+		return false
+	default:
+		return true
+	}
+}
+
+// Done returns a channel which is closed when the candidate loses or gives up the leadership.
+// A nil channel returns if the candidate isn't the leader.
+func (e *Election) Done() <-chan struct{} {
+	return e.mutex.Lost()
+}
+
+// Leader returns the current leader. The zero Leader returns if there is no leader.
+func (e *Election) Leader(ctx context.Context) (Leader, error) {
+	id, token, err := e.mutex.Holder(ctx)
+	if err != nil {
+		return Leader{}, err
+	}
+	return Leader{ID: id, Token: token}, nil
+}
+
+// Observe returns a channel which receives the leader when it changes. The current
+// leader is sent first. The channel is closed when the context is done.
+// Errors reading the leader are ignored and the leader is read again.
+func (e *Election) Observe(ctx context.Context) <-chan Leader {
+	ch := make(chan Leader)
+	e.mu.Lock()
+	interval := e.interval
+	e.mu.Unlock()
+	go func() {
+		defer close(ch)
+		t := time.NewTicker(interval)
+		defer t.Stop()
+		var last Leader
+		first := true
+		for {
+			if l, err := e.Leader(ctx); err == nil && (first || l != last) {
+				select {
+				case ch <- l:
+				case <-ctx.Done():
+					return
+				}
+				last = l
+				first = false
+			}
+			select {
+			case <-t.C:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return ch
+}
+
+// Local Variables:
+// c-basic-offset: 4
+// tab-width: 4
+// indent-tabs-mode: t
+// End:
+// vim600: noexpandtab sw=4 ts=4 fdm=marker
+// vim<600: noexpandtab sw=4 ts=4
@@ -0,0 +1,203 @@
+//
+// k2hdkc_go
+//
+// Copyright 2018 Yahoo Japan Corporation.
+//
+// Go driver for k2hdkc that is a highly available and scalable distributed
+// KVS clustering system. For k2hdkc, see
+// https://github.com/yahoojapan/k2hdkc for the details.
+//
+// For the full copyright and license information, please view
+// the license file that was distributed with this source code.
+//
+// AUTHOR:   Hirotaka Wakabayashi
+// CREATE:   Fri, 16 Oct 2026
+// REVISION:
+//
+
+package election
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/yahoojapan/k2hdkc_go/k2hdkc/k2hdkctest"
+	"github.com/yahoojapan/k2hdkc_go/k2hdkc/lock"
+)
+
+// TestElection tests a candidate becomes the leader after the leader resigns.
+func TestElection(t *testing.T) {
+	s := k2hdkctest.NewServer()
+	c := s.NewClient()
+	defer c.Close()
+	if err := Init(context.Background(), c, "election"); err != nil {
+		t.Fatalf("Init(ctx, c, election) = %v", err)
+	}
+
+	e1 := New(c, "election", "node1")
+	e2 := New(c, "election", "node2", lock.WithRetryInterval(5*time.Millisecond))
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	obs := e2.SetObserveInterval(5 * time.Millisecond).Observe(ctx)
+	if l := <-obs; l != (Leader{}) {
+		t.Errorf("the first leader = %v, want no leader", l)
+	}
+
+	if err := e1.Campaign(ctx); err != nil {
+		t.Fatalf("e1.Campaign(ctx) = %v", err)
+	}
+	if !e1.IsLeader() || e2.IsLeader() {
+		t.Errorf("e1.IsLeader() = %v, e2.IsLeader() = %v, want true and false", e1.IsLeader(), e2.IsLeader())
+	}
+	if l := <-obs; l.ID != "node1" {
+		t.Errorf("the observed leader = %v, want node1", l)
+	}
+	if l, err := e2.Leader(ctx); err != nil || l.ID != "node1" {
+		t.Errorf("e2.Leader(ctx) = (%v, %v), want node1", l, err)
+	}
+
+	done := make(chan error, 1)
+	go func() { done <- e2.Campaign(ctx) }()
+	time.Sleep(20 * time.Millisecond)
+	select {
+	case err := <-done:
+		t.Fatalf("e2.Campaign(ctx) = %v while e1 is the leader", err)
+	default:
+	}
+	resigned := e1.Done()
+	if err := e1.Resign(); err != nil {
+		t.Fatalf("e1.Resign() = %v", err)
+	}
+	<-resigned
+	if err := <-done; err != nil {
+		t.Fatalf("e2.Campaign(ctx) = %v", err)
+	}
+	for l := range obs {
+		if l.ID == "node2" {
+			break
+		}
+	}
+	if err := e2.Resign(); err != nil {
+		t.Errorf("e2.Resign() = %v", err)
+	}
+}
+
+// TestElectionRewin tests a candidate which has lost the leadership becomes the leader again.
+func TestElectionRewin(t *testing.T) {
+	s := k2hdkctest.NewServer()
+	c := s.NewClient()
+	defer c.Close()
+	if err := Init(context.Background(), c, "election"); err != nil {
+		t.Fatalf("Init(ctx, c, election) = %v", err)
+	}
+
+	e1 := New(c, "election", "node1", lock.WithRenewInterval(10*time.Millisecond), lock.WithRetryInterval(5*time.Millisecond))
+	e2 := New(c, "election", "node2", lock.WithRetryInterval(5*time.Millisecond))
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if err := e1.Campaign(ctx); err != nil {
+		t.Fatalf("e1.Campaign(ctx) = %v", err)
+	}
+	l, err := e1.Leader(ctx)
+	if err != nil || l.ID != "node1" {
+		t.Fatalf("e1.Leader(ctx) = (%v, %v), want node1", l, err)
+	}
+	lost := e1.Done()
+	// node2 takes the leadership after the lease of node1 is removed.
+	if err := c.Remove("election/lease/" + strconv.FormatUint(l.Token, 10)); err != nil {
+		t.Fatalf("c.Remove(lease) = %v", err)
+	}
+	if err := e2.Campaign(ctx); err != nil {
+		t.Fatalf("e2.Campaign(ctx) = %v", err)
+	}
+	select {
+	case <-lost:
+	case <-ctx.Done():
+		t.Fatalf("e1.Done() is not closed after node2 became the leader")
+	}
+	if e1.IsLeader() {
+		t.Errorf("e1.IsLeader() = true after the leadership is lost")
+	}
+	if err := e2.Resign(); err != nil {
+		t.Fatalf("e2.Resign() = %v", err)
+	}
+	if err := e1.Campaign(ctx); err != nil {
+		t.Fatalf("e1.Campaign(ctx) after the leadership is lost = %v", err)
+	}
+	if !e1.IsLeader() {
+		t.Errorf("e1.IsLeader() = false after e1 won again")
+	}
+	if l2, err := e2.Leader(ctx); err != nil || l2.ID != "node1" || l2.Token <= l.Token {
+		t.Errorf("e2.Leader(ctx) = (%v, %v), want node1 with a token greater than %v", l2, err, l.Token)
+	}
+	if err := e1.Resign(); err != nil {
+		t.Errorf("e1.Resign() = %v", err)
+	}
+}
+
+// TestElectionInit tests candidates racing on a fresh election never lead until Init
+// creates the keys, and then only one of them leads.
+func TestElectionInit(t *testing.T) {
+	s := k2hdkctest.NewServer()
+	c := s.NewClient()
+	defer c.Close()
+
+	es := []*Election{
+		New(c, "fresh", "node1", lock.WithRetryInterval(5*time.Millisecond)),
+		New(c, "fresh", "node2", lock.WithRetryInterval(5*time.Millisecond)),
+	}
+	campaign := func() chan error {
+		ch := make(chan error, len(es))
+		for _, e := range es {
+			go func(e *Election) { ch <- e.Campaign(context.Background()) }(e)
+		}
+		return ch
+	}
+	ch := campaign()
+	for range es {
+		if err := <-ch; !errors.Is(err, lock.ErrNotInitialized) {
+			t.Errorf("e.Campaign(ctx) of a fresh election = %v, want ErrNotInitialized", err)
+		}
+	}
+	for _, e := range es {
+		if e.IsLeader() {
+			t.Errorf("%v leads a fresh election", e)
+		}
+	}
+
+	if err := Init(context.Background(), c, "fresh"); err != nil {
+		t.Fatalf("Init(ctx, c, fresh) = %v", err)
+	}
+	ch = campaign()
+	if err := <-ch; err != nil {
+		t.Fatalf("e.Campaign(ctx) = %v", err)
+	}
+	leader, other := es[0], es[1]
+	if !leader.IsLeader() {
+		leader, other = other, leader
+	}
+	if !leader.IsLeader() || other.IsLeader() {
+		t.Fatalf("leaders = %v and %v, want only one", leader.IsLeader(), other.IsLeader())
+	}
+	if err := leader.Resign(); err != nil {
+		t.Fatalf("leader.Resign() = %v", err)
+	}
+	if err := <-ch; err != nil || !other.IsLeader() {
+		t.Errorf("other.Campaign(ctx) = %v, leader %v, want the leader", err, other.IsLeader())
+	}
+	if err := other.Resign(); err != nil {
+		t.Errorf("other.Resign() = %v", err)
+	}
+}
+
+// Local Variables:
+// c-basic-offset: 4
+// tab-width: 4
+// indent-tabs-mode: t
+// End:
+// vim600: noexpandtab sw=4 ts=4 fdm=marker
+// vim<600: noexpandtab sw=4 ts=4
@@ -0,0 +1,218 @@
+//
+// k2hdkc_go
+//
+// Copyright 2018 Yahoo Japan Corporation.
+//
+// Go driver for k2hdkc that is a highly available and scalable distributed
+// KVS clustering system. For k2hdkc, see
+// https://github.com/yahoojapan/k2hdkc for the details.
+//
+// For the full copyright and license information, please view
+// the license file that was distributed with this source code.
+//
+// AUTHOR:   Hirotaka Wakabayashi
+// CREATE:   Fri, 16 Oct 2026
+// REVISION:
+//
+
+package k2hdkc
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ResCode is the result code of a request, which is the higher 32 bits of dkcres_type_t.
+type ResCode uint8
+
+// ResCodeSuccess means the request has been processed by the cluster.
+// ResCodeError means the request has failed.
+// ResCodeUnknown means the library returned a result code this package doesn't know.
+const (
+	ResCodeSuccess ResCode = iota
+	ResCodeError
+	ResCodeUnknown
+)
+
+var resCodeText = map[ResCode]string{
+	ResCodeSuccess: "DKC_RES_SUCCESS",
+	ResCodeError:   "DKC_RES_ERROR",
+	ResCodeUnknown: "DKC_RES_UNKNOWN",
+}
+
+// String returns the name of the result code in the k2hdkc library.
+func (c ResCode) String() string {
+	if s, ok := resCodeText[c]; ok {
+		return s
+	}
+	return resCodeText[ResCodeUnknown]
+}
+
+// SubCode is the detail of a result code, which is the lower 32 bits of dkcres_type_t.
+type SubCode uint8
+
+// SubCodeNothing means no detail.
+// SubCodeNoData means the key doesn't exist.
+// SubCodeNoSubKey means the subkey doesn't exist.
+// SubCodeInvalid means the request has invalid parameters.
+// SubCodeNoMemory means the server or the library couldn't allocate memory.
+// SubCodeInternal means the server failed to process the request.
+// SubCodeNoServer means no server node is available to process the request.
+// SubCodeSend means the library failed to send the request.
+// SubCodeReceive means the library failed to receive the response.
+// SubCodeTimeout means the library timed out waiting for the response.
+// SubCodeCasMismatch means the current CAS value differs from the expected one.
+// SubCodeExists means the key already exists.
+// SubCodeUnknown means the library returned a sub code this package doesn't know.
+const (
+	SubCodeNothing SubCode = iota
+	SubCodeNoData
+	SubCodeNoSubKey
+	SubCodeInvalid
+	SubCodeNoMemory
+	SubCodeInternal
+	SubCodeNoServer
+	SubCodeSend
+	SubCodeReceive
+	SubCodeTimeout
+	SubCodeCasMismatch
+	SubCodeExists
+	SubCodeUnknown
+)
+
+var subCodeText = map[SubCode]string{
+	SubCodeNothing:     "DKC_RES_SUBCODE_NOTHING",
+	SubCodeNoData:      "DKC_RES_SUBCODE_NODATA",
+	SubCodeNoSubKey:    "DKC_RES_SUBCODE_NOSUBKEY",
+	SubCodeInvalid:     "DKC_RES_SUBCODE_INVAL",
+	SubCodeNoMemory:    "DKC_RES_SUBCODE_NOMEM",
+	SubCodeInternal:    "DKC_RES_SUBCODE_INTERNAL",
+	SubCodeNoServer:    "DKC_RES_SUBCODE_NOSERVER",
+	SubCodeSend:        "DKC_RES_SUBCODE_SENDERR",
+	SubCodeReceive:     "DKC_RES_SUBCODE_RCVERR",
+	SubCodeTimeout:     "DKC_RES_SUBCODE_TIMEOUT",
+	SubCodeCasMismatch: "DKC_RES_SUBCODE_DIFFVAL",
+	SubCodeExists:      "DKC_RES_SUBCODE_ALREADYEXIST",
+	SubCodeUnknown:     "DKC_RES_SUBCODE_UNKNOWN",
+}
+
+// String returns the name of the sub code in the k2hdkc library.
+func (c SubCode) String() string {
+	if s, ok := subCodeText[c]; ok {
+		return s
+	}
+	return subCodeText[SubCodeUnknown]
+}
+
+// Err returns the sentinel error of the sub code. nil returns if no sentinel error matches.
+func (c SubCode) Err() error {
+	switch c {
+	case SubCodeNoData, SubCodeNoSubKey:
+		return ErrNotFound
+	case SubCodeInvalid:
+		return ErrInvalid
+	case SubCodeNoMemory, SubCodeInternal:
+		return ErrInternal
+	case SubCodeNoServer:
+		return ErrNoServer
+	case SubCodeSend, SubCodeReceive:
+		return ErrCommunication
+	case SubCodeTimeout:
+		return ErrTimeout
+	case SubCodeCasMismatch:
+		return ErrCasConflict
+	case SubCodeExists:
+		return ErrExists
+	default:
+		return nil
+	}
+}
+
+// Sentinel errors which errors.Is can compare with the errors of Execute methods.
+var (
+	ErrNotFound      = errors.New("k2hdkc: key not found")
+	ErrCasConflict   = errors.New("k2hdkc: cas value conflict")
+	ErrNoServer      = errors.New("k2hdkc: no server available")
+	ErrCommunication = errors.New("k2hdkc: communication failure")
+	ErrTimeout       = errors.New("k2hdkc: timeout")
+	ErrInvalid       = errors.New("k2hdkc: invalid request")
+	ErrInternal      = errors.New("k2hdkc: internal error")
+	ErrExists        = errors.New("k2hdkc: key already exists")
+)
+
+// OpenError is the error of a Backend which failed to connect to the cluster. Connecting
+// again may succeed, for example after chmpx has started.
+type OpenError struct {
+	Err error
+}
+
+// Error returns a text representation of the error.
+func (e *OpenError) Error() string {
+	return fmt.Sprintf("failed to open a connection. %v", e.Err)
+}
+
+// Unwrap returns the cause of the error.
+func (e *OpenError) Unwrap() error {
+	return e.Err
+}
+
+// isOpenError returns true if err means a Backend failed to connect to the cluster.
+func isOpenError(err error) bool {
+	var oe *OpenError
+	return errors.As(err, &oe)
+}
+
+// ResError holds the result code and the sub code of a failed request.
+type ResError struct {
+	Op         string  // the C function name
+	Code       ResCode // result code
+	SubCode    SubCode // result code(details)
+	RawCode    uint64  // dkcres_type_t result code of the library, which may be unknown to ResCode
+	RawSubCode uint64  // dkcres_type_t sub code of the library, which may be unknown to SubCode
+	err        error   // the sentinel error if it differs from SubCode.Err().
+}
+
+// resError returns a ResError of the C function op with the response codes.
+func resError(op string, res *Response) *ResError {
+	return &ResError{
+		Op:         op,
+		Code:       res.Code,
+		SubCode:    res.SubCode,
+		RawCode:    res.RawCode,
+		RawSubCode: res.RawSubCode,
+	}
+}
+
+// Error returns a text representation of the error.
+func (e *ResError) Error() string {
+	if e.Code == ResCodeUnknown || e.SubCode == SubCodeUnknown {
+		return fmt.Sprintf("%v returned false. %v %v (%#x %#x)", e.Op, e.Code, e.SubCode, e.RawCode, e.RawSubCode)
+	}
+	return fmt.Sprintf("%v returned false. %v %v", e.Op, e.Code, e.SubCode)
+}
+
+// Unwrap returns the sentinel error of the sub code.
+func (e *ResError) Unwrap() error {
+	if e.err != nil {
+		return e.err
+	}
+	return e.SubCode.Err()
+}
+
+// Temporary returns true if the request may succeed when it's sent again.
+func (e *ResError) Temporary() bool {
+	switch e.Unwrap() {
+	case ErrNoServer, ErrCommunication, ErrTimeout:
+		return true
+	default:
+		return false
+	}
+}
+
+// Local Variables:
+// c-basic-offset: 4
+// tab-width: 4
+// indent-tabs-mode: t
+// End:
+// vim600: noexpandtab sw=4 ts=4 fdm=marker
+// vim<600: noexpandtab sw=4 ts=4
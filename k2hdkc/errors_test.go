@@ -0,0 +1,74 @@
+//
+// k2hdkc_go
+//
+// Copyright 2018 Yahoo Japan Corporation.
+//
+// Go driver for k2hdkc that is a highly available and scalable distributed
+// KVS clustering system. For k2hdkc, see
+// https://github.com/yahoojapan/k2hdkc for the details.
+//
+// For the full copyright and license information, please view
+// the license file that was distributed with this source code.
+//
+// AUTHOR:   Hirotaka Wakabayashi
+// CREATE:   Fri, 16 Oct 2026
+// REVISION:
+//
+
+package k2hdkc_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/yahoojapan/k2hdkc_go/k2hdkc"
+)
+
+// TestErrorsSubCode ensures sub codes map to sentinel errors.
+func TestErrorsSubCode(t *testing.T) {
+	testData := []struct {
+		c k2hdkc.SubCode
+		s string
+		e error
+	}{
+		{c: k2hdkc.SubCodeNothing, s: "DKC_RES_SUBCODE_NOTHING", e: nil},
+		{c: k2hdkc.SubCodeNoData, s: "DKC_RES_SUBCODE_NODATA", e: k2hdkc.ErrNotFound},
+		{c: k2hdkc.SubCodeNoServer, s: "DKC_RES_SUBCODE_NOSERVER", e: k2hdkc.ErrNoServer},
+		{c: k2hdkc.SubCodeCasMismatch, s: "DKC_RES_SUBCODE_DIFFVAL", e: k2hdkc.ErrCasConflict},
+		{c: k2hdkc.SubCode(255), s: "DKC_RES_SUBCODE_UNKNOWN", e: nil},
+	}
+	for _, d := range testData {
+		if s := d.c.String(); s != d.s {
+			t.Errorf("SubCode(%d).String() = %v, want %v", d.c, s, d.s)
+		}
+		if err := d.c.Err(); err != d.e {
+			t.Errorf("SubCode(%d).Err() = %v, want %v", d.c, err, d.e)
+		}
+	}
+}
+
+// TestErrorsRawCode ensures a ResError tells the raw codes the package doesn't know.
+func TestErrorsRawCode(t *testing.T) {
+	err := &k2hdkc.ResError{
+		Op:         "C.k2hdkc_pm_get_value_wp",
+		Code:       k2hdkc.ResCodeError,
+		SubCode:    k2hdkc.SubCodeUnknown,
+		RawCode:    0x100000000,
+		RawSubCode: 0x2a,
+	}
+	if s := err.Error(); !strings.Contains(s, "0x2a") {
+		t.Errorf("err.Error() = %v, want the raw sub code 0x2a", s)
+	}
+	if errors.Unwrap(err) != nil {
+		t.Errorf("errors.Unwrap(err) = %v, want nil", errors.Unwrap(err))
+	}
+}
+
+// Local Variables:
+// c-basic-offset: 4
+// tab-width: 4
+// indent-tabs-mode: t
+// End:
+// vim600: noexpandtab sw=4 ts=4 fdm=marker
+// vim<600: noexpandtab sw=4 ts=4
@@ -17,19 +17,11 @@
 
 package k2hdkc
 
-import (
-	// #cgo CFLAGS: -g -O2 -Wall -Wextra -Wno-unused-variable -Wno-unused-parameter -I. -I/usr/include/k2hdkc
-	// #cgo LDFLAGS: -L/usr/lib -lk2hdkc
-	// #include <stdlib.h>
-	// #include "k2hdkc.h"
-	// #include "k2hmacro.h"
-	"C"
-)
 import (
 	"bytes"
+	"context"
 	"errors"
 	"fmt"
-	"unsafe"
 )
 
 // Get holds arguments for C.k2hdkc_pm_get_value_wp and a pointer of GetResult.
@@ -47,9 +39,9 @@ func (r *Get) String() string {
 // GetResult holds the result of Get.Execute().
 type GetResult struct {
 	val        []byte
-	ok         C._Bool
-	resCode    C.dkcres_type_t // response
-	subResCode C.dkcres_type_t // response(details)
+	ok         bool
+	resCode    ResCode // response
+	subResCode SubCode // response(details)
 }
 
 // String returns a text representation of the object.
@@ -103,31 +95,20 @@ func (r *Get) Execute(s *Session) (bool, error) {
 	if r.key == nil || len(r.key) == 0 || r.result == nil {
 		return false, fmt.Errorf("some required members nil, r.key %v, r.result %v", r.key, r.result)
 	}
-	cKey := C.CBytes(r.key) // func C.CBytes([]byte) unsafe.Pointer
-	defer C.free(cKey)
-	cPass := C.CString(r.pass) // func C.CString(string) *C.char
-	defer C.free(unsafe.Pointer(cPass))
-
-	var cRetValue *C.uchar // value:(*main._Ctype_char)(nil) type:*main._Ctype_char
-	var valLen C.size_t    // valLen value:0x0 type:main._Ctype_size_t
-	r.result.ok = C.k2hdkc_pm_get_value_wp(
-		s.handler,
-		(*C.uchar)(cKey),
-		C.size_t(len(r.key)),
-		cPass,
-		&cRetValue,
-		&valLen)
-	defer C.free(unsafe.Pointer(cRetValue))
-	r.result.resCode = C.k2hdkc_get_res_code(s.handler)
-	r.result.subResCode = C.k2hdkc_get_res_subcode(s.handler)
-
+	res := s.do(&Request{Op: OpGet, Key: r.key, Pass: r.pass})
+	r.result.ok, r.result.resCode, r.result.subResCode = res.OK, res.Code, res.SubCode
 	if r.result.ok == false {
-		return false, fmt.Errorf("C.k2hdkc_pm_get_value_wp() = %v", r.result.ok)
+		return false, resError("C.k2hdkc_pm_get_value_wp", res)
 	}
-	r.result.val = C.GoBytes(unsafe.Pointer(cRetValue), C.int(valLen))
+	r.result.val = res.Val
 	return true, nil
 }
 
+// ExecuteContext calls Execute in a goroutine and returns the context error if the context is done before Execute returns.
+func (r *Get) ExecuteContext(ctx context.Context, s *Session) (bool, error) {
+	return executeContext(ctx, s, r)
+}
+
 // Result returns the pointer of GetResult that has the result of Execute method.
 func (r *Get) Result() *GetResult {
 	if r.result != nil {
@@ -142,12 +123,9 @@ func (r *GetResult) Bytes() []byte {
 }
 
 // String returns the C.k2hdkc_pm_get_value_wp response in text format.
+// Only the terminating NUL is removed. Values saved without it are returned as they are.
 func (r *GetResult) String() string {
-	valLen := len(r.val)
-	if valLen > 0 {
-		return string(r.val[:valLen-1]) // make a new string without the null termination
-	}
-	return ""
+	return string(trimNul(r.val))
 }
 
 // Bool returns true if C.k2hdkc_pm_get_value_wp has been successfully called.
@@ -160,9 +138,17 @@ func (r *GetResult) Bool() bool {
 
 // Error returns the errno of C.k2hdkc_pm_get_value_wp in string format.
 func (r *GetResult) Error() string {
-	return fmt.Sprintf("%v %v",
-		C.GoString(C.str_dkcres_result_type(r.resCode)),
-		C.GoString(C.str_dkcres_subcode_type(r.subResCode)))
+	return fmt.Sprintf("%v %v", r.resCode, r.subResCode)
+}
+
+// Code returns the result code of the request.
+func (r *GetResult) Code() ResCode {
+	return r.resCode
+}
+
+// SubCode returns the sub code of the request.
+func (r *GetResult) SubCode() SubCode {
+	return r.subResCode
 }
 
 // Local Variables:
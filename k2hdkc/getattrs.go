@@ -17,20 +17,12 @@
 
 package k2hdkc
 
-import (
-	// #cgo CFLAGS: -g -O2 -Wall -Wextra -Wno-unused-variable -Wno-unused-parameter -I. -I/usr/include/k2hdkc
-	// #cgo LDFLAGS: -L/usr/lib -lk2hdkc
-	// #include <stdlib.h>
-	// #include "k2hdkc.h"
-	// #include "k2hmacro.h"
-	"C"
-)
 import (
 	"bytes"
+	"context"
 	"errors"
 	"fmt"
 	"strconv"
-	"unsafe"
 )
 
 // GetAttrs holds arguments for C.k2hdkc_pm_get_attrs and a pointer of GetAttrsResult.
@@ -55,12 +47,27 @@ func (r *Attr) String() string {
 	return fmt.Sprintf("[%v, %v]", r.key, r.val)
 }
 
+// NewAttr returns the pointer to an Attr which has the name and the value.
+func NewAttr(key []byte, val []byte) *Attr {
+	return &Attr{key: key, val: val}
+}
+
+// Key returns the name of the attribute.
+func (r *Attr) Key() []byte {
+	return r.key
+}
+
+// Val returns the value of the attribute.
+func (r *Attr) Val() []byte {
+	return r.val
+}
+
 // GetAttrsResult holds the result of GetAttrs.Execute().
 type GetAttrsResult struct {
 	attrs      []*Attr
-	ok         C._Bool
-	resCode    C.dkcres_type_t // response
-	subResCode C.dkcres_type_t // response(details)
+	ok         bool
+	resCode    ResCode // response
+	subResCode SubCode // response(details)
 }
 
 // String returns a text representation of the object.
@@ -104,47 +111,20 @@ func (r *GetAttrs) Execute(s *Session) (bool, error) {
 	if r.key == nil || len(r.key) == 0 || r.result == nil {
 		return false, fmt.Errorf("some required members nil, r.key %v, r.result %v", r.key, r.result)
 	}
-	cKey := C.CBytes(r.key)
-	defer C.free(cKey)
-	var attrpack C.PK2HDKCATTRPCK
-	var attrpackLen C.int
-	r.result.ok = C.k2hdkc_pm_get_attrs(
-		s.handler,
-		(*C.uchar)(cKey),
-		C.size_t(len(r.key)),
-		&attrpack,
-		&attrpackLen,
-	)
-	defer C.dkc_free_attrpack(attrpack, attrpackLen)
-	r.result.resCode = C.k2hdkc_get_res_code(s.handler)
-	r.result.subResCode = C.k2hdkc_get_res_subcode(s.handler)
+	res := s.do(&Request{Op: OpGetAttrs, Key: r.key})
+	r.result.ok, r.result.resCode, r.result.subResCode = res.OK, res.Code, res.SubCode
 	if r.result.ok == false {
-		return false, fmt.Errorf("C.k2hdkc_pm_get_subkeys() = %v", r.result.ok)
-	}
-	if attrpackLen == 0 {
-		return true, nil
-	}
-	/*
-	   typedef struct k2h_attr_pack{
-	   	unsigned char*pkey;
-	   	size_t keylength;
-	   	unsigned char*pval;
-	   	size_t vallength;
-	   }K2HATTRPCK, *PK2HATTRPCK;
-	*/
-	// See https://github.com/golang/go/wiki/cgo#turning-c-arrays-into-go-slices
-	var theCArray C.PK2HDKCATTRPCK = attrpack
-	length := (int)(attrpackLen)
-	cslice := (*[1 << 30]C.K2HATTRPCK)(unsafe.Pointer(theCArray))[:length:length]
-	r.result.attrs = make([]*Attr, length) // copy
-	for i, data := range cslice {
-		akey := C.GoBytes(unsafe.Pointer(data.pkey), (C.int)(data.keylength))
-		aval := C.GoBytes(unsafe.Pointer(data.pval), (C.int)(data.vallength))
-		r.result.attrs[i] = &Attr{akey, aval}
+		return false, resError("C.k2hdkc_pm_get_attrs", res)
 	}
+	r.result.attrs = res.Attrs
 	return true, nil
 }
 
+// ExecuteContext calls Execute in a goroutine and returns the context error if the context is done before Execute returns.
+func (r *GetAttrs) ExecuteContext(ctx context.Context, s *Session) (bool, error) {
+	return executeContext(ctx, s, r)
+}
+
 // Result returns the pointer of GetAttrs that has the result of Execute method.
 func (r *GetAttrs) Result() *GetAttrsResult {
 	if r.result != nil {
@@ -206,9 +186,17 @@ func (r *GetAttrsResult) Bool() bool {
 
 // Error returns the errno of C.k2hdkc_pm_get_attrs in string format.
 func (r *GetAttrsResult) Error() string {
-	return fmt.Sprintf("%v %v",
-		C.GoString(C.str_dkcres_result_type(r.resCode)),
-		C.GoString(C.str_dkcres_subcode_type(r.subResCode)))
+	return fmt.Sprintf("%v %v", r.resCode, r.subResCode)
+}
+
+// Code returns the result code of the request.
+func (r *GetAttrsResult) Code() ResCode {
+	return r.resCode
+}
+
+// SubCode returns the sub code of the request.
+func (r *GetAttrsResult) SubCode() SubCode {
+	return r.subResCode
 }
 
 // Local Variables:
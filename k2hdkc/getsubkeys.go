@@ -17,19 +17,11 @@
 
 package k2hdkc
 
-import (
-	// #cgo CFLAGS: -g -O2 -Wall -Wextra -Wno-unused-variable -Wno-unused-parameter -I. -I/usr/include/k2hdkc
-	// #cgo LDFLAGS: -L/usr/lib -lk2hdkc
-	// #include <stdlib.h>
-	// #include "k2hdkc.h"
-	// #include "k2hmacro.h"
-	"C"
-)
 import (
 	"bytes"
+	"context"
 	"errors"
 	"fmt"
-	"unsafe"
 )
 
 // GetSubKeys holds arguments for C.k2hdkc_pm_get_subkeys and a pointer of GetSubKeysResult.
@@ -46,9 +38,9 @@ func (r *GetSubKeys) String() string {
 // GetSubKeysResult holds the result of GetSubKeys.Execute().
 type GetSubKeysResult struct {
 	skeys      [][]byte
-	ok         C._Bool
-	resCode    C.dkcres_type_t // response
-	subResCode C.dkcres_type_t // response(details)
+	ok         bool
+	resCode    ResCode // response
+	subResCode SubCode // response(details)
 }
 
 // String returns a text representation of the object.
@@ -95,42 +87,20 @@ func (r *GetSubKeys) Execute(s *Session) (bool, error) {
 	if r.key == nil || len(r.key) == 0 || r.result == nil {
 		return false, fmt.Errorf("required members nil, r.key %v, r.result %v", r.key, r.result)
 	}
-	cKey := C.CBytes(r.key)
-	defer C.free(cKey)
-	var keypack C.PK2HDKCKEYPCK
-	var keypackLen C.int
-
-	r.result.ok = C.k2hdkc_pm_get_subkeys(
-		s.handler,
-		(*C.uchar)(cKey),
-		C.size_t(len(r.key)),
-		&keypack,
-		&keypackLen,
-	)
-	defer C.dkc_free_keypack(keypack, keypackLen)
-	r.result.resCode = C.k2hdkc_get_res_code(s.handler)
-	r.result.subResCode = C.k2hdkc_get_res_subcode(s.handler)
-
+	res := s.do(&Request{Op: OpGetSubKeys, Key: r.key})
+	r.result.ok, r.result.resCode, r.result.subResCode = res.OK, res.Code, res.SubCode
 	if r.result.ok == false {
-		return false, fmt.Errorf("C.k2hdkc_pm_get_subkeys() = %v", r.result.ok)
-	}
-
-	if keypackLen == 0 {
-		return true, nil
-	}
-
-	// See https://github.com/golang/go/wiki/cgo#turning-c-arrays-into-go-slices
-	var theCArray C.PK2HDKCKEYPCK = keypack
-	length := (int)(keypackLen)
-	cslice := (*[1 << 30]C.K2HKEYPCK)(unsafe.Pointer(theCArray))[:length:length]
-	r.result.skeys = make([][]byte, length) // copy
-	for i, data := range cslice {
-		sk := C.GoBytes(unsafe.Pointer(data.pkey), (C.int)(data.length))
-		r.result.skeys[i] = sk
+		return false, resError("C.k2hdkc_pm_get_subkeys", res)
 	}
+	r.result.skeys = res.SubKeys
 	return true, nil
 }
 
+// ExecuteContext calls Execute in a goroutine and returns the context error if the context is done before Execute returns.
+func (r *GetSubKeys) ExecuteContext(ctx context.Context, s *Session) (bool, error) {
+	return executeContext(ctx, s, r)
+}
+
 // Result returns the pointer of GetSubKeysResult that has the result of Execute method.
 func (r *GetSubKeys) Result() *GetSubKeysResult {
 	if r.result != nil {
@@ -145,15 +115,16 @@ func (r *GetSubKeysResult) Bytes() [][]byte {
 }
 
 // String returns the C.k2hdkc_pm_get_subkeys() response in string format.
+// The terminating NUL of a subkey is removed if it exists. Use Keys to decode the subkeys in an encoding.
 func (r *GetSubKeysResult) String() []string {
+	return r.Keys(KeyEncodingCString)
+}
+
+// Keys returns the subkeys decoded in the encoding.
+func (r *GetSubKeysResult) Keys(e KeyEncoding) []string {
 	slice := make([]string, len(r.skeys))
 	for i, s := range r.skeys {
-		valLen := len(s)
-		if valLen > 0 {
-			slice[i] = string(s[:valLen-1]) // make a new string without the null termination
-		} else {
-			slice[i] = ""
-		}
+		slice[i] = DecodeKey(s, e)
 	}
 	return slice
 }
@@ -168,9 +139,17 @@ func (r *GetSubKeysResult) Bool() bool {
 
 // Error returns the errno of C.k2hdkc_pm_get_subkeys() in string format.
 func (r *GetSubKeysResult) Error() string {
-	return fmt.Sprintf("%v %v",
-		C.GoString(C.str_dkcres_result_type(r.resCode)),
-		C.GoString(C.str_dkcres_subcode_type(r.subResCode)))
+	return fmt.Sprintf("%v %v", r.resCode, r.subResCode)
+}
+
+// Code returns the result code of the request.
+func (r *GetSubKeysResult) Code() ResCode {
+	return r.resCode
+}
+
+// SubCode returns the sub code of the request.
+func (r *GetSubKeysResult) SubCode() SubCode {
+	return r.subResCode
 }
 
 // Local Variables:
@@ -0,0 +1,229 @@
+//
+// k2hdkc_go
+//
+// Copyright 2018 Yahoo Japan Corporation.
+//
+// Go driver for k2hdkc that is a highly available and scalable distributed
+// KVS clustering system. For k2hdkc, see
+// https://github.com/yahoojapan/k2hdkc for the details.
+//
+// For the full copyright and license information, please view
+// the license file that was distributed with this source code.
+//
+// AUTHOR:   Hirotaka Wakabayashi
+// CREATE:   Fri, 16 Oct 2026
+// REVISION:
+//
+
+package k2hdkc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+)
+
+// Handler executes a command.
+type Handler func(ctx context.Context, cmd Command) error
+
+// Interceptor is called instead of executing a command. It calls next to go on, or returns
+// without calling it to short-circuit the command. It may modify the command before calling next,
+// for example with SetCommandKey, and read the result with CommandResult after next returns
+// unless next returned a context error, since the abandoned command may still be running.
+// The result of a short-circuited command keeps zero values.
+type Interceptor func(ctx context.Context, cmd Command, next Handler) error
+
+// AddInterceptor adds interceptors of the commands which the client executes.
+// The first interceptor added is called first. Retries happen inside the chain, so an
+// interceptor is called once for each command.
+func (c *Client) AddInterceptor(in ...Interceptor) *Client {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	ins := make([]Interceptor, 0, len(c.interceptors)+len(in))
+	ins = append(ins, c.interceptors...)
+	for _, i := range in {
+		if i != nil {
+			ins = append(ins, i)
+		}
+	}
+	c.interceptors = ins
+	return c
+}
+
+// intercept calls the interceptors of the client and h at the end of the chain.
+func (c *Client) intercept(ctx context.Context, cmd Command, h Handler) error {
+	c.mu.Lock()
+	ins := c.interceptors
+	c.mu.Unlock()
+	for i := len(ins) - 1; i >= 0; i-- {
+		in, next := ins[i], h
+		h = func(ctx context.Context, cmd Command) error {
+			return in(ctx, cmd, next)
+		}
+	}
+	return h(ctx, cmd)
+}
+
+// commandError returns the error of a command which returned ok and err.
+func commandError(cmd Command, ok bool, err error) error {
+	if !ok && err == nil {
+		return fmt.Errorf("%T.Execute(s) returned ok %v", cmd, ok)
+	}
+	return err
+}
+
+// CommandKey returns the key of the command. The prefix of the queue returns for queue commands
+// and the old key for Rename. nil returns if the command isn't of this package.
+func CommandKey(cmd Command) []byte {
+	switch r := cmd.(type) {
+	case *AddSubKey:
+		return r.key
+	case *CasGet:
+		return r.key
+	case *CasIncDec:
+		return r.key
+	case *CasInit:
+		return r.key
+	case *CasSet:
+		return r.key
+	case *ClearSubKeys:
+		return r.key
+	case *Get:
+		return r.key
+	case *GetAttrs:
+		return r.key
+	case *GetSubKeys:
+		return r.key
+	case *QueuePop:
+		return r.prefix
+	case *QueuePush:
+		return r.prefix
+	case *QueueRemove:
+		return r.prefix
+	case *Remove:
+		return r.key
+	case *RemoveSubKey:
+		return r.key
+	case *Rename:
+		return r.oldKey
+	case *Set:
+		return r.key
+	case *SetAll:
+		return r.key
+	case *SetSubKeys:
+		return r.key
+	default:
+		return nil
+	}
+}
+
+// SetCommandKey replaces the key which CommandKey returns.
+func SetCommandKey(cmd Command, k []byte) error {
+	if len(k) == 0 {
+		return errors.New("len(key) is zero")
+	}
+	switch r := cmd.(type) {
+	case *AddSubKey:
+		r.key = k
+	case *CasGet:
+		r.key = k
+	case *CasIncDec:
+		r.key = k
+	case *CasInit:
+		r.key = k
+	case *CasSet:
+		r.key = k
+	case *ClearSubKeys:
+		r.key = k
+	case *Get:
+		r.key = k
+	case *GetAttrs:
+		r.key = k
+	case *GetSubKeys:
+		r.key = k
+	case *QueuePop:
+		r.prefix = k
+	case *QueuePush:
+		r.prefix = k
+	case *QueueRemove:
+		r.prefix = k
+	case *Remove:
+		r.key = k
+	case *RemoveSubKey:
+		r.key = k
+	case *Rename:
+		r.oldKey = k
+	case *Set:
+		r.key = k
+	case *SetAll:
+		r.key = k
+	case *SetSubKeys:
+		r.key = k
+	default:
+		return fmt.Errorf("unsupported command %T", cmd)
+	}
+	return nil
+}
+
+// CommandResult returns the codes of the result of the command. ok is false if the command isn't
+// of this package or has no result.
+func CommandResult(cmd Command) (code ResCode, sub SubCode, ok bool) {
+	type result interface {
+		Code() ResCode
+		SubCode() SubCode
+	}
+	var r result
+	switch c := cmd.(type) {
+	case *AddSubKey:
+		r = c.Result()
+	case *CasGet:
+		r = c.Result()
+	case *CasIncDec:
+		r = c.Result()
+	case *CasInit:
+		r = c.Result()
+	case *CasSet:
+		r = c.Result()
+	case *ClearSubKeys:
+		r = c.Result()
+	case *Get:
+		r = c.Result()
+	case *GetAttrs:
+		r = c.Result()
+	case *GetSubKeys:
+		r = c.Result()
+	case *QueuePop:
+		r = c.Result()
+	case *QueuePush:
+		r = c.Result()
+	case *QueueRemove:
+		r = c.Result()
+	case *Remove:
+		r = c.Result()
+	case *RemoveSubKey:
+		r = c.Result()
+	case *Rename:
+		r = c.Result()
+	case *Set:
+		r = c.Result()
+	case *SetAll:
+		r = c.Result()
+	case *SetSubKeys:
+		r = c.Result()
+	default:
+		return 0, 0, false
+	}
+	if v := reflect.ValueOf(r); v.IsNil() {
+		return 0, 0, false
+	}
+	return r.Code(), r.SubCode(), true
+}
+
+// Local Variables:
+// c-basic-offset: 4
+// tab-width: 4
+// indent-tabs-mode: t
+// End:
+// vim600: noexpandtab sw=4 ts=4 fdm=marker
+// vim<600: noexpandtab sw=4 ts=4
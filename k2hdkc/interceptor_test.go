@@ -0,0 +1,113 @@
+//
+// k2hdkc_go
+//
+// Copyright 2018 Yahoo Japan Corporation.
+//
+// Go driver for k2hdkc that is a highly available and scalable distributed
+// KVS clustering system. For k2hdkc, see
+// https://github.com/yahoojapan/k2hdkc for the details.
+//
+// For the full copyright and license information, please view
+// the license file that was distributed with this source code.
+//
+// AUTHOR:   Hirotaka Wakabayashi
+// CREATE:   Fri, 16 Oct 2026
+// REVISION:
+//
+
+package k2hdkc_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/yahoojapan/k2hdkc_go/k2hdkc"
+	"github.com/yahoojapan/k2hdkc_go/k2hdkc/k2hdkctest"
+)
+
+// TestInterceptorChain tests interceptors are called in order and see the command.
+func TestInterceptorChain(t *testing.T) {
+	s := k2hdkctest.NewServer()
+	c := s.NewClient()
+	defer c.Close()
+
+	var calls []string
+	var elapsed time.Duration
+	c.AddInterceptor(func(ctx context.Context, cmd k2hdkc.Command, next k2hdkc.Handler) error {
+		start := time.Now()
+		calls = append(calls, fmt.Sprintf("a %T %q", cmd, k2hdkc.CommandKey(cmd)))
+		err := next(ctx, cmd)
+		elapsed = time.Since(start)
+		code, sub, _ := k2hdkc.CommandResult(cmd)
+		calls = append(calls, fmt.Sprintf("a %v %v %v", code, sub, err))
+		return err
+	}, func(ctx context.Context, cmd k2hdkc.Command, next k2hdkc.Handler) error {
+		calls = append(calls, "b")
+		return next(ctx, cmd)
+	})
+
+	if _, err := c.GetValue("nokey"); !errors.Is(err, k2hdkc.ErrNotFound) {
+		t.Fatalf("c.GetValue(nokey) = %v, want ErrNotFound", err)
+	}
+	want := fmt.Sprint([]string{`a *k2hdkc.Get "nokey\x00"`, "b", "a DKC_RES_SUCCESS DKC_RES_SUBCODE_NODATA <nil>"})
+	if got := fmt.Sprint(calls); got != want || elapsed <= 0 {
+		t.Errorf("calls = %v, elapsed %v, want %v", got, elapsed, want)
+	}
+}
+
+// TestInterceptorRewrite tests an interceptor rewrites keys.
+func TestInterceptorRewrite(t *testing.T) {
+	s := k2hdkctest.NewServer()
+	c := s.NewClient().AddInterceptor(func(ctx context.Context, cmd k2hdkc.Command, next k2hdkc.Handler) error {
+		if err := k2hdkc.SetCommandKey(cmd, append([]byte("ns/"), k2hdkc.CommandKey(cmd)...)); err != nil {
+			return err
+		}
+		return next(ctx, cmd)
+	})
+	defer c.Close()
+	raw := s.NewClient()
+	defer raw.Close()
+
+	if err := c.SetValue("key", []byte("v")); err != nil {
+		t.Fatalf("c.SetValue(key) = %v", err)
+	}
+	if v, err := raw.GetValue("ns/key"); err != nil || !bytes.Equal(v, []byte("v")) {
+		t.Errorf("raw.GetValue(ns/key) = %q, %v, want v", v, err)
+	}
+	if v, err := c.GetValue("key"); err != nil || !bytes.Equal(v, []byte("v")) {
+		t.Errorf("c.GetValue(key) = %q, %v, want v", v, err)
+	}
+}
+
+// TestInterceptorShortCircuit tests an interceptor stops a command before it's sent.
+func TestInterceptorShortCircuit(t *testing.T) {
+	in := k2hdkctest.NewInjector(k2hdkctest.NewServer())
+	denied := errors.New("denied")
+	c := in.NewClient().AddInterceptor(func(ctx context.Context, cmd k2hdkc.Command, next k2hdkc.Handler) error {
+		if _, ok := cmd.(*k2hdkc.Remove); ok {
+			return denied
+		}
+		return next(ctx, cmd)
+	})
+	defer c.Close()
+
+	if err := c.Remove("key"); err != denied || in.Calls(k2hdkc.OpRemove) != 0 {
+		t.Errorf("c.Remove(key) = %v after %v calls, want denied", err, in.Calls(k2hdkc.OpRemove))
+	}
+	res, err := k2hdkc.NewBatch(c).Add(batchSets(t, 2)...).Run(context.Background())
+	if err != nil || res.Succeeded != 2 {
+		t.Errorf("Run() = %v, %v", res, err)
+	}
+}
+
+// Local Variables:
+// c-basic-offset: 4
+// tab-width: 4
+// indent-tabs-mode: t
+// End:
+// vim600: noexpandtab sw=4 ts=4 fdm=marker
+// vim<600: noexpandtab sw=4 ts=4
@@ -17,32 +17,10 @@
 
 package k2hdkc
 
-/*
-#cgo linux LDFLAGS: -ldl
-#include <dlfcn.h>
-#include <stdio.h>
-static int dlopen_k2hdkc() {
-  dlerror();
-  void* handler = dlopen("libk2hdkc.so", RTLD_LAZY);
-  if (handler == NULL) {
-    char* error = dlerror();
-    if (error != NULL) {
-      fprintf(stderr, "dlerror() %s\n", error);
-      return -1;
-    }
-  }
-  dlclose(handler);
-  return 0;
-}
-*/
-import "C"
-
-import (
-	"fmt"
-	"os"
-	"runtime"
-	"unsafe"
-)
+// The native backend sets these variables at init if the platform or the library isn't available.
+var unSupportedOs = false
+var unSupportedEndian = false
+var isNotExistLibK2hdkc = false
 
 // CasType defines cas value data type and length.
 type CasType uint8
@@ -66,31 +44,9 @@ const (
 	defaultUseKeyQueue     = false
 	defaultOrderFIFO       = true
 	defaultCheckAttr       = true
+	defaultConcurrency     = 8
 )
 
-var unSupportedOs = false
-var unSupportedEndian = false
-var isNotExistLibK2hdkc = false
-
-// init checks if OS supports epoll system call and alignment of 64 bit data in memory is on little endian.
-func init() {
-	if runtime.GOOS != "linux" && runtime.GOARCH != "amd64" {
-		fmt.Fprintf(os.Stderr, "k2hdkc currently works on linux only")
-		unSupportedOs = true
-	}
-	i := uint32(1)
-	b := (*[4]byte)(unsafe.Pointer(&i))
-	if b[0] != 1 {
-		fmt.Fprintf(os.Stderr, "k2hdkc_go currently works on little endian alignment only")
-		unSupportedEndian = true
-	}
-	// rpm or deb install the k2hdkc.so in /usr/lib.
-	if C.dlopen_k2hdkc() < 0 {
-		fmt.Fprintf(os.Stderr, "Please install the k2hdkc package at first")
-		isNotExistLibK2hdkc = true
-	}
-}
-
 // Local Variables:
 // c-basic-offset: 4
 // tab-width: 4
@@ -0,0 +1,216 @@
+//
+// k2hdkc_go
+//
+// Copyright 2018 Yahoo Japan Corporation.
+//
+// Go driver for k2hdkc that is a highly available and scalable distributed
+// KVS clustering system. For k2hdkc, see
+// https://github.com/yahoojapan/k2hdkc for the details.
+//
+// For the full copyright and license information, please view
+// the license file that was distributed with this source code.
+//
+// AUTHOR:   Hirotaka Wakabayashi
+// CREATE:   Fri, 16 Oct 2026
+// REVISION:
+//
+
+package k2hdkctest
+
+import (
+	"errors"
+	"regexp"
+	"sync"
+	"time"
+
+	"github.com/yahoojapan/k2hdkc_go/k2hdkc"
+)
+
+// ErrInjected is returned by Open of an Injector while the open failures set by FailOpen remain.
+var ErrInjected = errors.New("k2hdkctest: injected fault")
+
+// Fault describes requests which an Injector disturbs and how.
+// A request matches if its operation is Op and its key or queue prefix matches Key.
+// The zero Op and a nil Key match any request.
+type Fault struct {
+	Op      k2hdkc.Op
+	Key     *regexp.Regexp
+	After   int            // skips the first After matching requests.
+	Times   int            // disturbs Times matching requests after them. zero means all of them.
+	Latency time.Duration  // delays the request.
+	Fail    bool           // fails the request with Code and SubCode instead of executing it.
+	Code    k2hdkc.ResCode // result code of the failure. ResCodeSuccess means ResCodeError.
+	SubCode k2hdkc.SubCode // sub code of the failure.
+	Drop    bool           // closes the connection. The request and the following ones fail with SubCodeSend.
+}
+
+// fault holds a Fault and its counters.
+type fault struct {
+	Fault
+	seen    int
+	applied int
+}
+
+// match returns true if the fault disturbs the request. The counters are updated.
+func (f *fault) match(req *k2hdkc.Request) bool {
+	if f.Op != 0 && f.Op != req.Op {
+		return false
+	}
+	if f.Key != nil {
+		key := req.Key
+		if len(req.Prefix) != 0 {
+			key = req.Prefix
+		}
+		if !f.Key.Match(key) {
+			return false
+		}
+	}
+	f.seen++
+	if f.seen <= f.After {
+		return false
+	}
+	if f.Times > 0 && f.applied >= f.Times {
+		return false
+	}
+	f.applied++
+	return true
+}
+
+// Injector is a Backend which disturbs requests sent to another Backend.
+type Injector struct {
+	backend  k2hdkc.Backend
+	mu       sync.Mutex
+	faults   []*fault
+	calls    map[k2hdkc.Op]int
+	failOpen int
+}
+
+// NewInjector returns the pointer to an Injector which sends requests to the backend.
+// Use k2hdkc.DefaultBackend() to disturb the native backend.
+func NewInjector(b k2hdkc.Backend) *Injector {
+	return &Injector{backend: b, calls: make(map[k2hdkc.Op]int)}
+}
+
+// NewClient returns a Client which sends requests through the injector.
+func (in *Injector) NewClient() *k2hdkc.Client {
+	c := k2hdkc.NewClient("", 0)
+	if c == nil {
+		return nil
+	}
+	return c.SetBackend(in)
+}
+
+// Add adds the fault. The first fault matching a request is applied.
+func (in *Injector) Add(f Fault) *Injector {
+	in.mu.Lock()
+	defer in.mu.Unlock()
+	in.faults = append(in.faults, &fault{Fault: f})
+	return in
+}
+
+// FailOpen makes the next n calls of Open fail with ErrInjected.
+func (in *Injector) FailOpen(n int) *Injector {
+	in.mu.Lock()
+	defer in.mu.Unlock()
+	in.failOpen = n
+	return in
+}
+
+// Clear removes all faults and resets the counters.
+func (in *Injector) Clear() {
+	in.mu.Lock()
+	defer in.mu.Unlock()
+	in.faults = nil
+	in.calls = make(map[k2hdkc.Op]int)
+	in.failOpen = 0
+}
+
+// Calls returns the number of requests of the operation the injector has received.
+func (in *Injector) Calls(op k2hdkc.Op) int {
+	in.mu.Lock()
+	defer in.mu.Unlock()
+	return in.calls[op]
+}
+
+// Open opens a connection of the backend and wraps it.
+func (in *Injector) Open(c *k2hdkc.Client) (k2hdkc.Conn, error) {
+	in.mu.Lock()
+	if in.failOpen > 0 {
+		in.failOpen--
+		in.mu.Unlock()
+		return nil, &k2hdkc.OpenError{Err: ErrInjected}
+	}
+	in.mu.Unlock()
+	if in.backend == nil {
+		return nil, k2hdkc.ErrNoBackend
+	}
+	conn, err := in.backend.Open(c)
+	if err != nil {
+		return nil, err
+	}
+	return &faultConn{injector: in, conn: conn}, nil
+}
+
+// lookup counts the request and returns the fault to apply or nil.
+func (in *Injector) lookup(req *k2hdkc.Request) *Fault {
+	in.mu.Lock()
+	defer in.mu.Unlock()
+	in.calls[req.Op]++
+	for _, f := range in.faults {
+		if f.match(req) {
+			ret := f.Fault
+			return &ret
+		}
+	}
+	return nil
+}
+
+// faultConn is a connection wrapped by an Injector.
+type faultConn struct {
+	injector *Injector
+	conn     k2hdkc.Conn
+	dropped  bool
+}
+
+// Do applies the matching fault and executes the request.
+func (c *faultConn) Do(req *k2hdkc.Request) *k2hdkc.Response {
+	if c.dropped {
+		return failure(k2hdkc.SubCodeSend)
+	}
+	f := c.injector.lookup(req)
+	if f == nil {
+		return c.conn.Do(req)
+	}
+	if f.Latency > 0 {
+		time.Sleep(f.Latency)
+	}
+	if f.Drop {
+		c.dropped = true
+		c.conn.Close()
+		return failure(k2hdkc.SubCodeSend)
+	}
+	if f.Fail {
+		code := f.Code
+		if code == k2hdkc.ResCodeSuccess {
+			code = k2hdkc.ResCodeError
+		}
+		return &k2hdkc.Response{OK: false, Code: code, SubCode: f.SubCode}
+	}
+	return c.conn.Do(req)
+}
+
+// Close closes the wrapped connection.
+func (c *faultConn) Close() error {
+	if c.dropped {
+		return nil
+	}
+	return c.conn.Close()
+}
+
+// Local Variables:
+// c-basic-offset: 4
+// tab-width: 4
+// indent-tabs-mode: t
+// End:
+// vim600: noexpandtab sw=4 ts=4 fdm=marker
+// vim<600: noexpandtab sw=4 ts=4
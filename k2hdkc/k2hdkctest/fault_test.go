@@ -0,0 +1,114 @@
+//
+// k2hdkc_go
+//
+// Copyright 2018 Yahoo Japan Corporation.
+//
+// Go driver for k2hdkc that is a highly available and scalable distributed
+// KVS clustering system. For k2hdkc, see
+// https://github.com/yahoojapan/k2hdkc for the details.
+//
+// For the full copyright and license information, please view
+// the license file that was distributed with this source code.
+//
+// AUTHOR:   Hirotaka Wakabayashi
+// CREATE:   Fri, 16 Oct 2026
+// REVISION:
+//
+
+package k2hdkctest
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/yahoojapan/k2hdkc_go/k2hdkc"
+)
+
+// TestFaultFail tests faults matched by the operation, the key and the call count.
+func TestFaultFail(t *testing.T) {
+	in := NewInjector(NewServer())
+	in.Add(Fault{Op: k2hdkc.OpSet, Key: regexp.MustCompile("^bad"), Fail: true, SubCode: k2hdkc.SubCodeNoServer})
+	in.Add(Fault{Op: k2hdkc.OpGet, After: 1, Times: 1, Fail: true, SubCode: k2hdkc.SubCodeTimeout})
+	c := in.NewClient()
+	defer c.Close()
+
+	if err := c.SetValue("badkey", []byte("v")); !errors.Is(err, k2hdkc.ErrNoServer) {
+		t.Errorf("c.SetValue(badkey, v) = %v, want ErrNoServer", err)
+	}
+	if err := c.SetValue("key", []byte("v")); err != nil {
+		t.Fatalf("c.SetValue(key, v) = %v", err)
+	}
+	if _, err := c.GetValue("key"); err != nil {
+		t.Errorf("c.GetValue(key) 1st = %v, want nil", err)
+	}
+	if _, err := c.GetValue("key"); !errors.Is(err, k2hdkc.ErrTimeout) {
+		t.Errorf("c.GetValue(key) 2nd = %v, want ErrTimeout", err)
+	}
+	if _, err := c.GetValue("key"); err != nil {
+		t.Errorf("c.GetValue(key) 3rd = %v, want nil", err)
+	}
+	if n := in.Calls(k2hdkc.OpGet); n != 3 {
+		t.Errorf("in.Calls(OpGet) = %v, want 3", n)
+	}
+}
+
+// TestFaultDrop tests a dropped connection is replaced by a new session.
+func TestFaultDrop(t *testing.T) {
+	in := NewInjector(NewServer())
+	in.Add(Fault{Op: k2hdkc.OpSet, Times: 1, Drop: true})
+	c := in.NewClient()
+	defer c.Close()
+
+	if err := c.SetValue("key", []byte("v")); !errors.Is(err, k2hdkc.ErrCommunication) {
+		t.Errorf("c.SetValue(key, v) = %v, want ErrCommunication", err)
+	}
+	if err := c.SetValue("key", []byte("v")); err != nil {
+		t.Errorf("c.SetValue(key, v) after drop = %v", err)
+	}
+	if st := c.PoolStats(); st.Opened != 2 || st.Closed != 1 {
+		t.Errorf("c.PoolStats() = %v, want 2 opened and 1 closed", st)
+	}
+}
+
+// TestFaultLatency tests latency makes a request exceed the deadline.
+func TestFaultLatency(t *testing.T) {
+	in := NewInjector(NewServer())
+	in.Add(Fault{Op: k2hdkc.OpGet, Latency: 100 * time.Millisecond})
+	c := in.NewClient()
+	defer c.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, err := c.GetValueContext(ctx, "key"); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("c.GetValueContext(ctx, key) = %v, want DeadlineExceeded", err)
+	}
+	// the result of the abandoned command isn't returned.
+	if r, err := c.GetContext(ctx, "key"); r != nil || !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("c.GetContext(ctx, key) = (%v, %v), want (nil, DeadlineExceeded)", r, err)
+	}
+}
+
+// TestFaultOpen tests FailOpen.
+func TestFaultOpen(t *testing.T) {
+	in := NewInjector(NewServer()).FailOpen(1)
+	c := in.NewClient()
+	defer c.Close()
+
+	if _, err := c.GetValue("key"); !errors.Is(err, ErrInjected) {
+		t.Errorf("c.GetValue(key) = %v, want ErrInjected", err)
+	}
+	if _, err := c.GetValue("key"); !errors.Is(err, k2hdkc.ErrNotFound) {
+		t.Errorf("c.GetValue(key) = %v, want ErrNotFound", err)
+	}
+}
+
+// Local Variables:
+// c-basic-offset: 4
+// tab-width: 4
+// indent-tabs-mode: t
+// End:
+// vim600: noexpandtab sw=4 ts=4 fdm=marker
+// vim<600: noexpandtab sw=4 ts=4
@@ -0,0 +1,583 @@
+//
+// k2hdkc_go
+//
+// Copyright 2018 Yahoo Japan Corporation.
+//
+// Go driver for k2hdkc that is a highly available and scalable distributed
+// KVS clustering system. For k2hdkc, see
+// https://github.com/yahoojapan/k2hdkc for the details.
+//
+// For the full copyright and license information, please view
+// the license file that was distributed with this source code.
+//
+// AUTHOR:   Hirotaka Wakabayashi
+// CREATE:   Fri, 16 Oct 2026
+// REVISION:
+//
+
+// Package k2hdkctest implements an in-memory k2hdkc server for tests.
+//
+// A Server is a k2hdkc.Backend. Clients which use it run without the k2hdkc library
+// and a chmpx process, so tests built with the nok2hdkc tag or without cgo run anywhere.
+//
+//	s := k2hdkctest.NewServer()
+//	c := s.NewClient()
+//	defer c.Close()
+//	c.SetValue("hello", []byte("world"))
+package k2hdkctest
+
+import (
+	"bytes"
+	"encoding/binary"
+	"sync"
+	"time"
+
+	"github.com/yahoojapan/k2hdkc_go/k2hdkc"
+)
+
+// entry holds a value and its attributes.
+type entry struct {
+	val     []byte
+	subkeys [][]byte
+	pass    string
+	mtime   time.Time
+	expire  time.Time // zero means the entry never expires.
+}
+
+// item holds a value in a queue. The key is nil unless the item is in a key queue.
+type item struct {
+	key    []byte
+	val    []byte
+	pass   string
+	expire time.Time
+}
+
+// Server is an in-memory k2hdkc cluster which is safe for concurrent use by multiple goroutines.
+type Server struct {
+	mu      sync.Mutex
+	now     func() time.Time
+	entries map[string]*entry
+	queues  map[string][]*item // values pushed by OpQueuePush without a key.
+	kqueues map[string][]*item // keys and values pushed by OpQueuePush with a key.
+}
+
+// NewServer returns the pointer to an empty Server.
+func NewServer() *Server {
+	s := &Server{now: time.Now}
+	s.Reset()
+	return s
+}
+
+// NewClient returns a Client which sends requests to the server.
+func (s *Server) NewClient() *k2hdkc.Client {
+	c := k2hdkc.NewClient("", 0)
+	if c == nil {
+		return nil
+	}
+	return c.SetBackend(s)
+}
+
+// SetNow sets the clock which the server uses for the mtime and the expiration.
+func (s *Server) SetNow(now func() time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if now == nil {
+		now = time.Now
+	}
+	s.now = now
+}
+
+// Reset removes all keys and queues.
+func (s *Server) Reset() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.entries = make(map[string]*entry)
+	s.queues = make(map[string][]*item)
+	s.kqueues = make(map[string][]*item)
+}
+
+// Open returns a connection to the server.
+func (s *Server) Open(c *k2hdkc.Client) (k2hdkc.Conn, error) {
+	return &conn{server: s}, nil
+}
+
+// conn is a connection to a Server.
+type conn struct {
+	server *Server
+	closed bool
+}
+
+// Do executes the request.
+func (c *conn) Do(req *k2hdkc.Request) *k2hdkc.Response {
+	if c.closed {
+		return failure(k2hdkc.SubCodeSend)
+	}
+	return c.server.do(req)
+}
+
+// Close closes the connection.
+func (c *conn) Close() error {
+	c.closed = true
+	return nil
+}
+
+// success returns a successful response.
+func success() *k2hdkc.Response {
+	return &k2hdkc.Response{OK: true, Code: k2hdkc.ResCodeSuccess, SubCode: k2hdkc.SubCodeNothing}
+}
+
+// noData returns a successful response without data.
+func noData() *k2hdkc.Response {
+	return &k2hdkc.Response{OK: true, Code: k2hdkc.ResCodeSuccess, SubCode: k2hdkc.SubCodeNoData}
+}
+
+// failure returns a failed response with the sub code.
+func failure(sc k2hdkc.SubCode) *k2hdkc.Response {
+	return &k2hdkc.Response{OK: false, Code: k2hdkc.ResCodeError, SubCode: sc}
+}
+
+// clone returns a copy of the byte slice.
+func clone(b []byte) []byte {
+	if b == nil {
+		return nil
+	}
+	return append([]byte{}, b...)
+}
+
+// cloneList returns a copy of the list of byte slices.
+func cloneList(l [][]byte) [][]byte {
+	if l == nil {
+		return nil
+	}
+	c := make([][]byte, 0, len(l))
+	for _, b := range l {
+		c = append(c, clone(b))
+	}
+	return c
+}
+
+// expireAt returns the expiration time of the seconds. zero seconds means no expiration.
+func (s *Server) expireAt(sec int64) time.Time {
+	if sec <= 0 {
+		return time.Time{}
+	}
+	return s.now().Add(time.Duration(sec) * time.Second)
+}
+
+// expired returns true if the time is past the expiration time.
+func (s *Server) expired(t time.Time) bool {
+	return !t.IsZero() && !s.now().Before(t)
+}
+
+// lookup returns the entry of the key. An expired entry is removed and nil returns.
+func (s *Server) lookup(key []byte) *entry {
+	e, ok := s.entries[string(key)]
+	if !ok {
+		return nil
+	}
+	if s.expired(e.expire) {
+		delete(s.entries, string(key))
+		return nil
+	}
+	return e
+}
+
+// store saves the value of the key. The subkey list of an existing entry is kept.
+func (s *Server) store(key []byte, val []byte, pass string, expire int64) *entry {
+	e := s.lookup(key)
+	if e == nil {
+		e = &entry{}
+		s.entries[string(key)] = e
+	}
+	e.val = clone(val)
+	e.pass = pass
+	e.mtime = s.now()
+	e.expire = s.expireAt(expire)
+	return e
+}
+
+// do executes the request.
+func (s *Server) do(req *k2hdkc.Request) *k2hdkc.Response {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	switch req.Op {
+	case k2hdkc.OpGet:
+		return s.get(req)
+	case k2hdkc.OpSet:
+		return s.set(req)
+	case k2hdkc.OpRemove:
+		return s.remove(req)
+	case k2hdkc.OpRename:
+		return s.rename(req)
+	case k2hdkc.OpGetSubKeys:
+		return s.getSubKeys(req)
+	case k2hdkc.OpSetSubKeys, k2hdkc.OpClearSubKeys:
+		return s.setSubKeys(req)
+	case k2hdkc.OpAddSubKey:
+		return s.addSubKey(req)
+	case k2hdkc.OpRemoveSubKey:
+		return s.removeSubKey(req)
+	case k2hdkc.OpSetAll:
+		return s.setAll(req)
+	case k2hdkc.OpGetAttrs:
+		return s.getAttrs(req)
+	case k2hdkc.OpCasInit:
+		return s.casInit(req)
+	case k2hdkc.OpCasGet:
+		return s.casGet(req)
+	case k2hdkc.OpCasSet:
+		return s.casSet(req)
+	case k2hdkc.OpCasIncrement, k2hdkc.OpCasDecrement:
+		return s.casIncDec(req)
+	case k2hdkc.OpQueuePush:
+		return s.queuePush(req)
+	case k2hdkc.OpQueuePop:
+		return s.queuePop(req)
+	case k2hdkc.OpQueueRemove:
+		return s.queueRemove(req)
+	}
+	return failure(k2hdkc.SubCodeInvalid)
+}
+
+// get returns the value. No data returns if the key doesn't exist or the pass differs.
+func (s *Server) get(req *k2hdkc.Request) *k2hdkc.Response {
+	e := s.lookup(req.Key)
+	if e == nil || e.pass != req.Pass {
+		return noData()
+	}
+	res := success()
+	res.Val = clone(e.val)
+	return res
+}
+
+// set saves the value.
+func (s *Server) set(req *k2hdkc.Request) *k2hdkc.Response {
+	e := s.store(req.Key, req.Val, req.Pass, req.Expire)
+	if req.RmSubKeyList {
+		e.subkeys = nil
+	}
+	return success()
+}
+
+// remove removes the key. The subkeys are kept.
+func (s *Server) remove(req *k2hdkc.Request) *k2hdkc.Response {
+	if s.lookup(req.Key) == nil {
+		return failure(k2hdkc.SubCodeNoData)
+	}
+	delete(s.entries, string(req.Key))
+	return success()
+}
+
+// rename moves the entry to the new key and replaces the old key with the new key in the subkey list of the parent.
+// The new key is not added to the list of a parent without the old key.
+func (s *Server) rename(req *k2hdkc.Request) *k2hdkc.Response {
+	e := s.lookup(req.Key)
+	if e == nil || e.pass != req.Pass {
+		return failure(k2hdkc.SubCodeNoData)
+	}
+	var parent *entry
+	if len(req.ParentKey) != 0 {
+		if parent = s.lookup(req.ParentKey); parent == nil {
+			return failure(k2hdkc.SubCodeNoData)
+		}
+	}
+	delete(s.entries, string(req.Key))
+	e.mtime = s.now()
+	if req.Expire > 0 {
+		e.expire = s.expireAt(req.Expire)
+	}
+	s.entries[string(req.NewKey)] = e
+	if parent != nil {
+		for i, sk := range parent.subkeys {
+			if bytes.Equal(sk, req.Key) {
+				parent.subkeys[i] = clone(req.NewKey)
+				break
+			}
+		}
+	}
+	return success()
+}
+
+// getSubKeys returns the subkey list.
+func (s *Server) getSubKeys(req *k2hdkc.Request) *k2hdkc.Response {
+	e := s.lookup(req.Key)
+	if e == nil {
+		return failure(k2hdkc.SubCodeNoData)
+	}
+	res := success()
+	res.SubKeys = cloneList(e.subkeys)
+	if res.SubKeys == nil {
+		res.SubKeys = [][]byte{}
+	}
+	return res
+}
+
+// setSubKeys replaces the subkey list. OpClearSubKeys has no subkeys.
+func (s *Server) setSubKeys(req *k2hdkc.Request) *k2hdkc.Response {
+	e := s.lookup(req.Key)
+	if e == nil {
+		return failure(k2hdkc.SubCodeNoData)
+	}
+	e.subkeys = cloneList(req.SubKeys)
+	e.mtime = s.now()
+	return success()
+}
+
+// addSubKey saves the value of the subkey and adds the subkey to the subkey list of the key.
+func (s *Server) addSubKey(req *k2hdkc.Request) *k2hdkc.Response {
+	e := s.lookup(req.Key)
+	if e == nil {
+		return failure(k2hdkc.SubCodeNoData)
+	}
+	s.store(req.SubKey, req.Val, req.Pass, req.Expire)
+	for _, sk := range e.subkeys {
+		if bytes.Equal(sk, req.SubKey) {
+			return success()
+		}
+	}
+	e.subkeys = append(e.subkeys, clone(req.SubKey))
+	e.mtime = s.now()
+	return success()
+}
+
+// removeSubKey removes the subkey from the subkey list and removes the subkey.
+// Nest removes the subkeys of the subkey recursively.
+func (s *Server) removeSubKey(req *k2hdkc.Request) *k2hdkc.Response {
+	e := s.lookup(req.Key)
+	if e == nil {
+		return failure(k2hdkc.SubCodeNoData)
+	}
+	for i, sk := range e.subkeys {
+		if bytes.Equal(sk, req.SubKey) {
+			e.subkeys = append(e.subkeys[:i:i], e.subkeys[i+1:]...)
+			e.mtime = s.now()
+			s.removeTree(req.SubKey, req.Nest, 0)
+			return success()
+		}
+	}
+	return failure(k2hdkc.SubCodeNoSubKey)
+}
+
+// maxDepth limits the recursion of removeTree for cyclic subkey lists.
+const maxDepth = 64
+
+// removeTree removes the key and its subkeys if nest is true.
+func (s *Server) removeTree(key []byte, nest bool, depth int) {
+	e := s.lookup(key)
+	if e == nil {
+		return
+	}
+	delete(s.entries, string(key))
+	if !nest || depth >= maxDepth {
+		return
+	}
+	for _, sk := range e.subkeys {
+		s.removeTree(sk, nest, depth+1)
+	}
+}
+
+// setAll saves the value and replaces the subkey list.
+func (s *Server) setAll(req *k2hdkc.Request) *k2hdkc.Response {
+	e := s.store(req.Key, req.Val, req.Pass, req.Expire)
+	e.subkeys = cloneList(req.SubKeys)
+	return success()
+}
+
+// timespec returns the time in the format of struct timespec.
+func timespec(t time.Time) []byte {
+	b := make([]byte, 16)
+	binary.LittleEndian.PutUint64(b[0:8], (uint64)(t.Unix()))
+	binary.LittleEndian.PutUint64(b[8:16], (uint64)(t.Nanosecond()))
+	return b
+}
+
+// getAttrs returns the mtime and the expire attributes.
+func (s *Server) getAttrs(req *k2hdkc.Request) *k2hdkc.Response {
+	e := s.lookup(req.Key)
+	if e == nil {
+		return failure(k2hdkc.SubCodeNoData)
+	}
+	res := success()
+	res.Attrs = append(res.Attrs, k2hdkc.NewAttr([]byte("mtime\x00"), timespec(e.mtime)))
+	if !e.expire.IsZero() {
+		res.Attrs = append(res.Attrs, k2hdkc.NewAttr([]byte("expire\x00"), timespec(e.expire)))
+	}
+	return res
+}
+
+// validCas returns true if the length of the cas value is 1, 2, 4 or 8 bytes.
+func validCas(b []byte) bool {
+	switch len(b) {
+	case 1, 2, 4, 8:
+		return true
+	}
+	return false
+}
+
+// casEntry returns the entry of the cas value or the failure response.
+func (s *Server) casEntry(req *k2hdkc.Request) (*entry, *k2hdkc.Response) {
+	e := s.lookup(req.Key)
+	if e == nil || e.pass != req.Pass {
+		return nil, failure(k2hdkc.SubCodeNoData)
+	}
+	if !validCas(e.val) {
+		return nil, failure(k2hdkc.SubCodeInvalid)
+	}
+	return e, nil
+}
+
+// casInit saves the cas value.
+func (s *Server) casInit(req *k2hdkc.Request) *k2hdkc.Response {
+	if !validCas(req.Val) {
+		return failure(k2hdkc.SubCodeInvalid)
+	}
+	s.store(req.Key, req.Val, req.Pass, req.Expire)
+	return success()
+}
+
+// casGet returns the cas value. The length of the value must be the cas type.
+func (s *Server) casGet(req *k2hdkc.Request) *k2hdkc.Response {
+	e, res := s.casEntry(req)
+	if res != nil {
+		return res
+	}
+	if len(e.val)*8 != int(req.CasType) {
+		return failure(k2hdkc.SubCodeInvalid)
+	}
+	res = success()
+	res.Val = clone(e.val)
+	return res
+}
+
+// casSet swaps the cas value if the current value is the old value.
+func (s *Server) casSet(req *k2hdkc.Request) *k2hdkc.Response {
+	e, res := s.casEntry(req)
+	if res != nil {
+		return res
+	}
+	if len(e.val) != len(req.Old) || len(req.Old) != len(req.Val) {
+		return failure(k2hdkc.SubCodeInvalid)
+	}
+	if !bytes.Equal(e.val, req.Old) {
+		return failure(k2hdkc.SubCodeCasMismatch)
+	}
+	s.store(req.Key, req.Val, req.Pass, req.Expire)
+	return success()
+}
+
+// casIncDec increments or decrements the cas value. The value wraps around in its length.
+func (s *Server) casIncDec(req *k2hdkc.Request) *k2hdkc.Response {
+	e, res := s.casEntry(req)
+	if res != nil {
+		return res
+	}
+	var v uint64
+	for i := len(e.val) - 1; i >= 0; i-- {
+		v = v<<8 | (uint64)(e.val[i])
+	}
+	if req.Op == k2hdkc.OpCasIncrement {
+		v++
+	} else {
+		v--
+	}
+	val := make([]byte, len(e.val))
+	for i := range val {
+		val[i] = (byte)(v >> (8 * uint(i)))
+	}
+	s.store(req.Key, val, req.Pass, req.Expire)
+	return success()
+}
+
+// queue returns the queue of the prefix.
+func (s *Server) queue(req *k2hdkc.Request) map[string][]*item {
+	if req.KeyQueue {
+		return s.kqueues
+	}
+	return s.queues
+}
+
+// queuePush adds the value to the tail of the queue if Fifo, otherwise to the head.
+// Pushing to a key queue saves the key too.
+func (s *Server) queuePush(req *k2hdkc.Request) *k2hdkc.Response {
+	if req.KeyQueue && len(req.Key) == 0 {
+		return failure(k2hdkc.SubCodeInvalid)
+	}
+	q := s.queue(req)
+	p := string(req.Prefix)
+	it := &item{
+		key:    clone(req.Key),
+		val:    clone(req.Val),
+		pass:   req.Pass,
+		expire: s.expireAt(req.Expire),
+	}
+	if req.Fifo {
+		q[p] = append(q[p], it)
+	} else {
+		q[p] = append([]*item{it}, q[p]...)
+	}
+	if req.KeyQueue {
+		s.store(req.Key, req.Val, req.Pass, req.Expire)
+	}
+	return success()
+}
+
+// queuePop removes the value at the head of the queue. Fifo only decides where queuePush adds a value.
+// Popping from a key queue removes the key too. No data returns if the queue is empty or the pass differs.
+func (s *Server) queuePop(req *k2hdkc.Request) *k2hdkc.Response {
+	q := s.queue(req)
+	p := string(req.Prefix)
+	for len(q[p]) > 0 {
+		it := q[p][0]
+		if s.expired(it.expire) {
+			q[p] = q[p][1:]
+			continue
+		}
+		if it.pass != req.Pass {
+			return noData()
+		}
+		q[p] = q[p][1:]
+		res := success()
+		res.Val = it.val
+		if req.KeyQueue {
+			res.Key = it.key
+			delete(s.entries, string(it.key))
+		}
+		return res
+	}
+	delete(q, p)
+	return noData()
+}
+
+// queueRemove removes the count of values from the head of the queue.
+func (s *Server) queueRemove(req *k2hdkc.Request) *k2hdkc.Response {
+	if req.Count <= 0 {
+		return failure(k2hdkc.SubCodeInvalid)
+	}
+	q := s.queue(req)
+	p := string(req.Prefix)
+	items := q[p]
+	n := int(req.Count)
+	if n > len(items) {
+		n = len(items)
+	}
+	removed, items := items[:n], items[n:]
+	if req.KeyQueue {
+		for _, it := range removed {
+			delete(s.entries, string(it.key))
+		}
+	}
+	if len(items) == 0 {
+		delete(q, p)
+	} else {
+		q[p] = items
+	}
+	return success()
+}
+
+// Local Variables:
+// c-basic-offset: 4
+// tab-width: 4
+// indent-tabs-mode: t
+// End:
+// vim600: noexpandtab sw=4 ts=4 fdm=marker
+// vim<600: noexpandtab sw=4 ts=4
@@ -0,0 +1,254 @@
+//
+// k2hdkc_go
+//
+// Copyright 2018 Yahoo Japan Corporation.
+//
+// Go driver for k2hdkc that is a highly available and scalable distributed
+// KVS clustering system. For k2hdkc, see
+// https://github.com/yahoojapan/k2hdkc for the details.
+//
+// For the full copyright and license information, please view
+// the license file that was distributed with this source code.
+//
+// AUTHOR:   Hirotaka Wakabayashi
+// CREATE:   Fri, 16 Oct 2026
+// REVISION:
+//
+
+package k2hdkctest
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/yahoojapan/k2hdkc_go/k2hdkc"
+)
+
+// TestValue tests values, passes and expiration.
+func TestValue(t *testing.T) {
+	s := NewServer()
+	now := time.Unix(1500000000, 0)
+	s.SetNow(func() time.Time { return now })
+	c := s.NewClient()
+	defer c.Close()
+
+	if err := c.SetValue("key", []byte("val")); err != nil {
+		t.Fatalf("c.SetValue(key, val) = %v", err)
+	}
+	if v, err := c.GetValue("key"); err != nil || string(v) != "val" {
+		t.Errorf("c.GetValue(key) = (%v, %v), want val", string(v), err)
+	}
+	if err := c.SetValue("secret", []byte("val"), k2hdkc.WithPass("pass"), k2hdkc.WithExpire(10*time.Second)); err != nil {
+		t.Fatalf("c.SetValue(secret, val, pass, expire) = %v", err)
+	}
+	if _, err := c.GetValue("secret"); !errors.Is(err, k2hdkc.ErrNotFound) {
+		t.Errorf("c.GetValue(secret) without the pass = %v, want ErrNotFound", err)
+	}
+	if v, err := c.GetValue("secret", k2hdkc.WithPass("pass")); err != nil || string(v) != "val" {
+		t.Errorf("c.GetValue(secret, pass) = (%v, %v), want val", string(v), err)
+	}
+	attrs, err := c.GetAttrs("secret")
+	if err != nil {
+		t.Fatalf("c.GetAttrs(secret) = %v", err)
+	}
+	if attrs["mtime"] != "1500000000" || attrs["expire"] != "1500000010" {
+		t.Errorf("c.GetAttrs(secret) = %v, want mtime 1500000000 and expire 1500000010", attrs)
+	}
+	now = now.Add(10 * time.Second)
+	if _, err := c.GetValue("secret", k2hdkc.WithPass("pass")); !errors.Is(err, k2hdkc.ErrNotFound) {
+		t.Errorf("c.GetValue(secret, pass) after expiration = %v, want ErrNotFound", err)
+	}
+	if err := c.Remove("key"); err != nil {
+		t.Errorf("c.Remove(key) = %v", err)
+	}
+	if err := c.Remove("key"); !errors.Is(err, k2hdkc.ErrNotFound) {
+		t.Errorf("c.Remove(key) twice = %v, want ErrNotFound", err)
+	}
+}
+
+// TestSubKeys tests subkeys and rename.
+func TestSubKeys(t *testing.T) {
+	s := NewServer()
+	c := s.NewClient()
+	defer c.Close()
+
+	if err := c.SetAll("parent", []byte("p"), []string{"a"}); err != nil {
+		t.Fatalf("c.SetAll(parent, p, [a]) = %v", err)
+	}
+	if err := c.AddSubKey("parent", "b", []byte("b")); err != nil {
+		t.Fatalf("c.AddSubKey(parent, b, b) = %v", err)
+	}
+	if err := c.AddSubKey("b", "c", []byte("c")); err != nil {
+		t.Fatalf("c.AddSubKey(b, c, c) = %v", err)
+	}
+	r, err := c.GetSubKeys("parent")
+	if err != nil {
+		t.Fatalf("c.GetSubKeys(parent) = %v", err)
+	}
+	if got, want := r.String(), []string{"a", "b"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("c.GetSubKeys(parent) = %v, want %v", got, want)
+	}
+	if err := c.Rename("b", "d", k2hdkc.WithParentKey("parent")); err != nil {
+		t.Fatalf("c.Rename(b, d, parent) = %v", err)
+	}
+	if r, err := c.GetSubKeys("parent"); err != nil || !reflect.DeepEqual(r.String(), []string{"a", "d"}) {
+		t.Errorf("c.GetSubKeys(parent) after rename = (%v, %v), want [a d]", r, err)
+	}
+	if sks, err := c.GetSubKeyList("parent"); err != nil || !reflect.DeepEqual(sks, []string{"a", "d"}) {
+		t.Errorf("c.GetSubKeyList(parent) after rename = (%v, %v), want [a d]", sks, err)
+	}
+	if sks, err := c.GetSubKeyList("nokey"); sks != nil || !errors.Is(err, k2hdkc.ErrNotFound) {
+		t.Errorf("c.GetSubKeyList(nokey) = (%v, %v), want ErrNotFound", sks, err)
+	}
+	if v, err := c.GetValue("d"); err != nil || string(v) != "b" {
+		t.Errorf("c.GetValue(d) = (%v, %v), want b", string(v), err)
+	}
+	// renaming replaces only the key in the subkey list of the parent, which never gets a missing key.
+	for _, k := range []string{"a", "other"} {
+		if err := c.SetValue(k, []byte(k)); err != nil {
+			t.Fatalf("c.SetValue(%v, %v) = %v", k, k, err)
+		}
+	}
+	if err := c.Rename("a", "e", k2hdkc.WithParentKey("other")); err != nil {
+		t.Fatalf("c.Rename(a, e, other) = %v", err)
+	}
+	if sks, err := c.GetSubKeyList("other"); err != nil || len(sks) != 0 {
+		t.Errorf("c.GetSubKeyList(other) after rename = (%v, %v), want none", sks, err)
+	}
+	if sks, err := c.GetSubKeyList("parent"); err != nil || !reflect.DeepEqual(sks, []string{"a", "d"}) {
+		t.Errorf("c.GetSubKeyList(parent) after rename of another parent = (%v, %v), want [a d]", sks, err)
+	}
+	if err := c.RemoveSubKey("parent", "d", k2hdkc.WithNest()); err != nil {
+		t.Fatalf("c.RemoveSubKey(parent, d, nest) = %v", err)
+	}
+	if _, err := c.GetValue("c"); !errors.Is(err, k2hdkc.ErrNotFound) {
+		t.Errorf("c.GetValue(c) after nested remove = %v, want ErrNotFound", err)
+	}
+	if err := c.RemoveSubKey("parent", "d"); !errors.Is(err, k2hdkc.ErrNotFound) {
+		t.Errorf("c.RemoveSubKey(parent, d) twice = %v, want ErrNotFound", err)
+	}
+	if err := c.ClearSubKeys("parent"); err != nil {
+		t.Fatalf("c.ClearSubKeys(parent) = %v", err)
+	}
+	if r, err := c.GetSubKeys("parent"); err != nil || len(r.Bytes()) != 0 {
+		t.Errorf("c.GetSubKeys(parent) after clear = (%v, %v), want empty", r, err)
+	}
+}
+
+// TestCas tests the cas values of each type.
+func TestCas(t *testing.T) {
+	s := NewServer()
+	c := s.NewClient()
+	defer c.Close()
+
+	for _, ct := range []k2hdkc.CasType{k2hdkc.CasType8, k2hdkc.CasType16, k2hdkc.CasType32, k2hdkc.CasType64} {
+		max := uint64(1)<<uint(ct) - 1
+		if err := c.CasInit("cas", max, k2hdkc.WithCasType(ct)); err != nil {
+			t.Fatalf("c.CasInit(cas, %v, %v) = %v", max, ct, err)
+		}
+		if err := c.CasIncrement("cas"); err != nil {
+			t.Fatalf("c.CasIncrement(cas) = %v", err)
+		}
+		if v, err := c.CasGet("cas", k2hdkc.WithCasType(ct)); err != nil || v != 0 {
+			t.Errorf("c.CasGet(cas, %v) after increment = (%v, %v), want 0", ct, v, err)
+		}
+		if err := c.CasDecrement("cas"); err != nil {
+			t.Fatalf("c.CasDecrement(cas) = %v", err)
+		}
+		if err := c.CasSet("cas", 1, 2, k2hdkc.WithCasType(ct)); !errors.Is(err, k2hdkc.ErrCasConflict) {
+			t.Errorf("c.CasSet(cas, 1, 2, %v) = %v, want ErrCasConflict", ct, err)
+		}
+		if err := c.CasSet("cas", max, 1, k2hdkc.WithCasType(ct)); err != nil {
+			t.Errorf("c.CasSet(cas, %v, 1, %v) = %v", max, ct, err)
+		}
+		if v, err := c.CasGet("cas", k2hdkc.WithCasType(ct)); err != nil || v != 1 {
+			t.Errorf("c.CasGet(cas, %v) = (%v, %v), want 1", ct, v, err)
+		}
+	}
+	if _, err := c.CasGet("cas", k2hdkc.WithCasType(k2hdkc.CasType8)); !errors.Is(err, k2hdkc.ErrInvalid) {
+		t.Errorf("c.CasGet(cas, CasType8) of a 64 bit value = %v, want ErrInvalid", err)
+	}
+}
+
+// TestQueue tests FIFO and LIFO queues and key queues.
+func TestQueue(t *testing.T) {
+	s := NewServer()
+	c := s.NewClient()
+	defer c.Close()
+
+	for _, v := range []string{"1", "2", "3"} {
+		if err := c.QueuePush("q", []byte(v)); err != nil {
+			t.Fatalf("c.QueuePush(q, %v) = %v", v, err)
+		}
+	}
+	if _, v, err := c.QueuePop("q"); err != nil || string(v) != "1" {
+		t.Errorf("c.QueuePop(q) = (%v, %v), want 1", string(v), err)
+	}
+	if _, v, err := c.QueuePop("q", k2hdkc.WithLifo()); err != nil || string(v) != "2" {
+		t.Errorf("c.QueuePop(q, lifo) = (%v, %v), want 2", string(v), err)
+	}
+	if err := c.QueueRemove("q", 5); err != nil {
+		t.Errorf("c.QueueRemove(q, 5) = %v", err)
+	}
+	if _, _, err := c.QueuePop("q"); !errors.Is(err, k2hdkc.ErrNotFound) {
+		t.Errorf("c.QueuePop(q) of an empty queue = %v, want ErrNotFound", err)
+	}
+
+	// values pushed in the LIFO order are pushed to the head and every pop takes the head.
+	for _, v := range []string{"1", "2", "3", "4"} {
+		if err := c.QueuePush("lq", []byte(v), k2hdkc.WithLifo()); err != nil {
+			t.Fatalf("c.QueuePush(lq, %v, lifo) = %v", v, err)
+		}
+	}
+	for _, want := range []string{"4", "3"} {
+		if _, v, err := c.QueuePop("lq", k2hdkc.WithLifo()); err != nil || string(v) != want {
+			t.Errorf("c.QueuePop(lq, lifo) = (%v, %v), want %v", string(v), err, want)
+		}
+	}
+	if err := c.QueueRemove("lq", 1, k2hdkc.WithLifo()); err != nil {
+		t.Errorf("c.QueueRemove(lq, 1, lifo) = %v", err)
+	}
+	if _, v, err := c.QueuePop("lq"); err != nil || string(v) != "1" {
+		t.Errorf("c.QueuePop(lq) = (%v, %v), want 1", string(v), err)
+	}
+
+	if err := c.KeyQueuePush("kq", "key", []byte("val")); err != nil {
+		t.Fatalf("c.KeyQueuePush(kq, key, val) = %v", err)
+	}
+	if v, err := c.GetValue("key"); err != nil || string(v) != "val" {
+		t.Errorf("c.GetValue(key) = (%v, %v), want val", string(v), err)
+	}
+	// string keys are sent with the terminating NUL.
+	k, v, err := c.QueuePop("kq", k2hdkc.WithKeyQueue())
+	if err != nil || string(k) != "key\x00" || string(v) != "val" {
+		t.Errorf("c.QueuePop(kq, keyqueue) = (%v, %v, %v), want key and val", string(k), string(v), err)
+	}
+	if _, err := c.GetValue("key"); !errors.Is(err, k2hdkc.ErrNotFound) {
+		t.Errorf("c.GetValue(key) after pop = %v, want ErrNotFound", err)
+	}
+}
+
+// TestReset tests Reset removes all data.
+func TestReset(t *testing.T) {
+	s := NewServer()
+	c := s.NewClient()
+	defer c.Close()
+
+	if err := c.SetValue("key", []byte("val")); err != nil {
+		t.Fatalf("c.SetValue(key, val) = %v", err)
+	}
+	s.Reset()
+	if _, err := c.GetValue("key"); !errors.Is(err, k2hdkc.ErrNotFound) {
+		t.Errorf("c.GetValue(key) after Reset = %v, want ErrNotFound", err)
+	}
+}
+
+// Local Variables:
+// c-basic-offset: 4
+// tab-width: 4
+// indent-tabs-mode: t
+// End:
+// vim600: noexpandtab sw=4 ts=4 fdm=marker
+// vim<600: noexpandtab sw=4 ts=4
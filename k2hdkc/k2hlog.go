@@ -18,21 +18,14 @@
 package k2hdkc
 
 import (
-	// #cgo CFLAGS: -g -O2 -Wall -Wextra -Wno-unused-variable -Wno-unused-parameter -I. -I/usr/include/k2hdkc -I/usr/include/chmpx -I/usr/include/k2hash
-	// #cgo LDFLAGS: -L/usr/lib -lk2hdkc -lchmpx -lk2hash
-	// #include <stdlib.h>
-	// #include "k2hdkc.h"
-	// #include "chmpx.h"
-	// #include "k2hash.h"
-	"C"
 	"fmt"
 	"io"
 	"log"
 	"os"
-)
-import (
+	"os/signal"
 	"sync"
-	"unsafe"
+	"syscall"
+	"time"
 )
 
 type logSeverity uint8
@@ -86,6 +79,7 @@ const (
 )
 
 // K2hLog holds pointer to log.Logger and logging configurations.
+// Each K2hLog has its own output and severity. Only the logging of the C libraries is global.
 type K2hLog struct {
 	file         string
 	fp           *os.File
@@ -93,9 +87,18 @@ type K2hLog struct {
 	libSeverity  logSeverity
 	bundleLibLog bool
 	logger       *log.Logger
+	mu           sync.Mutex    // guards the members. logging reads logger and severity under it.
+	refs         int           // the number of owners. the file is closed when it reaches 0.
+	rotator      *logRotator   // writes to the file if not nil.
+	shared       bool          // true while rotator is the one of the K2hLog cloned from.
+	client       bool          // true for the copy owned by a client. it never points the C libraries at its file.
+	maxSize      int64         // rotates the file if it's larger than maxSize. 0 means no limit.
+	maxAge       time.Duration // rotates the file if it's older than maxAge. 0 means no limit.
+	backups      int           // the number of rotated files kept.
 }
 
-// K2hLogInstance initializes a K2hLog structure
+// K2hLogInstance initializes a K2hLog structure shared in the process.
+// The package holds a reference of it, so closing it never closes the file in use by others.
 func K2hLogInstance() *K2hLog {
 	// In once.Do method, Mutex.Lock() calls before the anonymous function calls.
 	// defer Mutex.Unlock() calls at the end of the enclosing function.
@@ -107,6 +110,13 @@ func K2hLogInstance() *K2hLog {
 	return k2hlog
 }
 
+// NewK2hLog returns a new K2hLog independent of K2hLogInstance. It's configured by the
+// GO_K2HDKC_DBGLEVEL and GO_K2HDKC_DBGFILE environments like K2hLogInstance.
+// The caller owns a reference of it and must call Close.
+func NewK2hLog() *K2hLog {
+	return newK2hLog()
+}
+
 // newK2hLog initializes a K2hLog structure.
 func newK2hLog() *K2hLog {
 	severity := defaultLogSeverity
@@ -118,19 +128,13 @@ func newK2hLog() *K2hLog {
 	file := defaultLogFile
 	if f := os.Getenv("GO_K2HDKC_DBGFILE"); f != "" {
 		file = f
-		fp, err := os.OpenFile(file, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0644)
-		if err != nil {
-			log.Printf("[%v] %v open error:[%s]. Fallback to stderr.", logSeverityText[SeverityError], file, err)
-			fp = os.Stderr
-		}
-		logger := log.New(fp, "", log.LstdFlags|log.Lmicroseconds|log.Lshortfile)
 		l := &K2hLog{
 			file:        file,
-			fp:          fp,
-			logger:      logger, // l.logger refers logger.
 			severity:    severity,
 			libSeverity: defaultLibLogSeverity,
+			refs:        1,
 		}
+		l.fp, l.rotator, l.logger = openLogFile(file)
 		return l
 	}
 	// 2009/01/23 01:23:23.123123 sample.go:10: message
@@ -142,65 +146,183 @@ func newK2hLog() *K2hLog {
 		severity:     severity,
 		libSeverity:  defaultLibLogSeverity,
 		bundleLibLog: defaultBundleLibLog,
+		refs:         1,
+	}
+	return l
+}
+
+// clone returns a new K2hLog with the same severity and output as l.
+// A log file is shared with l until SetLogFile is called on the new K2hLog, so it is
+// rotated only by l. The new K2hLog never changes the log files of the C libraries.
+func (l *K2hLog) clone() *K2hLog {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	n := &K2hLog{
+		file:        l.file,
+		fp:          l.fp,
+		severity:    l.severity,
+		libSeverity: l.libSeverity,
+		maxSize:     l.maxSize,
+		maxAge:      l.maxAge,
+		backups:     l.backups,
+		client:      true,
+		refs:        1,
+	}
+	if l.rotator != nil {
+		n.rotator = l.rotator.retain()
+		n.shared = true
+		n.logger = log.New(n.rotator, "", log.LstdFlags|log.Lmicroseconds|log.Lshortfile)
+	} else {
+		n.logger = log.New(l.logger.Writer(), "", log.LstdFlags|log.Lmicroseconds|log.Lshortfile)
 	}
+	return n
+}
+
+// Retain adds a reference of the K2hLog and returns it. Each reference is released by Close.
+func (l *K2hLog) Retain() *K2hLog {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.refs++
 	return l
 }
 
-// Close closes the file descriptor.
+// openLogFile opens the file and returns the logger writing to it.
+// The logger writes to stderr if the file can't be opened.
+func openLogFile(f string) (*os.File, *logRotator, *log.Logger) {
+	r, err := openLogRotator(f)
+	if err != nil {
+		log.Printf("[%v] %v open error:[%s]. Fallback to stderr.", logSeverityText[SeverityError], f, err)
+		return os.Stderr, nil, log.New(os.Stderr, "", log.LstdFlags|log.Lmicroseconds|log.Lshortfile)
+	}
+	return nil, r, log.New(r, "", log.LstdFlags|log.Lmicroseconds|log.Lshortfile)
+}
+
+// Close releases a reference and closes the log file when no reference remains.
+// stderr is never closed.
 // NOTICE You must call Close() to avoid leaking file descriptor.
 func (l *K2hLog) Close() {
-	if l.fp != nil {
-		l.fp.Close()
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.refs > 1 {
+		l.refs--
+		return
+	}
+	l.refs = 0
+	if l.rotator != nil {
+		l.rotator.Close()
 	}
 }
 
 // String returns a text representation of the object.
 func (l *K2hLog) String() string {
+	l.mu.Lock()
+	defer l.mu.Unlock()
 	return fmt.Sprintf("[%v, %v, %v, %v, %v]", l.file, l.fp, l.severity, l.libSeverity, l.bundleLibLog)
 }
 
 // BundleLibLog sets the log file for dependent libraries.
+// It does nothing while CaptureLibLog captures the output of the libraries, or for the
+// logger of a client because the log files of the libraries are global in the process.
 func (l *K2hLog) BundleLibLog(b bool) {
-	if l.file != "" && b {
-		cs := C.CString(l.file)
-		defer C.free(unsafe.Pointer(cs))
-		C.k2hdkc_set_debug_file(cs)
-		C.chmpx_set_debug_file(cs)
-		C.k2h_set_debug_file(cs)
+	if libLogCaptured() {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.client {
+		return
+	}
+	l.bundleLibLog = l.file != "" && b
+	if l.rotator != nil {
+		// the rotator points the libraries at the file again after every rotation.
+		l.rotator.setLibLog(l.bundleLibLog)
+	} else if l.bundleLibLog {
+		setLibLogFiles(l.file, l.file, l.file)
 	} else {
-		C.k2hdkc_unset_debug_file()
-		C.chmpx_unset_debug_file()
-		C.k2h_unset_debug_file()
+		setLibLogFiles("", "", "")
 	}
 }
 
 // SetLogFile sets the file to be logged.
 func (l *K2hLog) SetLogFile(f string) {
+	var (
+		fp     = os.Stderr
+		r      *logRotator
+		logger = log.New(os.Stderr, "", log.LstdFlags|log.Lmicroseconds|log.Lshortfile)
+	)
 	if f != "" {
-		fp, err := os.OpenFile(f, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0644)
-		if err != nil {
-			log.Printf("[%v] %v open error:[%s]. Fallback to stderr.", logSeverityText[SeverityError], f, err)
-			fp = os.Stderr
-		}
-		logger := log.New(fp, "", log.LstdFlags|log.Lmicroseconds|log.Lshortfile)
-		if logger != nil {
-			// new logger comes up. closing existing logger.
-			l.Close()
+		fp, r, logger = openLogFile(f)
+		if r != nil {
+			r.setLimits(l.maxSize, l.maxAge, l.backups)
 		}
-		l.file = f
-		l.fp = fp
-		l.logger = logger
-		l.BundleLibLog(true)
-	} else {
-		logger := log.New(os.Stderr, "", log.LstdFlags|log.Lmicroseconds|log.Lshortfile)
-		if logger != nil {
-			// new logger comes up. closing existing logger.
-			l.Close()
+	}
+	l.mu.Lock()
+	if l.rotator != nil {
+		// new logger comes up. closing existing file.
+		l.rotator.Close()
+	}
+	l.file = f
+	l.fp = fp
+	l.rotator = r
+	l.shared = false
+	l.logger = logger
+	l.mu.Unlock()
+	l.BundleLibLog(f != "") // redirect logging to stderr if f is empty.
+}
+
+// SetLogRotation rotates the log file when it gets larger than maxSize bytes or older than maxAge.
+// The rotated files are renamed to file.1, the newest one, up to file.N where N is backups.
+// 0 means no limit of the size or the age. The C libraries are pointed at the new file after
+// every rotation if BundleLibLog is on. The logger of a client sharing the file of
+// K2hLogInstance keeps the limits until SetLogFile is called on it.
+func (l *K2hLog) SetLogRotation(maxSize int64, maxAge time.Duration, backups int) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.maxSize = maxSize
+	l.maxAge = maxAge
+	l.backups = backups
+	if l.rotator != nil && !l.shared {
+		l.rotator.setLimits(maxSize, maxAge, backups)
+	}
+}
+
+// Reopen closes the log file and opens it again. Call it after an external tool like
+// logrotate renamed the file. The C libraries are pointed at the new file if BundleLibLog is on.
+// It does nothing if the log goes to stderr.
+func (l *K2hLog) Reopen() error {
+	l.mu.Lock()
+	r := l.rotator
+	l.mu.Unlock()
+	if r == nil {
+		return nil
+	}
+	return r.reopen()
+}
+
+// ReopenOnSIGHUP calls Reopen whenever the process receives SIGHUP, which logrotate sends
+// after renaming the file. Calling the returned function stops it.
+func (l *K2hLog) ReopenOnSIGHUP() (stop func()) {
+	ch := make(chan os.Signal, 1)
+	signal.Notify(ch, syscall.SIGHUP)
+	done := make(chan struct{})
+	go func() {
+		for {
+			select {
+			case <-ch:
+				if err := l.Reopen(); err != nil {
+					log.Printf("[%v] %v reopen error:[%s].", logSeverityText[SeverityError], l.file, err)
+				}
+			case <-done:
+				return
+			}
 		}
-		l.file = f
-		l.fp = os.Stderr
-		l.logger = logger
-		l.BundleLibLog(false) // redirect logging to stderr.
+	}()
+	var stopOnce sync.Once
+	return func() {
+		stopOnce.Do(func() {
+			signal.Stop(ch)
+			close(done)
+		})
 	}
 }
 
@@ -226,9 +348,19 @@ func normalizeSeverity(p string) logSeverity {
 
 // SetLogSeverity sets the severity member.
 func (l *K2hLog) SetLogSeverity(p logSeverity) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
 	l.severity = p
 }
 
+// output returns the logger and the severity, which SetLogFile and SetLogSeverity
+// may replace while other goroutines are logging.
+func (l *K2hLog) output() (*log.Logger, logSeverity) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.logger, l.severity
+}
+
 // SetLibLogSeverity enables logging of the dependent libraries.
 func (l *K2hLog) SetLibLogSeverity(p logSeverity) {
 	if (p & LibComlog) != 0 {
@@ -254,144 +386,100 @@ func (l *K2hLog) SetLibLogSeverity(p logSeverity) {
 
 // SetComlog enables the k2hdkc communication logging.
 func (l *K2hLog) SetComlog(b bool) {
-	if b {
-		switch l.severity {
-		case SeveritySilent:
-			C.k2hdkc_disable_comlog()
-		default:
-			C.k2hdkc_enable_comlog()
-		}
-	} else {
-		C.k2hdkc_disable_comlog()
-	}
+	_, severity := l.output()
+	setLibComlog(b && severity != SeveritySilent)
 }
 
 // SetK2hdkcLog sets the serverity of k2hdkc library logger.
 func (l *K2hLog) SetK2hdkcLog(b bool) {
 	if b {
-		switch l.severity {
-		case SeveritySilent:
-			C.k2hdkc_set_debug_level_silent() // set silent for debugging level
-		case SeverityError:
-			C.k2hdkc_set_debug_level_error() // set error for debugging level
-		case SeverityWarning:
-			C.k2hdkc_set_debug_level_warning() // set warning for debugging level
-		case SeverityInfo:
-			C.k2hdkc_set_debug_level_message() // set message for debugging level
-		case SeverityDump:
-			C.k2hdkc_set_debug_level_dump() // set dump for debugging level
-		default:
-			log.Printf("[%v] Unknown severity. Fallback to ERROR.", logSeverityText[SeverityError])
-			C.k2hdkc_set_debug_level_message() // set message for debugging leve
-		}
+		_, severity := l.output()
+		setLibLogLevel(ComponentK2hdkc, severity)
 	} else {
-		C.k2hdkc_set_debug_level_silent() // set silent for debugging level
+		setLibLogLevel(ComponentK2hdkc, SeveritySilent)
 	}
 }
 
 // SetChmpxLog sets the serverity of chmpx library logger.
 func (l *K2hLog) SetChmpxLog(b bool) {
 	if b {
-		switch l.severity {
-		case SeveritySilent:
-			C.chmpx_set_debug_level_silent() // set silent for debugging level
-		case SeverityError:
-			C.chmpx_set_debug_level_error() // set error for debugging level
-		case SeverityWarning:
-			C.chmpx_set_debug_level_warning() // set warning for debugging level
-		case SeverityInfo:
-			C.chmpx_set_debug_level_message() // set message for debugging level
-		case SeverityDump:
-			C.chmpx_set_debug_level_dump() // set dump for debugging level
-		default:
-			log.Printf("[%v] Unknown severity. Fallback to ERROR.", logSeverityText[SeverityError])
-			C.chmpx_set_debug_level_message() // set message for debugging leve
-		}
+		_, severity := l.output()
+		setLibLogLevel(ComponentChmpx, severity)
 	} else {
-		C.chmpx_set_debug_level_silent() // set silent for debugging level
+		setLibLogLevel(ComponentChmpx, SeveritySilent)
 	}
 }
 
 // SetK2hashLog sets the serverity of k2hash library logger.
 func (l *K2hLog) SetK2hashLog(b bool) {
 	if b {
-		switch l.severity {
-		case SeveritySilent:
-			C.k2h_set_debug_level_silent() // set silent for debugging level
-		case SeverityError:
-			C.k2h_set_debug_level_error() // set error for debugging level
-		case SeverityWarning:
-			C.k2h_set_debug_level_warning() // set warning for debugging level
-		case SeverityInfo, SeverityDump:
-			C.k2h_set_debug_level_message() // set message for debugging level
-		default:
-			log.Printf("[%v] Unknown severity. Fallback to ERROR.", logSeverityText[SeverityError])
-			C.k2h_set_debug_level_message() // set message for debugging leve
-		}
+		_, severity := l.output()
+		setLibLogLevel(ComponentK2hash, severity)
 	} else {
-		C.k2h_set_debug_level_silent() // set silent for debugging level
+		setLibLogLevel(ComponentK2hash, SeveritySilent)
 	}
 }
 
 // Dump prints a debug message.
 func (l *K2hLog) Dump(msg string) {
-	if l.severity&(SeverityDump) != 0 {
-		l.logger.Printf("[%v] %v", logSeverityText[SeverityDump], msg)
+	if logger, severity := l.output(); severity&(SeverityDump) != 0 {
+		logger.Printf("[%v] %v", logSeverityText[SeverityDump], msg)
 	}
 }
 
 // Dumpf prints a formatted debug message.
 func (l *K2hLog) Dumpf(format string, v ...interface{}) {
-	if l.severity&(SeverityDump) != 0 {
-		l.logger.Printf("[%v] %v", logSeverityText[SeverityDump], fmt.Sprintf(format, v...))
+	if logger, severity := l.output(); severity&(SeverityDump) != 0 {
+		logger.Printf("[%v] %v", logSeverityText[SeverityDump], fmt.Sprintf(format, v...))
 	}
 }
 
 // Info prints a normal severity message.
 func (l *K2hLog) Info(msg string) {
-	if l.severity&(SeverityDump|SeverityInfo) != 0 {
-		l.logger.Printf("[%v] %v", logSeverityText[SeverityInfo], msg)
+	if logger, severity := l.output(); severity&(SeverityDump|SeverityInfo) != 0 {
+		logger.Printf("[%v] %v", logSeverityText[SeverityInfo], msg)
 	}
 }
 
 // Infof prints a formatted normal severity message.
 func (l *K2hLog) Infof(format string, v ...interface{}) {
-	if l.severity&(SeverityDump|SeverityInfo) != 0 {
-		l.logger.Printf("[%v] %v", logSeverityText[SeverityInfo], fmt.Sprintf(format, v...))
+	if logger, severity := l.output(); severity&(SeverityDump|SeverityInfo) != 0 {
+		logger.Printf("[%v] %v", logSeverityText[SeverityInfo], fmt.Sprintf(format, v...))
 	}
 }
 
 // Error prints an error message.
 func (l *K2hLog) Error(msg string) {
-	if l.severity&(SeverityDump|SeverityInfo|SeverityError) != 0 {
-		l.logger.Printf("[%v] %v", logSeverityText[SeverityError], msg)
+	if logger, severity := l.output(); severity&(SeverityDump|SeverityInfo|SeverityError) != 0 {
+		logger.Printf("[%v] %v", logSeverityText[SeverityError], msg)
 	}
 }
 
 // Errorf prints a formatted error message.
 func (l *K2hLog) Errorf(format string, v ...interface{}) {
-	if l.severity&(SeverityDump|SeverityInfo|SeverityError) != 0 {
-		l.logger.Printf("[%v] %v", logSeverityText[SeverityError], fmt.Sprintf(format, v...))
+	if logger, severity := l.output(); severity&(SeverityDump|SeverityInfo|SeverityError) != 0 {
+		logger.Printf("[%v] %v", logSeverityText[SeverityError], fmt.Sprintf(format, v...))
 	}
 }
 
 // Warn prints a warning message.
 func (l *K2hLog) Warn(msg string) {
-	if l.severity&(SeverityDump|SeverityInfo|SeverityError|SeverityWarning) != 0 {
-		l.logger.Printf("[%v] %v", logSeverityText[SeverityWarning], msg)
+	if logger, severity := l.output(); severity&(SeverityDump|SeverityInfo|SeverityError|SeverityWarning) != 0 {
+		logger.Printf("[%v] %v", logSeverityText[SeverityWarning], msg)
 	}
 }
 
 // Warnf prints a formatted warning message.
 func (l *K2hLog) Warnf(format string, v ...interface{}) {
-	if l.severity&(SeverityDump|SeverityInfo|SeverityError|SeverityWarning) != 0 {
-		l.logger.Printf("[%v] %v", logSeverityText[SeverityWarning], fmt.Sprintf(format, v...))
+	if logger, severity := l.output(); severity&(SeverityDump|SeverityInfo|SeverityError|SeverityWarning) != 0 {
+		logger.Printf("[%v] %v", logSeverityText[SeverityWarning], fmt.Sprintf(format, v...))
 	}
 }
 
 // SetOutput sets the destination for the logger.
 func (l *K2hLog) SetOutput(w io.Writer) {
-	l.logger.SetOutput(w)
+	logger, _ := l.output()
+	logger.SetOutput(w)
 }
 
 // Local Variables:
@@ -0,0 +1,222 @@
+//
+// k2hdkc_go
+//
+// Copyright 2018 Yahoo Japan Corporation.
+//
+// Go driver for k2hdkc that is a highly available and scalable distributed
+// KVS clustering system. For k2hdkc, see
+// https://github.com/yahoojapan/k2hdkc for the details.
+//
+// For the full copyright and license information, please view
+// the license file that was distributed with this source code.
+//
+// AUTHOR:   Hirotaka Wakabayashi
+// CREATE:   Fri, 16 Oct 2026
+// REVISION:
+//
+
+package k2hdkc
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// ComponentK2hdkc, ComponentChmpx and ComponentK2hash are the values of the component field
+// of the messages captured from the C libraries.
+const (
+	ComponentK2hdkc = "k2hdkc"
+	ComponentChmpx  = "chmpx"
+	ComponentK2hash = "k2hash"
+)
+
+// libLogDrainTimeout is how long stopping a capture waits for the C libraries to close the pipes
+// and then for the readers to return.
+var libLogDrainTimeout = time.Second
+
+// libLogLevels maps the level tags of the C library messages to the levels.
+var libLogLevels = map[string]Level{
+	"ERR":     LevelError,
+	"ERROR":   LevelError,
+	"WAN":     LevelWarn,
+	"WARN":    LevelWarn,
+	"WARNING": LevelWarn,
+	"MSG":     LevelInfo,
+	"INFO":    LevelInfo,
+	"DMP":     LevelDebug,
+	"DUMP":    LevelDebug,
+}
+
+// libLogCapture reads the debug output of the C libraries from pipes.
+type libLogCapture struct {
+	logger  Logger
+	readers []*os.File
+	writers map[string]*os.File // the write ends by component. the C libraries open them by path.
+	wg      sync.WaitGroup
+}
+
+var (
+	captureMu sync.Mutex
+	capture   *libLogCapture // the running capture if not nil.
+	captured  int32          // 1 while capture isn't nil.
+)
+
+// libLogCaptured returns true while the output of the C libraries is captured. It doesn't take
+// captureMu, since the readers call it when their Logger rotates the log file.
+func libLogCaptured() bool {
+	return atomic.LoadInt32(&captured) != 0
+}
+
+// CaptureLibLog sends the debug output of the k2hdkc, chmpx and k2hash libraries to the Logger
+// through pipes instead of files. Each line is logged at the level parsed from it with the
+// component field. The libraries write only the messages enabled by SetLibLogSeverity.
+// The debug files of the libraries are global, so the output goes to the last Logger given.
+// BundleLibLog does nothing while capturing. nil stops capturing and the libraries write to
+// stderr again.
+func CaptureLibLog(l Logger) error {
+	captureMu.Lock()
+	old := capture
+	capture = nil
+	var err error
+	if l != nil {
+		capture, err = startLibLogCapture(l)
+	}
+	if capture != nil {
+		atomic.StoreInt32(&captured, 1)
+	} else {
+		atomic.StoreInt32(&captured, 0)
+		if old != nil {
+			setLibLogFiles("", "", "")
+		}
+	}
+	if old != nil {
+		old.close()
+	}
+	captureMu.Unlock()
+	// the readers may log while the old capture drains, so captureMu isn't held.
+	if old != nil {
+		old.wait()
+	}
+	return err
+}
+
+// CaptureLibLog sends the debug output of the C libraries to the K2hLog instead of the log file
+// if b is true. See the CaptureLibLog function. It turns BundleLibLog off.
+func (l *K2hLog) CaptureLibLog(b bool) error {
+	if !b {
+		return CaptureLibLog(nil)
+	}
+	l.BundleLibLog(false)
+	return CaptureLibLog(l)
+}
+
+// startLibLogCapture opens a pipe for each library and points the library at it.
+func startLibLogCapture(l Logger) (*libLogCapture, error) {
+	c := &libLogCapture{logger: l, writers: make(map[string]*os.File)}
+	for _, component := range []string{ComponentK2hdkc, ComponentChmpx, ComponentK2hash} {
+		r, w, err := os.Pipe()
+		if err != nil {
+			// the readers return at once since no library has opened the pipes.
+			c.close()
+			for _, r := range c.readers {
+				r.Close()
+			}
+			return nil, fmt.Errorf("os.Pipe() returned %v", err)
+		}
+		c.readers = append(c.readers, r)
+		c.writers[component] = w
+		c.wg.Add(1)
+		go c.read(component, r)
+	}
+	setLibLogFiles(pipePath(c.writers[ComponentK2hdkc]), pipePath(c.writers[ComponentChmpx]), pipePath(c.writers[ComponentK2hash]))
+	return c, nil
+}
+
+// pipePath returns the path which opens the same pipe as w.
+func pipePath(w *os.File) string {
+	return fmt.Sprintf("/dev/fd/%d", w.Fd())
+}
+
+// read logs the lines from r until all the write ends are closed.
+func (c *libLogCapture) read(component string, r *os.File) {
+	defer c.wg.Done()
+	br := bufio.NewReader(r)
+	for {
+		// ReadString never stops on a long line, which would block the library.
+		line, err := br.ReadString('\n')
+		if level, msg := parseLibLogLine(line); msg != "" {
+			c.logger.Log(context.Background(), level, msg, Field{FieldComponent, component})
+		}
+		if err != nil {
+			return
+		}
+	}
+}
+
+// close closes the write ends after the libraries have been pointed at other files.
+func (c *libLogCapture) close() {
+	for _, w := range c.writers {
+		w.Close()
+	}
+}
+
+// wait waits for the readers to log the rest of the output and closes the read ends, which
+// stops the readers if a library still holds a pipe open. Readers which don't return then are given up.
+func (c *libLogCapture) wait() {
+	done := make(chan struct{})
+	go func() {
+		c.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(libLogDrainTimeout):
+		// a library still holds the pipe open.
+	}
+	for _, r := range c.readers {
+		r.Close()
+	}
+	select {
+	case <-done:
+	case <-time.After(libLogDrainTimeout):
+		// a reader is blocked in the Logger.
+	}
+}
+
+// parseLibLogLine returns the level and the message of a line of the C libraries like
+// "[ERR] k2hdkccom.cc(123) : message". The first bracketed level tag is removed from the message.
+// LevelInfo is returned if the line has no level tag.
+func parseLibLogLine(line string) (Level, string) {
+	line = strings.TrimSpace(line)
+	for i := 0; i < len(line); {
+		start := strings.IndexByte(line[i:], '[')
+		if start < 0 {
+			break
+		}
+		start += i
+		end := strings.IndexByte(line[start:], ']')
+		if end < 0 {
+			break
+		}
+		end += start
+		if level, ok := libLogLevels[strings.ToUpper(line[start+1:end])]; ok {
+			return level, strings.TrimSpace(line[:start] + line[end+1:])
+		}
+		i = end + 1
+	}
+	return LevelInfo, line
+}
+
+// Local Variables:
+// c-basic-offset: 4
+// tab-width: 4
+// indent-tabs-mode: t
+// End:
+// vim600: noexpandtab sw=4 ts=4 fdm=marker
+// vim<600: noexpandtab sw=4 ts=4
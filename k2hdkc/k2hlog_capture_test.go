@@ -0,0 +1,172 @@
+//
+// k2hdkc_go
+//
+// Copyright 2018 Yahoo Japan Corporation.
+//
+// Go driver for k2hdkc that is a highly available and scalable distributed
+// KVS clustering system. For k2hdkc, see
+// https://github.com/yahoojapan/k2hdkc for the details.
+//
+// For the full copyright and license information, please view
+// the license file that was distributed with this source code.
+//
+// AUTHOR:   Hirotaka Wakabayashi
+// CREATE:   Fri, 16 Oct 2026
+// REVISION:
+//
+
+package k2hdkc
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// TestParseLibLogLine tests the levels and the messages parsed from the C library lines.
+func TestParseLibLogLine(t *testing.T) {
+	tests := []struct {
+		line  string
+		level Level
+		msg   string
+	}{
+		{"[ERR] k2hdkccom.cc(123) : failed\n", LevelError, "k2hdkccom.cc(123) : failed"},
+		{"[WAN] chmpx.cc(1) : slow", LevelWarn, "chmpx.cc(1) : slow"},
+		{"2026-10-16 [MSG] hello [ERR]", LevelInfo, "2026-10-16  hello [ERR]"},
+		{"[CHMPX] [DMP] dump", LevelDebug, "[CHMPX]  dump"},
+		{"no tag", LevelInfo, "no tag"},
+		{"\n", LevelInfo, ""},
+	}
+	for _, tt := range tests {
+		if level, msg := parseLibLogLine(tt.line); level != tt.level || msg != tt.msg {
+			t.Errorf("parseLibLogLine(%q) = (%v, %q), want (%v, %q)", tt.line, level, msg, tt.level, tt.msg)
+		}
+	}
+}
+
+// TestCaptureLibLog tests the lines written to the pipes are logged with the component.
+func TestCaptureLibLog(t *testing.T) {
+	var buf bytes.Buffer
+	if err := CaptureLibLog(NewJSONLogger(&buf, LevelWarn)); err != nil {
+		t.Fatalf("CaptureLibLog(l) = %v", err)
+	}
+	captureMu.Lock()
+	capture.writers[ComponentChmpx].WriteString("[ERR] chmpx.cc(12) : failed\n[MSG] hidden\n")
+	capture.writers[ComponentK2hash].WriteString("[WAN] k2hash.cc(3) : slow")
+	captureMu.Unlock()
+	if err := CaptureLibLog(nil); err != nil {
+		t.Fatalf("CaptureLibLog(nil) = %v", err)
+	}
+
+	got := make(map[string]map[string]interface{})
+	for _, l := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		m := make(map[string]interface{})
+		if err := json.Unmarshal([]byte(l), &m); err != nil {
+			t.Fatalf("json.Unmarshal(%q) = %v", l, err)
+		}
+		got[m[FieldComponent].(string)] = m
+	}
+	if len(got) != 2 {
+		t.Fatalf("log = %q, want 2 lines", buf.String())
+	}
+	if m := got[ComponentChmpx]; m["level"] != "ERROR" || m["msg"] != "chmpx.cc(12) : failed" {
+		t.Errorf("chmpx line = %v", m)
+	}
+	if m := got[ComponentK2hash]; m["level"] != "WARN" || m["msg"] != "k2hash.cc(3) : slow" {
+		t.Errorf("k2hash line = %v", m)
+	}
+}
+
+// TestCaptureLibLogBundle tests changing the log file doesn't stop capturing.
+func TestCaptureLibLogBundle(t *testing.T) {
+	l := NewK2hLog()
+	defer l.Close()
+	if err := l.CaptureLibLog(true); err != nil {
+		t.Fatalf("l.CaptureLibLog(true) = %v", err)
+	}
+	defer l.CaptureLibLog(false)
+	f := filepath.Join(t.TempDir(), "bundle.log")
+	l.SetLogFile(f)
+	l.BundleLibLog(true)
+	libLog.mu.Lock()
+	files := libLog.files
+	libLog.mu.Unlock()
+	for _, file := range files {
+		if !strings.HasPrefix(file, "/dev/fd/") {
+			t.Errorf("the library log file = %q after SetLogFile(%q), want a pipe", file, f)
+		}
+	}
+	if !libLogCaptured() {
+		t.Errorf("libLogCaptured() = false after SetLogFile(%q)", f)
+	}
+}
+
+// stuckLogger blocks in Log until release is closed and then checks the capture like a
+// K2hLog which rotates its file.
+type stuckLogger struct {
+	logged  chan struct{}
+	release chan struct{}
+	once    sync.Once
+}
+
+func (l *stuckLogger) Enabled(ctx context.Context, level Level) bool { return true }
+
+func (l *stuckLogger) Log(ctx context.Context, level Level, msg string, fields ...Field) {
+	l.once.Do(func() { close(l.logged) })
+	<-l.release
+	libLogCaptured()
+}
+
+// TestCaptureLibLogStop tests stopping a capture neither deadlocks with a reader checking the
+// capture nor waits forever for a reader blocked in the Logger.
+func TestCaptureLibLogStop(t *testing.T) {
+	for _, stuck := range []bool{false, true} {
+		l := &stuckLogger{logged: make(chan struct{}), release: make(chan struct{})}
+		if err := CaptureLibLog(l); err != nil {
+			t.Fatalf("CaptureLibLog(l) = %v", err)
+		}
+		captureMu.Lock()
+		capture.writers[ComponentChmpx].WriteString("[ERR] chmpx.cc(12) : failed\n")
+		captureMu.Unlock()
+		<-l.logged
+
+		drain := libLogDrainTimeout
+		if stuck {
+			libLogDrainTimeout = 10 * time.Millisecond
+		}
+		done := make(chan error, 1)
+		go func() { done <- CaptureLibLog(nil) }()
+		if !stuck {
+			time.Sleep(10 * time.Millisecond)
+			close(l.release)
+		}
+		select {
+		case err := <-done:
+			if err != nil {
+				t.Errorf("CaptureLibLog(nil) = %v", err)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("CaptureLibLog(nil) is blocked by the reader (stuck %v)", stuck)
+		}
+		libLogDrainTimeout = drain
+		if libLogCaptured() {
+			t.Errorf("libLogCaptured() = true after CaptureLibLog(nil)")
+		}
+		if stuck {
+			close(l.release)
+		}
+	}
+}
+
+// Local Variables:
+// c-basic-offset: 4
+// tab-width: 4
+// indent-tabs-mode: t
+// End:
+// vim600: noexpandtab sw=4 ts=4 fdm=marker
+// vim<600: noexpandtab sw=4 ts=4
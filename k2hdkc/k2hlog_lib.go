@@ -0,0 +1,90 @@
+//
+// k2hdkc_go
+//
+// Copyright 2018 Yahoo Japan Corporation.
+//
+// Go driver for k2hdkc that is a highly available and scalable distributed
+// KVS clustering system. For k2hdkc, see
+// https://github.com/yahoojapan/k2hdkc for the details.
+//
+// For the full copyright and license information, please view
+// the license file that was distributed with this source code.
+//
+// AUTHOR:   Hirotaka Wakabayashi
+// CREATE:   Fri, 16 Oct 2026
+// REVISION:
+//
+
+package k2hdkc
+
+import (
+	"sync"
+)
+
+// libLog is the logging configuration of the C libraries. It's global in the process
+// because the libraries keep it in global variables, so the last K2hLog which sets it wins.
+var libLog struct {
+	mu     sync.Mutex
+	levels map[string]logSeverity // the debug levels by component.
+	files  [3]string              // the debug files of k2hdkc, chmpx and k2hash. "" means stderr.
+}
+
+// setLibComlog enables or disables the k2hdkc communication logging.
+func setLibComlog(b bool) {
+	libLog.mu.Lock()
+	defer libLog.mu.Unlock()
+	setComlog(b)
+}
+
+// setLibLogLevel sets the debug level of the C library of the component.
+func setLibLogLevel(component string, p logSeverity) {
+	libLog.mu.Lock()
+	defer libLog.mu.Unlock()
+	switch component {
+	case ComponentK2hdkc:
+		setK2hdkcDebugLevel(p)
+	case ComponentChmpx:
+		setChmpxDebugLevel(p)
+	case ComponentK2hash:
+		setK2hashDebugLevel(p)
+	default:
+		return
+	}
+	if libLog.levels == nil {
+		libLog.levels = make(map[string]logSeverity)
+	}
+	libLog.levels[component] = p
+}
+
+// setLibLogFiles points the C libraries at the debug files. All "" means stderr.
+func setLibLogFiles(k2hdkcFile, chmpxFile, k2hashFile string) {
+	libLog.mu.Lock()
+	defer libLog.mu.Unlock()
+	if k2hdkcFile == "" && chmpxFile == "" && k2hashFile == "" {
+		unsetLibDebugFile()
+	} else {
+		setK2hdkcDebugFile(k2hdkcFile)
+		setChmpxDebugFile(chmpxFile)
+		setK2hashDebugFile(k2hashFile)
+	}
+	libLog.files = [3]string{k2hdkcFile, chmpxFile, k2hashFile}
+}
+
+// LibLogLevel returns the debug level of the C library of the component set last,
+// which is SeveritySilent if it's never set.
+func LibLogLevel(component string) logSeverity {
+	libLog.mu.Lock()
+	defer libLog.mu.Unlock()
+	if p, ok := libLog.levels[component]; ok {
+		return p
+	}
+	return SeveritySilent
+}
+
+// Local Variables:
+// c-basic-offset: 4
+// tab-width: 4
+// indent-tabs-mode: t
+// End:
+// vim600: noexpandtab sw=4 ts=4 fdm=marker
+// vim<600: noexpandtab sw=4 ts=4
@@ -0,0 +1,133 @@
+//
+// k2hdkc_go
+//
+// Copyright 2018 Yahoo Japan Corporation.
+//
+// Go driver for k2hdkc that is a highly available and scalable distributed
+// KVS clustering system. For k2hdkc, see
+// https://github.com/yahoojapan/k2hdkc for the details.
+//
+// For the full copyright and license information, please view
+// the license file that was distributed with this source code.
+//
+// AUTHOR:   Hirotaka Wakabayashi
+// CREATE:   Fri, 16 Oct 2026
+// REVISION:
+//
+
+//go:build cgo && !nok2hdkc
+// +build cgo,!nok2hdkc
+
+package k2hdkc
+
+import (
+	// #cgo CFLAGS: -g -O2 -Wall -Wextra -Wno-unused-variable -Wno-unused-parameter -I. -I/usr/include/k2hdkc -I/usr/include/chmpx -I/usr/include/k2hash
+	// #cgo LDFLAGS: -L/usr/lib -lk2hdkc -lchmpx -lk2hash
+	// #include <stdlib.h>
+	// #include "k2hdkc.h"
+	// #include "chmpx.h"
+	// #include "k2hash.h"
+	"C"
+	"log"
+	"unsafe"
+)
+
+// setK2hdkcDebugFile sets the log file of the k2hdkc library.
+func setK2hdkcDebugFile(f string) {
+	cs := C.CString(f)
+	defer C.free(unsafe.Pointer(cs))
+	C.k2hdkc_set_debug_file(cs)
+}
+
+// setChmpxDebugFile sets the log file of the chmpx library.
+func setChmpxDebugFile(f string) {
+	cs := C.CString(f)
+	defer C.free(unsafe.Pointer(cs))
+	C.chmpx_set_debug_file(cs)
+}
+
+// setK2hashDebugFile sets the log file of the k2hash library.
+func setK2hashDebugFile(f string) {
+	cs := C.CString(f)
+	defer C.free(unsafe.Pointer(cs))
+	C.k2h_set_debug_file(cs)
+}
+
+// unsetLibDebugFile redirects logging of the dependent libraries to stderr.
+func unsetLibDebugFile() {
+	C.k2hdkc_unset_debug_file()
+	C.chmpx_unset_debug_file()
+	C.k2h_unset_debug_file()
+}
+
+// setComlog enables or disables the k2hdkc communication logging.
+func setComlog(b bool) {
+	if b {
+		C.k2hdkc_enable_comlog()
+	} else {
+		C.k2hdkc_disable_comlog()
+	}
+}
+
+// setK2hdkcDebugLevel sets the debugging level of the k2hdkc library.
+func setK2hdkcDebugLevel(p logSeverity) {
+	switch p {
+	case SeveritySilent:
+		C.k2hdkc_set_debug_level_silent() // set silent for debugging level
+	case SeverityError:
+		C.k2hdkc_set_debug_level_error() // set error for debugging level
+	case SeverityWarning:
+		C.k2hdkc_set_debug_level_warning() // set warning for debugging level
+	case SeverityInfo:
+		C.k2hdkc_set_debug_level_message() // set message for debugging level
+	case SeverityDump:
+		C.k2hdkc_set_debug_level_dump() // set dump for debugging level
+	default:
+		log.Printf("[%v] Unknown severity. Fallback to ERROR.", logSeverityText[SeverityError])
+		C.k2hdkc_set_debug_level_message() // set message for debugging leve
+	}
+}
+
+// setChmpxDebugLevel sets the debugging level of the chmpx library.
+func setChmpxDebugLevel(p logSeverity) {
+	switch p {
+	case SeveritySilent:
+		C.chmpx_set_debug_level_silent() // set silent for debugging level
+	case SeverityError:
+		C.chmpx_set_debug_level_error() // set error for debugging level
+	case SeverityWarning:
+		C.chmpx_set_debug_level_warning() // set warning for debugging level
+	case SeverityInfo:
+		C.chmpx_set_debug_level_message() // set message for debugging level
+	case SeverityDump:
+		C.chmpx_set_debug_level_dump() // set dump for debugging level
+	default:
+		log.Printf("[%v] Unknown severity. Fallback to ERROR.", logSeverityText[SeverityError])
+		C.chmpx_set_debug_level_message() // set message for debugging leve
+	}
+}
+
+// setK2hashDebugLevel sets the debugging level of the k2hash library.
+func setK2hashDebugLevel(p logSeverity) {
+	switch p {
+	case SeveritySilent:
+		C.k2h_set_debug_level_silent() // set silent for debugging level
+	case SeverityError:
+		C.k2h_set_debug_level_error() // set error for debugging level
+	case SeverityWarning:
+		C.k2h_set_debug_level_warning() // set warning for debugging level
+	case SeverityInfo, SeverityDump:
+		C.k2h_set_debug_level_message() // set message for debugging level
+	default:
+		log.Printf("[%v] Unknown severity. Fallback to ERROR.", logSeverityText[SeverityError])
+		C.k2h_set_debug_level_message() // set message for debugging leve
+	}
+}
+
+// Local Variables:
+// c-basic-offset: 4
+// tab-width: 4
+// indent-tabs-mode: t
+// End:
+// vim600: noexpandtab sw=4 ts=4 fdm=marker
+// vim<600: noexpandtab sw=4 ts=4
@@ -0,0 +1,202 @@
+//
+// k2hdkc_go
+//
+// Copyright 2018 Yahoo Japan Corporation.
+//
+// Go driver for k2hdkc that is a highly available and scalable distributed
+// KVS clustering system. For k2hdkc, see
+// https://github.com/yahoojapan/k2hdkc for the details.
+//
+// For the full copyright and license information, please view
+// the license file that was distributed with this source code.
+//
+// AUTHOR:   Hirotaka Wakabayashi
+// CREATE:   Fri, 16 Oct 2026
+// REVISION:
+//
+
+package k2hdkc
+
+import (
+	"fmt"
+	"os"
+	"sync"
+	"time"
+)
+
+// logRotator is the log file of a K2hLog. It renames the file to file.1, file.2 and so on
+// when the file gets too large or too old, and opens a new file.
+type logRotator struct {
+	mu      sync.Mutex
+	path    string
+	fp      *os.File
+	size    int64         // the size of the file. writes of the C libraries are counted only when it opens.
+	opened  time.Time     // the time when the file was opened.
+	maxSize int64         // rotates the file if it's larger than maxSize. 0 means no limit.
+	maxAge  time.Duration // rotates the file if it's older than maxAge. 0 means no limit.
+	backups int           // the number of rotated files kept.
+	libLog  bool          // true if the C libraries write to the file.
+	refs    int           // the number of K2hLogs writing to the file. the file is closed when it reaches 0.
+	now     func() time.Time
+}
+
+// openLogRotator opens the file in append mode.
+func openLogRotator(path string) (*logRotator, error) {
+	r := &logRotator{path: path, now: time.Now, refs: 1}
+	if err := r.open(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// open opens the file. The caller must hold r.mu or own r.
+func (r *logRotator) open() error {
+	fp, err := os.OpenFile(r.path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0644)
+	if err != nil {
+		return err
+	}
+	var size int64
+	if fi, err := fp.Stat(); err == nil {
+		size = fi.Size()
+	}
+	r.fp = fp
+	r.size = size
+	r.opened = r.now()
+	if r.libLog && !libLogCaptured() {
+		// the C libraries keep the old file open until they are pointed at the path again.
+		setLibLogFiles(r.path, r.path, r.path)
+	}
+	return nil
+}
+
+// Write writes p to the file after rotating it if needed.
+func (r *logRotator) Write(p []byte) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.fp == nil {
+		return 0, os.ErrClosed
+	}
+	if r.needRotate(len(p)) {
+		if err := r.rotate(); err != nil {
+			fmt.Fprintf(os.Stderr, "[%v] %v rotate error:[%s].\n", logSeverityText[SeverityError], r.path, err)
+		}
+		if r.fp == nil {
+			return 0, os.ErrClosed
+		}
+	}
+	n, err := r.fp.Write(p)
+	r.size += int64(n)
+	return n, err
+}
+
+// needRotate returns true if writing n bytes exceeds the limits.
+func (r *logRotator) needRotate(n int) bool {
+	if r.maxSize > 0 && r.size > 0 && r.size+int64(n) > r.maxSize {
+		return true
+	}
+	return r.maxAge > 0 && r.now().Sub(r.opened) >= r.maxAge
+}
+
+// rotate shifts the rotated files, removes the oldest one and opens a new file.
+// A new file is opened even if renaming fails. The caller must hold r.mu.
+func (r *logRotator) rotate() error {
+	err := r.fp.Close()
+	r.fp = nil
+	if err == nil {
+		err = r.shift()
+	}
+	if oerr := r.open(); oerr != nil {
+		return oerr
+	}
+	return err
+}
+
+// shift renames the file to file.1 after renaming file.i to file.i+1.
+func (r *logRotator) shift() error {
+	if r.backups <= 0 {
+		if err := os.Remove(r.path); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		return nil
+	}
+	for i := r.backups - 1; i > 0; i-- {
+		if err := os.Rename(r.backupPath(i), r.backupPath(i+1)); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	if err := os.Rename(r.path, r.backupPath(1)); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+// backupPath returns the path of the i-th rotated file. 1 is the newest one.
+func (r *logRotator) backupPath(i int) string {
+	return fmt.Sprintf("%v.%d", r.path, i)
+}
+
+// setLimits sets the limits of the rotation.
+func (r *logRotator) setLimits(maxSize int64, maxAge time.Duration, backups int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.maxSize = maxSize
+	r.maxAge = maxAge
+	r.backups = backups
+}
+
+// setLibLog sets whether the C libraries write to the file.
+func (r *logRotator) setLibLog(b bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.libLog = b
+	if b {
+		setLibLogFiles(r.path, r.path, r.path)
+	} else {
+		setLibLogFiles("", "", "")
+	}
+}
+
+// reopen closes the file and opens the path again, which may be a new file
+// after an external tool like logrotate renamed the old one.
+func (r *logRotator) reopen() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.fp != nil {
+		r.fp.Close()
+		r.fp = nil
+	}
+	return r.open()
+}
+
+// retain adds a reference of the rotator and returns it. Each reference is released by Close.
+func (r *logRotator) retain() *logRotator {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.refs++
+	return r
+}
+
+// Close releases a reference and closes the file when no reference remains.
+func (r *logRotator) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.refs > 1 {
+		r.refs--
+		return nil
+	}
+	r.refs = 0
+	if r.fp == nil {
+		return nil
+	}
+	err := r.fp.Close()
+	r.fp = nil
+	return err
+}
+
+// Local Variables:
+// c-basic-offset: 4
+// tab-width: 4
+// indent-tabs-mode: t
+// End:
+// vim600: noexpandtab sw=4 ts=4 fdm=marker
+// vim<600: noexpandtab sw=4 ts=4
@@ -0,0 +1,40 @@
+//
+// k2hdkc_go
+//
+// Copyright 2018 Yahoo Japan Corporation.
+//
+// Go driver for k2hdkc that is a highly available and scalable distributed
+// KVS clustering system. For k2hdkc, see
+// https://github.com/yahoojapan/k2hdkc for the details.
+//
+// For the full copyright and license information, please view
+// the license file that was distributed with this source code.
+//
+// AUTHOR:   Hirotaka Wakabayashi
+// CREATE:   Fri, 16 Oct 2026
+// REVISION:
+//
+
+//go:build !cgo || nok2hdkc
+// +build !cgo nok2hdkc
+
+package k2hdkc
+
+// The dependent libraries are not linked. Their logging is never configured.
+
+func setK2hdkcDebugFile(f string)       {}
+func setChmpxDebugFile(f string)        {}
+func setK2hashDebugFile(f string)       {}
+func unsetLibDebugFile()                {}
+func setComlog(b bool)                  {}
+func setK2hdkcDebugLevel(p logSeverity) {}
+func setChmpxDebugLevel(p logSeverity)  {}
+func setK2hashDebugLevel(p logSeverity) {}
+
+// Local Variables:
+// c-basic-offset: 4
+// tab-width: 4
+// indent-tabs-mode: t
+// End:
+// vim600: noexpandtab sw=4 ts=4 fdm=marker
+// vim<600: noexpandtab sw=4 ts=4
@@ -20,10 +20,16 @@ package k2hdkc
 import (
 	"bufio"
 	"bytes"
+	"context"
 	"fmt"
+	"io/ioutil"
 	"os"
+	"path/filepath"
 	"regexp"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 )
 
 // ensureLog read data up to 128bytes from the path and match the data with the want.
@@ -330,6 +336,190 @@ func TestK2hLogInstance(t *testing.T) {
 	}
 }
 
+// TestK2hLogStructured tests the fields of a structured message.
+func TestK2hLogStructured(t *testing.T) {
+	os.Unsetenv("GO_K2HDKC_DBGFILE")
+	log := newK2hLog()
+	log.SetLogSeverity(SeverityWarning)
+	var buf bytes.Buffer
+	log.SetOutput(&buf)
+
+	log.Log(context.Background(), LevelInfo, "hidden")
+	log.Log(context.Background(), LevelWarn, "command failed", Field{FieldCommand, "Get"}, Field{FieldKey, "a b"}, Field{FieldSession, 1})
+	want := `[WARN] command failed command=Get key="a b" session=1` + "\n"
+	if got := buf.String(); !strings.HasSuffix(got, want) || strings.Contains(got, "hidden") {
+		t.Errorf("log = %q, want %q", got, want)
+	}
+}
+
+// TestK2hLogRotateSize tests the file is rotated by the size and old files are removed.
+func TestK2hLogRotateSize(t *testing.T) {
+	os.Unsetenv("GO_K2HDKC_DBGFILE")
+	path := filepath.Join(t.TempDir(), "rotate.log")
+	log := newK2hLog()
+	log.SetLogRotation(100, 0, 2)
+	log.SetLogFile(path)
+	defer log.Close()
+	for i := 0; i < 5; i++ {
+		log.Error(strings.Repeat("x", 20))
+	}
+	for _, p := range []string{path, path + ".1", path + ".2"} {
+		if fi, err := os.Stat(p); err != nil || fi.Size() > 100 {
+			t.Errorf("os.Stat(%q) = (%v, %v), want a file up to 100 bytes", p, fi, err)
+		}
+	}
+	if _, err := os.Stat(path + ".3"); !os.IsNotExist(err) {
+		t.Errorf("os.Stat(%q) = %v, want not exist", path+".3", err)
+	}
+}
+
+// TestK2hLogRotateAge tests the file is rotated by the age.
+func TestK2hLogRotateAge(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "rotate.log")
+	r, err := openLogRotator(path)
+	if err != nil {
+		t.Fatalf("openLogRotator(%q) = %v", path, err)
+	}
+	defer r.Close()
+	now := time.Now()
+	r.now = func() time.Time { return now }
+	r.setLimits(0, time.Hour, 1)
+	r.Write([]byte("old\n"))
+	now = now.Add(time.Hour)
+	r.Write([]byte("new\n"))
+	if b, err := ioutil.ReadFile(path + ".1"); err != nil || string(b) != "old\n" {
+		t.Errorf("ioutil.ReadFile(%q) = (%q, %v), want old", path+".1", b, err)
+	}
+	if b, err := ioutil.ReadFile(path); err != nil || string(b) != "new\n" {
+		t.Errorf("ioutil.ReadFile(%q) = (%q, %v), want new", path, b, err)
+	}
+}
+
+// TestK2hLogReopen tests the log goes to a new file after the file is renamed and reopened.
+func TestK2hLogReopen(t *testing.T) {
+	os.Unsetenv("GO_K2HDKC_DBGFILE")
+	path := filepath.Join(t.TempDir(), "reopen.log")
+	log := newK2hLog()
+	log.SetLogFile(path)
+	defer log.Close()
+	log.Error("before")
+	if err := os.Rename(path, path+".old"); err != nil {
+		t.Fatalf("os.Rename(%q) = %v", path, err)
+	}
+	if err := log.Reopen(); err != nil {
+		t.Errorf("log.Reopen() = %v", err)
+	}
+	log.Error("after")
+	if b, err := ioutil.ReadFile(path); err != nil || !strings.Contains(string(b), "after") || strings.Contains(string(b), "before") {
+		t.Errorf("ioutil.ReadFile(%q) = (%q, %v), want only after", path, b, err)
+	}
+}
+
+// TestK2hLogRefs tests the file is closed when the last reference is released.
+func TestK2hLogRefs(t *testing.T) {
+	os.Unsetenv("GO_K2HDKC_DBGFILE")
+	path := filepath.Join(t.TempDir(), "refs.log")
+	log := NewK2hLog()
+	log.SetLogFile(path)
+	log.Retain()
+	log.Close()
+	log.Error("still open")
+	log.Close()
+	log.Error("closed")
+	if b, err := ioutil.ReadFile(path); err != nil || !strings.Contains(string(b), "still open") || strings.Contains(string(b), "closed") {
+		t.Errorf("ioutil.ReadFile(%q) = (%q, %v), want only still open", path, b, err)
+	}
+}
+
+// TestK2hLogConcurrent tests changing the output while other goroutines log. Run it with -race.
+func TestK2hLogConcurrent(t *testing.T) {
+	os.Unsetenv("GO_K2HDKC_DBGFILE")
+	dir := t.TempDir()
+	log := NewK2hLog()
+	defer log.Close()
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				log.Warn("message")
+				log.Log(context.Background(), LevelError, "message")
+			}
+		}()
+	}
+	for i := 0; i < 10; i++ {
+		log.SetLogFile(filepath.Join(dir, fmt.Sprintf("concurrent%d.log", i)))
+		log.SetLogSeverity(SeverityWarning)
+	}
+	wg.Wait()
+}
+
+// TestK2hLogClientOwnLog tests a client changes its own logger and closing it keeps others working.
+func TestK2hLogClientOwnLog(t *testing.T) {
+	os.Unsetenv("GO_K2HDKC_DBGFILE")
+	path := filepath.Join(t.TempDir(), "client.log")
+	c1 := NewClient("", 0)
+	c2 := NewClient("", 0)
+	defer c2.Close()
+	severity := K2hLogInstance().severity
+	c1.SetLogFile(path).SetLogSeverity(SeverityWarning)
+	if c1.log == K2hLogInstance() || c2.log != K2hLogInstance() {
+		t.Errorf("c1.log = %v, c2.log = %v, want only c2 to use K2hLogInstance", c1.log, c2.log)
+	}
+	if c2.log.severity != severity {
+		t.Errorf("c2.log.severity = %v, want %v", c2.log.severity, severity)
+	}
+	c1.log.Warn("c1 message")
+	c1.Close()
+	c1.Close()
+	if _, err := os.Stderr.Stat(); err != nil {
+		t.Errorf("os.Stderr.Stat() = %v, want stderr open", err)
+	}
+	if b, err := ioutil.ReadFile(path); err != nil || !strings.Contains(string(b), "c1 message") {
+		t.Errorf("ioutil.ReadFile(%q) = (%q, %v), want c1 message", path, b, err)
+	}
+	if LibLogLevel(ComponentK2hdkc) != SeverityWarning {
+		t.Errorf("LibLogLevel(ComponentK2hdkc) = %v, want %v", LibLogLevel(ComponentK2hdkc), SeverityWarning)
+	}
+}
+
+// TestK2hLogCloneFile tests a clone writes through the rotator of its origin until it gets its own file,
+// and never changes the log files of the C libraries.
+func TestK2hLogCloneFile(t *testing.T) {
+	os.Unsetenv("GO_K2HDKC_DBGFILE")
+	dir := t.TempDir()
+	path := filepath.Join(dir, "origin.log")
+	log := newK2hLog()
+	log.SetLogFile(path)
+	log.SetLogRotation(1000, 0, 1)
+	defer log.Close()
+	files := libLog.files
+
+	n := log.clone()
+	if n.rotator != log.rotator {
+		t.Errorf("n.rotator = %p, want the rotator of the origin %p", n.rotator, log.rotator)
+	}
+	n.SetLogRotation(10, 0, 3)
+	if log.rotator.maxSize != 1000 || log.rotator.backups != 1 {
+		t.Errorf("log.rotator limits = (%v, %v), want (1000, 1)", log.rotator.maxSize, log.rotator.backups)
+	}
+	n.Error("clone message")
+	n.SetLogFile(filepath.Join(dir, "clone.log"))
+	if n.rotator == log.rotator || n.rotator.maxSize != 10 {
+		t.Errorf("n.rotator = %v, want its own rotator with maxSize 10", n.rotator)
+	}
+	n.BundleLibLog(true)
+	if libLog.files != files {
+		t.Errorf("libLog.files = %v, want %v", libLog.files, files)
+	}
+	n.Close()
+	log.Error("origin message")
+	if b, err := ioutil.ReadFile(path); err != nil || !strings.Contains(string(b), "clone message") || !strings.Contains(string(b), "origin message") {
+		t.Errorf("ioutil.ReadFile(%q) = (%q, %v), want clone and origin messages", path, b, err)
+	}
+}
+
 // Local Variables:
 // c-basic-offset: 4
 // tab-width: 4
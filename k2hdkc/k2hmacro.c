@@ -15,6 +15,9 @@
 // REVISION:
 //
 
+//go:build cgo && !nok2hdkc
+// +build cgo,!nok2hdkc
+
 #include <k2hdkc/k2hdkc.h>
 #include <k2hash/k2hash.h>
 
@@ -0,0 +1,114 @@
+//
+// k2hdkc_go
+//
+// Copyright 2018 Yahoo Japan Corporation.
+//
+// Go driver for k2hdkc that is a highly available and scalable distributed
+// KVS clustering system. For k2hdkc, see
+// https://github.com/yahoojapan/k2hdkc for the details.
+//
+// For the full copyright and license information, please view
+// the license file that was distributed with this source code.
+//
+// AUTHOR:   Hirotaka Wakabayashi
+// CREATE:   Fri, 16 Oct 2026
+// REVISION:
+//
+
+package k2hdkc
+
+import (
+	"fmt"
+)
+
+// KeyEncoding defines how a string key is converted to the key bytes in the k2hdkc cluster.
+type KeyEncoding uint8
+
+// KeyEncodingCString means a key is saved with the terminating NUL like the C, C++ and Node.js clients do.
+// It's the default and command constructors use it for string keys.
+// KeyEncodingBinary means a key is saved as it is. Command constructors use it for []byte keys.
+const (
+	KeyEncodingCString KeyEncoding = iota
+	KeyEncodingBinary
+)
+
+var keyEncodingText = map[KeyEncoding]string{
+	KeyEncodingCString: "CSTRING",
+	KeyEncodingBinary:  "BINARY",
+}
+
+// String returns the name of the encoding.
+func (e KeyEncoding) String() string {
+	if s, ok := keyEncodingText[e]; ok {
+		return s
+	}
+	return fmt.Sprintf("KeyEncoding(%d)", (uint8)(e))
+}
+
+// EncodeKey returns the key bytes of the string in the encoding. nil returns for an empty string.
+// Pass the bytes to the command constructors to use the encoding with commands.
+func EncodeKey(k string, e KeyEncoding) []byte {
+	if k == "" {
+		return nil
+	}
+	if e == KeyEncodingBinary {
+		return []byte(k)
+	}
+	return append([]byte(k), 0)
+}
+
+// DecodeKey returns the string of the key bytes in the encoding.
+func DecodeKey(b []byte, e KeyEncoding) string {
+	if e == KeyEncodingBinary {
+		return string(b)
+	}
+	return string(trimNul(b))
+}
+
+// CStringKey converts binary key bytes to C-string key bytes. Bytes which end with NUL return as they are.
+func CStringKey(b []byte) []byte {
+	if n := len(b); n == 0 || b[n-1] == 0 {
+		return b
+	}
+	return append(append(make([]byte, 0, len(b)+1), b...), 0)
+}
+
+// BinaryKey converts C-string key bytes to binary key bytes by removing the terminating NUL.
+func BinaryKey(b []byte) []byte {
+	return trimNul(b)
+}
+
+// SetKeyEncoding sets the encoding of the string keys, subkeys and queue prefixes of the Client methods.
+func (c *Client) SetKeyEncoding(e KeyEncoding) *Client {
+	c.keyEncoding = e
+	return c
+}
+
+// KeyEncoding returns the encoding of the string keys of the Client methods.
+func (c *Client) KeyEncoding() KeyEncoding {
+	return c.keyEncoding
+}
+
+// key returns the key bytes of the string in the encoding of the client.
+func (c *Client) key(k string) []byte {
+	return EncodeKey(k, c.keyEncoding)
+}
+
+// keys returns the key bytes of the strings in the encoding of the client. Empty strings are skipped.
+func (c *Client) keys(ks []string) [][]byte {
+	keys := make([][]byte, 0, len(ks))
+	for _, k := range ks {
+		if k != "" {
+			keys = append(keys, c.key(k))
+		}
+	}
+	return keys
+}
+
+// Local Variables:
+// c-basic-offset: 4
+// tab-width: 4
+// indent-tabs-mode: t
+// End:
+// vim600: noexpandtab sw=4 ts=4 fdm=marker
+// vim<600: noexpandtab sw=4 ts=4
@@ -0,0 +1,93 @@
+//
+// k2hdkc_go
+//
+// Copyright 2018 Yahoo Japan Corporation.
+//
+// Go driver for k2hdkc that is a highly available and scalable distributed
+// KVS clustering system. For k2hdkc, see
+// https://github.com/yahoojapan/k2hdkc for the details.
+//
+// For the full copyright and license information, please view
+// the license file that was distributed with this source code.
+//
+// AUTHOR:   Hirotaka Wakabayashi
+// CREATE:   Fri, 16 Oct 2026
+// REVISION:
+//
+
+package k2hdkc_test
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+
+	"github.com/yahoojapan/k2hdkc_go/k2hdkc"
+	"github.com/yahoojapan/k2hdkc_go/k2hdkc/k2hdkctest"
+)
+
+// TestKeyEncodingHelpers tests the conversion between the encodings.
+func TestKeyEncodingHelpers(t *testing.T) {
+	if b := k2hdkc.EncodeKey("a", k2hdkc.KeyEncodingCString); !bytes.Equal(b, []byte("a\x00")) {
+		t.Errorf("EncodeKey(a, CString) = %q, want a with NUL", b)
+	}
+	if b := k2hdkc.EncodeKey("a", k2hdkc.KeyEncodingBinary); !bytes.Equal(b, []byte("a")) {
+		t.Errorf("EncodeKey(a, Binary) = %q, want a", b)
+	}
+	if s := k2hdkc.DecodeKey([]byte("a\x00"), k2hdkc.KeyEncodingCString); s != "a" {
+		t.Errorf("DecodeKey(a NUL, CString) = %q, want a", s)
+	}
+	if s := k2hdkc.DecodeKey([]byte("a\x00"), k2hdkc.KeyEncodingBinary); s != "a\x00" {
+		t.Errorf("DecodeKey(a NUL, Binary) = %q, want a NUL", s)
+	}
+	if b := k2hdkc.CStringKey(k2hdkc.CStringKey([]byte("a"))); !bytes.Equal(b, []byte("a\x00")) {
+		t.Errorf("CStringKey(CStringKey(a)) = %q, want a with NUL", b)
+	}
+	if b := k2hdkc.BinaryKey([]byte("a\x00")); !bytes.Equal(b, []byte("a")) {
+		t.Errorf("BinaryKey(a NUL) = %q, want a", b)
+	}
+}
+
+// TestKeyEncodingClient tests a binary client reads keys which a command wrote with binary keys.
+func TestKeyEncodingClient(t *testing.T) {
+	s := k2hdkctest.NewServer()
+	c := s.NewClient().SetKeyEncoding(k2hdkc.KeyEncodingBinary)
+	defer c.Close()
+
+	cmd, err := k2hdkc.NewSet([]byte("bin"), []byte("v"))
+	if err != nil {
+		t.Fatalf("NewSet(bin, v) = %v", err)
+	}
+	if _, err := c.Send(cmd); err != nil {
+		t.Fatalf("c.Send(cmd) = %v", err)
+	}
+	if v, err := c.GetValue("bin"); err != nil || string(v) != "v" {
+		t.Errorf("c.GetValue(bin) = (%q, %v), want v", v, err)
+	}
+	if err := c.SetAll("parent", []byte("p"), []string{"a", "b"}); err != nil {
+		t.Fatalf("c.SetAll(parent, p, [a b]) = %v", err)
+	}
+	r, err := c.GetSubKeys("parent")
+	if err != nil {
+		t.Fatalf("c.GetSubKeys(parent) = %v", err)
+	}
+	if got := r.Keys(k2hdkc.KeyEncodingBinary); !reflect.DeepEqual(got, []string{"a", "b"}) {
+		t.Errorf("r.Keys(Binary) = %q, want [a b]", got)
+	}
+	if got := r.Bytes(); !bytes.Equal(got[0], []byte("a")) {
+		t.Errorf("r.Bytes()[0] = %q, want a without NUL", got[0])
+	}
+	cs := s.NewClient()
+	defer cs.Close()
+	if _, err := cs.GetValue("bin"); err == nil {
+		t.Errorf("a C-string client reads the binary key bin")
+	}
+}
+
+// Local Variables:
+// c-basic-offset: 4
+// tab-width: 4
+// indent-tabs-mode: t
+// End:
+// vim600: noexpandtab sw=4 ts=4 fdm=marker
+// vim<600: noexpandtab sw=4 ts=4
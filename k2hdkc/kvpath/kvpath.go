@@ -0,0 +1,438 @@
+//
+// k2hdkc_go
+//
+// Copyright 2018 Yahoo Japan Corporation.
+//
+// Go driver for k2hdkc that is a highly available and scalable distributed
+// KVS clustering system. For k2hdkc, see
+// https://github.com/yahoojapan/k2hdkc for the details.
+//
+// For the full copyright and license information, please view
+// the license file that was distributed with this source code.
+//
+// AUTHOR:   Hirotaka Wakabayashi
+// CREATE:   Fri, 16 Oct 2026
+// REVISION:
+//
+
+// Package kvpath maps slash-separated paths like "/a/b/c" onto keys and subkey lists
+// of a k2hdkc cluster.
+//
+// The key of a path is the root followed by the path. A directory is a key whose subkeys
+// are the keys of its entries, so the subkey lists always follow the tree. The first byte
+// of a value tells the kind of the entry.
+//
+//	root/a       "d"            directory. its subkeys are root/a/b and so on.
+//	root/a/b     "f" + data     file.
+//
+// An FS changes the tree with several commands, so concurrent changes of the same
+// directory may leave the subkey list inconsistent.
+package kvpath
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/yahoojapan/k2hdkc_go/k2hdkc"
+)
+
+var (
+	// ErrNotExist is returned if the path doesn't exist.
+	ErrNotExist = errors.New("kvpath: file does not exist")
+	// ErrExist is returned if the path already exists.
+	ErrExist = errors.New("kvpath: file already exists")
+	// ErrNotDir is returned if a directory is expected but the path is a file.
+	ErrNotDir = errors.New("kvpath: not a directory")
+	// ErrIsDir is returned if a file is expected but the path is a directory.
+	ErrIsDir = errors.New("kvpath: is a directory")
+	// ErrNotEmpty is returned by Remove of a directory with entries.
+	ErrNotEmpty = errors.New("kvpath: directory not empty")
+	// ErrInvalid is returned for a relative path, the root or a value not written by kvpath.
+	ErrInvalid = errors.New("kvpath: invalid argument")
+)
+
+const (
+	kindDir  = 'd'
+	kindFile = 'f'
+)
+
+// Option sets an optional parameter of an FS.
+type Option func(*FS)
+
+// WithRoot sets the prefix of the keys. The default is "", which makes the key of "/a" "/a".
+func WithRoot(root string) Option {
+	return func(f *FS) {
+		f.root = strings.TrimSuffix(root, "/")
+	}
+}
+
+// Entry is an entry of a directory.
+type Entry struct {
+	Name  string
+	IsDir bool
+	Size  int // length of the data of a file.
+}
+
+// String returns a text representation of the object.
+func (e Entry) String() string {
+	return fmt.Sprintf("[%v, %v, %v]", e.Name, e.IsDir, e.Size)
+}
+
+// FS is a tree of directories and files on a k2hdkc cluster.
+type FS struct {
+	client *k2hdkc.Client
+	root   string
+}
+
+// New returns the pointer to an FS.
+func New(c *k2hdkc.Client, opts ...Option) *FS {
+	f := &FS{client: c}
+	for _, opt := range opts {
+		if opt != nil {
+			opt(f)
+		}
+	}
+	return f
+}
+
+// pathError returns the error of the operation on the path.
+func pathError(op string, p string, err error) error {
+	return fmt.Errorf("%v %v: %w", op, p, err)
+}
+
+// clean returns the cleaned absolute path.
+func clean(op string, p string) (string, error) {
+	if !strings.HasPrefix(p, "/") {
+		return "", pathError(op, p, ErrInvalid)
+	}
+	return path.Clean(p), nil
+}
+
+// key returns the key of the cleaned path.
+func (f *FS) key(p string) string {
+	return f.root + p
+}
+
+// stat returns the kind and the data of the path.
+func (f *FS) stat(ctx context.Context, op string, p string) (byte, []byte, error) {
+	v, err := f.client.GetValueContext(ctx, f.key(p))
+	if errors.Is(err, k2hdkc.ErrNotFound) {
+		return 0, nil, pathError(op, p, ErrNotExist)
+	}
+	if err != nil {
+		return 0, nil, err
+	}
+	if len(v) == 0 || (v[0] != kindDir && v[0] != kindFile) {
+		return 0, nil, pathError(op, p, ErrInvalid)
+	}
+	return v[0], v[1:], nil
+}
+
+// Stat returns the entry of the path.
+func (f *FS) Stat(ctx context.Context, p string) (Entry, error) {
+	p, err := clean("stat", p)
+	if err != nil {
+		return Entry{}, err
+	}
+	kind, data, err := f.stat(ctx, "stat", p)
+	if err != nil {
+		return Entry{}, err
+	}
+	return Entry{Name: path.Base(p), IsDir: kind == kindDir, Size: len(data)}, nil
+}
+
+// dir checks that the parent of the path is a directory and returns it.
+func (f *FS) dir(ctx context.Context, op string, p string) (string, error) {
+	if p == "/" {
+		return "", pathError(op, p, ErrInvalid)
+	}
+	parent := path.Dir(p)
+	kind, _, err := f.stat(ctx, op, parent)
+	if err != nil {
+		return "", err
+	}
+	if kind != kindDir {
+		return "", pathError(op, parent, ErrNotDir)
+	}
+	return parent, nil
+}
+
+// MkdirAll creates the directory and the parents which don't exist.
+// It does nothing if the directory already exists.
+func (f *FS) MkdirAll(ctx context.Context, p string) error {
+	p, err := clean("mkdir", p)
+	if err != nil {
+		return err
+	}
+	kind, _, err := f.stat(ctx, "mkdir", "/")
+	switch {
+	case errors.Is(err, ErrNotExist):
+		if err := f.client.SetValueContext(ctx, f.key("/"), []byte{kindDir}); err != nil {
+			return err
+		}
+	case err != nil:
+		return err
+	case kind != kindDir:
+		return pathError("mkdir", "/", ErrNotDir)
+	}
+	cur := "/"
+	for _, name := range strings.Split(strings.TrimPrefix(p, "/"), "/") {
+		if name == "" {
+			continue
+		}
+		next := path.Join(cur, name)
+		kind, _, err := f.stat(ctx, "mkdir", next)
+		switch {
+		case errors.Is(err, ErrNotExist):
+			if err := f.client.AddSubKeyContext(ctx, f.key(cur), f.key(next), []byte{kindDir}); err != nil {
+				return err
+			}
+		case err != nil:
+			return err
+		case kind != kindDir:
+			return pathError("mkdir", next, ErrNotDir)
+		}
+		cur = next
+	}
+	return nil
+}
+
+// WriteFile writes the data to the file, creating it if it doesn't exist.
+// The parent directory must exist.
+func (f *FS) WriteFile(ctx context.Context, p string, data []byte) error {
+	p, err := clean("write", p)
+	if err != nil {
+		return err
+	}
+	parent, err := f.dir(ctx, "write", p)
+	if err != nil {
+		return err
+	}
+	kind, _, err := f.stat(ctx, "write", p)
+	if err != nil && !errors.Is(err, ErrNotExist) {
+		return err
+	}
+	if err == nil && kind == kindDir {
+		return pathError("write", p, ErrIsDir)
+	}
+	v := append([]byte{kindFile}, data...)
+	return f.client.AddSubKeyContext(ctx, f.key(parent), f.key(p), v)
+}
+
+// ReadFile returns the data of the file.
+func (f *FS) ReadFile(ctx context.Context, p string) ([]byte, error) {
+	p, err := clean("read", p)
+	if err != nil {
+		return nil, err
+	}
+	kind, data, err := f.stat(ctx, "read", p)
+	if err != nil {
+		return nil, err
+	}
+	if kind == kindDir {
+		return nil, pathError("read", p, ErrIsDir)
+	}
+	return data, nil
+}
+
+// children returns the paths of the entries of the directory.
+func (f *FS) children(ctx context.Context, p string) ([]string, error) {
+	sks, err := f.client.GetSubKeyListContext(ctx, f.key(p))
+	if errors.Is(err, k2hdkc.ErrNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	prefix := f.key(strings.TrimSuffix(p, "/") + "/")
+	var ps []string
+	for _, sk := range sks {
+		name := strings.TrimPrefix(sk, prefix)
+		// subkeys added by others aren't entries.
+		if name == sk || name == "" || strings.Contains(name, "/") {
+			continue
+		}
+		ps = append(ps, path.Join(p, name))
+	}
+	return ps, nil
+}
+
+// ReadDir returns the entries of the directory sorted by name.
+func (f *FS) ReadDir(ctx context.Context, p string) ([]Entry, error) {
+	p, err := clean("readdir", p)
+	if err != nil {
+		return nil, err
+	}
+	kind, _, err := f.stat(ctx, "readdir", p)
+	if err != nil {
+		return nil, err
+	}
+	if kind != kindDir {
+		return nil, pathError("readdir", p, ErrNotDir)
+	}
+	ps, err := f.children(ctx, p)
+	if err != nil {
+		return nil, err
+	}
+	keys := make([]string, len(ps))
+	for i, cp := range ps {
+		keys[i] = f.key(cp)
+	}
+	// the missing keys have been removed after listing.
+	vals, _, errs := f.client.GetManyContext(ctx, keys)
+	for _, k := range keys {
+		if err, ok := errs[k]; ok {
+			return nil, err
+		}
+	}
+	entries := make([]Entry, 0, len(ps))
+	for i, cp := range ps {
+		v, ok := vals[keys[i]]
+		if !ok || len(v) == 0 {
+			continue
+		}
+		entries = append(entries, Entry{Name: path.Base(cp), IsDir: v[0] == kindDir, Size: len(v) - 1})
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Name < entries[j].Name })
+	return entries, nil
+}
+
+// Remove removes the file or the empty directory.
+func (f *FS) Remove(ctx context.Context, p string) error {
+	p, err := clean("remove", p)
+	if err != nil {
+		return err
+	}
+	parent, err := f.dir(ctx, "remove", p)
+	if err != nil {
+		return err
+	}
+	kind, _, err := f.stat(ctx, "remove", p)
+	if err != nil {
+		return err
+	}
+	if kind == kindDir {
+		ps, err := f.children(ctx, p)
+		if err != nil {
+			return err
+		}
+		if len(ps) != 0 {
+			return pathError("remove", p, ErrNotEmpty)
+		}
+	}
+	return f.client.RemoveSubKeyContext(ctx, f.key(parent), f.key(p))
+}
+
+// RemoveAll removes the path and all the entries under it. It returns nil if the path doesn't exist.
+func (f *FS) RemoveAll(ctx context.Context, p string) error {
+	p, err := clean("removeall", p)
+	if err != nil {
+		return err
+	}
+	parent, err := f.dir(ctx, "removeall", p)
+	if errors.Is(err, ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if _, _, err := f.stat(ctx, "removeall", p); errors.Is(err, ErrNotExist) {
+		return nil
+	}
+	return f.client.RemoveSubKeyContext(ctx, f.key(parent), f.key(p), k2hdkc.WithNest())
+}
+
+// Rename moves the file or the directory to the new path, whose parent must exist.
+// The new path must not exist. The entries of a directory are moved one by one.
+func (f *FS) Rename(ctx context.Context, oldPath string, newPath string) error {
+	oldPath, err := clean("rename", oldPath)
+	if err != nil {
+		return err
+	}
+	newPath, err = clean("rename", newPath)
+	if err != nil {
+		return err
+	}
+	if strings.HasPrefix(newPath, oldPath+"/") {
+		return pathError("rename", newPath, ErrInvalid)
+	}
+	oldParent, err := f.dir(ctx, "rename", oldPath)
+	if err != nil {
+		return err
+	}
+	newParent, err := f.dir(ctx, "rename", newPath)
+	if err != nil {
+		return err
+	}
+	kind, data, err := f.stat(ctx, "rename", oldPath)
+	if err != nil {
+		return err
+	}
+	if _, _, err := f.stat(ctx, "rename", newPath); err == nil {
+		return pathError("rename", newPath, ErrExist)
+	} else if !errors.Is(err, ErrNotExist) {
+		return err
+	}
+	if oldParent == newParent {
+		return f.move(ctx, oldPath, newPath, newParent, kind == kindDir)
+	}
+	// renaming replaces the key only in the subkey list which has it, so the new parent
+	// gets the new key explicitly and the old parent loses the old key after the move.
+	if err := f.move(ctx, oldPath, newPath, "", kind == kindDir); err != nil {
+		return err
+	}
+	if err := f.client.AddSubKeyContext(ctx, f.key(newParent), f.key(newPath), append([]byte{kind}, data...)); err != nil {
+		return err
+	}
+	return f.client.RemoveSubKeyContext(ctx, f.key(oldParent), f.key(oldPath))
+}
+
+// move renames the key of the path and replaces it in the subkey list of the parent unless the parent is empty.
+// The keys of the entries of a directory are renamed after it, since they contain the path.
+func (f *FS) move(ctx context.Context, oldPath string, newPath string, parent string, isDir bool) error {
+	var opts []k2hdkc.Option
+	if parent != "" {
+		opts = append(opts, k2hdkc.WithParentKey(f.key(parent)))
+	}
+	if err := f.client.RenameContext(ctx, f.key(oldPath), f.key(newPath), opts...); err != nil {
+		return err
+	}
+	if !isDir {
+		return nil
+	}
+	// the subkey list moved with the directory still has the old keys.
+	sks, err := f.client.GetSubKeyListContext(ctx, f.key(newPath))
+	if errors.Is(err, k2hdkc.ErrNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	prefix := f.key(oldPath + "/")
+	for _, sk := range sks {
+		name := strings.TrimPrefix(sk, prefix)
+		if name == sk || name == "" || strings.Contains(name, "/") {
+			continue
+		}
+		kind, _, err := f.stat(ctx, "rename", path.Join(oldPath, name))
+		if err != nil {
+			return err
+		}
+		if err := f.move(ctx, path.Join(oldPath, name), path.Join(newPath, name), newPath, kind == kindDir); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Local Variables:
+// c-basic-offset: 4
+// tab-width: 4
+// indent-tabs-mode: t
+// End:
+// vim600: noexpandtab sw=4 ts=4 fdm=marker
+// vim<600: noexpandtab sw=4 ts=4
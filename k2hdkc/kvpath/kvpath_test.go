@@ -0,0 +1,212 @@
+//
+// k2hdkc_go
+//
+// Copyright 2018 Yahoo Japan Corporation.
+//
+// Go driver for k2hdkc that is a highly available and scalable distributed
+// KVS clustering system. For k2hdkc, see
+// https://github.com/yahoojapan/k2hdkc for the details.
+//
+// For the full copyright and license information, please view
+// the license file that was distributed with this source code.
+//
+// AUTHOR:   Hirotaka Wakabayashi
+// CREATE:   Fri, 16 Oct 2026
+// REVISION:
+//
+
+package kvpath
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"regexp"
+	"testing"
+
+	"github.com/yahoojapan/k2hdkc_go/k2hdkc"
+	"github.com/yahoojapan/k2hdkc_go/k2hdkc/k2hdkctest"
+)
+
+// subKeys returns the subkeys of the key.
+func subKeys(t *testing.T, c *k2hdkc.Client, k string) []string {
+	r, err := c.GetSubKeys(k)
+	if errors.Is(err, k2hdkc.ErrNotFound) {
+		return nil
+	}
+	if err != nil {
+		t.Fatalf("c.GetSubKeys(%v) = %v", k, err)
+	}
+	return r.Keys(c.KeyEncoding())
+}
+
+// TestFSReadWrite tests the subkey lists follow the directories and the files.
+func TestFSReadWrite(t *testing.T) {
+	s := k2hdkctest.NewServer()
+	c := s.NewClient()
+	defer c.Close()
+	ctx := context.Background()
+	f := New(c, WithRoot("app"))
+
+	if err := f.MkdirAll(ctx, "/a/b"); err != nil {
+		t.Fatalf("f.MkdirAll(ctx, /a/b) = %v", err)
+	}
+	if err := f.MkdirAll(ctx, "/a/b"); err != nil {
+		t.Errorf("f.MkdirAll(ctx, /a/b) twice = %v", err)
+	}
+	if err := f.WriteFile(ctx, "/a/b/c", []byte("hello")); err != nil {
+		t.Fatalf("f.WriteFile(ctx, /a/b/c) = %v", err)
+	}
+	if err := f.WriteFile(ctx, "/a/x", []byte("hi")); err != nil {
+		t.Fatalf("f.WriteFile(ctx, /a/x) = %v", err)
+	}
+	if got := subKeys(t, c, "app/a"); !reflect.DeepEqual(got, []string{"app/a/b", "app/a/x"}) {
+		t.Errorf("subkeys of app/a = %v", got)
+	}
+	if b, err := f.ReadFile(ctx, "/a/b/c"); err != nil || string(b) != "hello" {
+		t.Errorf("f.ReadFile(ctx, /a/b/c) = (%q, %v), want hello", b, err)
+	}
+	entries, err := f.ReadDir(ctx, "/a")
+	want := []Entry{{Name: "b", IsDir: true}, {Name: "x", Size: 2}}
+	if err != nil || !reflect.DeepEqual(entries, want) {
+		t.Errorf("f.ReadDir(ctx, /a) = (%v, %v), want %v", entries, err, want)
+	}
+
+	if _, err := f.ReadFile(ctx, "/a"); !errors.Is(err, ErrIsDir) {
+		t.Errorf("f.ReadFile(ctx, /a) = %v, want ErrIsDir", err)
+	}
+	if err := f.WriteFile(ctx, "/none/c", nil); !errors.Is(err, ErrNotExist) {
+		t.Errorf("f.WriteFile(ctx, /none/c) = %v, want ErrNotExist", err)
+	}
+	if err := f.MkdirAll(ctx, "/a/x/y"); !errors.Is(err, ErrNotDir) {
+		t.Errorf("f.MkdirAll(ctx, /a/x/y) = %v, want ErrNotDir", err)
+	}
+	if _, err := f.ReadFile(ctx, "a"); !errors.Is(err, ErrInvalid) {
+		t.Errorf("f.ReadFile(ctx, a) = %v, want ErrInvalid", err)
+	}
+}
+
+// TestFSReadDirRemoved tests ReadDir skips an entry removed after the directory is listed.
+func TestFSReadDirRemoved(t *testing.T) {
+	in := k2hdkctest.NewInjector(k2hdkctest.NewServer())
+	c := in.NewClient()
+	defer c.Close()
+	ctx := context.Background()
+	f := New(c)
+
+	if err := f.MkdirAll(ctx, "/d"); err != nil {
+		t.Fatalf("f.MkdirAll(ctx, /d) = %v", err)
+	}
+	for _, p := range []string{"/d/a", "/d/b"} {
+		if err := f.WriteFile(ctx, p, []byte("v")); err != nil {
+			t.Fatalf("f.WriteFile(ctx, %v) = %v", p, err)
+		}
+	}
+	in.Add(k2hdkctest.Fault{Op: k2hdkc.OpGet, Key: regexp.MustCompile("^/d/b"), Fail: true, SubCode: k2hdkc.SubCodeNoData})
+	entries, err := f.ReadDir(ctx, "/d")
+	if want := []Entry{{Name: "a", Size: 1}}; err != nil || !reflect.DeepEqual(entries, want) {
+		t.Errorf("f.ReadDir(ctx, /d) = (%v, %v), want %v", entries, err, want)
+	}
+}
+
+// TestFSRemove tests Remove and RemoveAll update the subkey list of the parent.
+func TestFSRemove(t *testing.T) {
+	s := k2hdkctest.NewServer()
+	c := s.NewClient()
+	defer c.Close()
+	ctx := context.Background()
+	f := New(c)
+
+	if err := f.MkdirAll(ctx, "/a/b"); err != nil {
+		t.Fatalf("f.MkdirAll(ctx, /a/b) = %v", err)
+	}
+	if err := f.WriteFile(ctx, "/a/b/c", []byte("v")); err != nil {
+		t.Fatalf("f.WriteFile(ctx, /a/b/c) = %v", err)
+	}
+	if err := f.Remove(ctx, "/a/b"); !errors.Is(err, ErrNotEmpty) {
+		t.Errorf("f.Remove(ctx, /a/b) = %v, want ErrNotEmpty", err)
+	}
+	if err := f.Remove(ctx, "/a/b/c"); err != nil {
+		t.Errorf("f.Remove(ctx, /a/b/c) = %v", err)
+	}
+	if got := subKeys(t, c, "/a/b"); len(got) != 0 {
+		t.Errorf("subkeys of /a/b = %v, want none", got)
+	}
+	if err := f.WriteFile(ctx, "/a/b/d", []byte("v")); err != nil {
+		t.Fatalf("f.WriteFile(ctx, /a/b/d) = %v", err)
+	}
+	if err := f.RemoveAll(ctx, "/a/b"); err != nil {
+		t.Errorf("f.RemoveAll(ctx, /a/b) = %v", err)
+	}
+	if err := f.RemoveAll(ctx, "/a/b"); err != nil {
+		t.Errorf("f.RemoveAll(ctx, /a/b) twice = %v", err)
+	}
+	if _, err := f.ReadFile(ctx, "/a/b/d"); !errors.Is(err, ErrNotExist) {
+		t.Errorf("f.ReadFile(ctx, /a/b/d) = %v, want ErrNotExist", err)
+	}
+	if got := subKeys(t, c, "/a"); len(got) != 0 {
+		t.Errorf("subkeys of /a = %v, want none", got)
+	}
+}
+
+// TestFSRename tests a directory and a file are moved to other parents with the subkey lists of both parents updated.
+func TestFSRename(t *testing.T) {
+	s := k2hdkctest.NewServer()
+	c := s.NewClient()
+	defer c.Close()
+	ctx := context.Background()
+	f := New(c)
+
+	for _, d := range []string{"/a/b/c", "/x"} {
+		if err := f.MkdirAll(ctx, d); err != nil {
+			t.Fatalf("f.MkdirAll(ctx, %v) = %v", d, err)
+		}
+	}
+	if err := f.WriteFile(ctx, "/a/b/c/file", []byte("v")); err != nil {
+		t.Fatalf("f.WriteFile(ctx, /a/b/c/file) = %v", err)
+	}
+	if err := f.Rename(ctx, "/a/b", "/x/y"); err != nil {
+		t.Fatalf("f.Rename(ctx, /a/b, /x/y) = %v", err)
+	}
+	if b, err := f.ReadFile(ctx, "/x/y/c/file"); err != nil || string(b) != "v" {
+		t.Errorf("f.ReadFile(ctx, /x/y/c/file) = (%q, %v), want v", b, err)
+	}
+	if _, err := f.Stat(ctx, "/a/b"); !errors.Is(err, ErrNotExist) {
+		t.Errorf("f.Stat(ctx, /a/b) = %v, want ErrNotExist", err)
+	}
+	if got := subKeys(t, c, "/a"); len(got) != 0 {
+		t.Errorf("subkeys of /a = %v, want none", got)
+	}
+	if got := subKeys(t, c, "/x"); !reflect.DeepEqual(got, []string{"/x/y"}) {
+		t.Errorf("subkeys of /x = %v, want [/x/y]", got)
+	}
+	if got := subKeys(t, c, "/x/y/c"); !reflect.DeepEqual(got, []string{"/x/y/c/file"}) {
+		t.Errorf("subkeys of /x/y/c = %v, want [/x/y/c/file]", got)
+	}
+	if err := f.Rename(ctx, "/x/y/c/file", "/a/moved"); err != nil {
+		t.Fatalf("f.Rename(ctx, /x/y/c/file, /a/moved) = %v", err)
+	}
+	if b, err := f.ReadFile(ctx, "/a/moved"); err != nil || string(b) != "v" {
+		t.Errorf("f.ReadFile(ctx, /a/moved) = (%q, %v), want v", b, err)
+	}
+	if got := subKeys(t, c, "/x/y/c"); len(got) != 0 {
+		t.Errorf("subkeys of /x/y/c = %v, want none", got)
+	}
+	if got := subKeys(t, c, "/a"); !reflect.DeepEqual(got, []string{"/a/moved"}) {
+		t.Errorf("subkeys of /a = %v, want [/a/moved]", got)
+	}
+	if err := f.Rename(ctx, "/x", "/x/y/z"); !errors.Is(err, ErrInvalid) {
+		t.Errorf("f.Rename(ctx, /x, /x/y/z) = %v, want ErrInvalid", err)
+	}
+	if err := f.Rename(ctx, "/a/moved", "/x/y"); !errors.Is(err, ErrExist) {
+		t.Errorf("f.Rename(ctx, /a/moved, /x/y) = %v, want ErrExist", err)
+	}
+}
+
+// Local Variables:
+// c-basic-offset: 4
+// tab-width: 4
+// indent-tabs-mode: t
+// End:
+// vim600: noexpandtab sw=4 ts=4 fdm=marker
+// vim<600: noexpandtab sw=4 ts=4
@@ -0,0 +1,434 @@
+//
+// k2hdkc_go
+//
+// Copyright 2018 Yahoo Japan Corporation.
+//
+// Go driver for k2hdkc that is a highly available and scalable distributed
+// KVS clustering system. For k2hdkc, see
+// https://github.com/yahoojapan/k2hdkc for the details.
+//
+// For the full copyright and license information, please view
+// the license file that was distributed with this source code.
+//
+// AUTHOR:   Hirotaka Wakabayashi
+// CREATE:   Fri, 16 Oct 2026
+// REVISION:
+//
+
+// Package lock implements a distributed mutex with leases on a k2hdkc cluster.
+//
+// A Mutex uses three kinds of keys.
+//
+//	name              cas64 value which is the fencing token of the last holder.
+//	name/fence        cas64 counter which the fencing tokens are taken from.
+//	name/lease/token  value which expires unless the holder of the token renews it.
+//
+// Init creates the name key and the counter once before the mutex of the name is used.
+// k2hdkc has no request which creates a key only if it doesn't exist, so Lock never
+// creates them and returns ErrNotInitialized instead.
+//
+// The mutex is held while the lease of the token in the name key exists. A holder
+// renews its lease in a goroutine and the Lost channel is closed when the lease
+// has expired or another holder has taken the mutex. Fencing tokens increase with
+// every acquisition, so a resource can reject requests with an older token.
+package lock
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/yahoojapan/k2hdkc_go/k2hdkc"
+)
+
+var (
+	// ErrNotLocked is returned by Unlock if the mutex isn't held.
+	ErrNotLocked = errors.New("lock: mutex is not locked")
+	// ErrLeaseLost is returned by Unlock if the lease has been lost before Unlock.
+	ErrLeaseLost = errors.New("lock: lease lost")
+	// ErrNotInitialized is returned by Lock if Init hasn't created the keys of the name.
+	ErrNotInitialized = errors.New("lock: mutex is not initialized")
+)
+
+const (
+	defaultTTL           = 10 * time.Second
+	defaultRetryInterval = 100 * time.Millisecond
+)
+
+// Option sets an optional parameter of a Mutex.
+type Option func(*Mutex)
+
+// WithTTL sets the lease time. The lease expires unless it's renewed in the time.
+// k2hdkc expires keys in seconds. A duration less than a second is rounded up to a second.
+func WithTTL(d time.Duration) Option {
+	return func(m *Mutex) {
+		if d > 0 {
+			m.ttl = d
+		}
+	}
+}
+
+// WithRenewInterval sets the interval of the lease renewal. The default is a third of the TTL.
+// An interval not less than the TTL is ignored.
+func WithRenewInterval(d time.Duration) Option {
+	return func(m *Mutex) {
+		if d > 0 {
+			m.renew = d
+		}
+	}
+}
+
+// WithRetryInterval sets the interval Lock waits before trying again.
+func WithRetryInterval(d time.Duration) Option {
+	return func(m *Mutex) {
+		if d > 0 {
+			m.retry = d
+		}
+	}
+}
+
+// WithOwner sets the identity of the holder which is saved in the lease. The default is "hostname:pid".
+func WithOwner(o string) Option {
+	return func(m *Mutex) {
+		if o != "" {
+			m.owner = o
+		}
+	}
+}
+
+// defaultOwner returns the identity of this process.
+func defaultOwner() string {
+	host, err := os.Hostname()
+	if err != nil {
+		host = "localhost"
+	}
+	return fmt.Sprintf("%s:%d", host, os.Getpid())
+}
+
+// Mutex is a lease based mutual exclusion lock on a k2hdkc cluster.
+// A Mutex is safe for concurrent use by multiple goroutines. Lock on a Mutex which
+// is held by the same Mutex waits for Unlock like sync.Mutex.
+type Mutex struct {
+	client *k2hdkc.Client
+	name   string
+	owner  string
+	ttl    time.Duration
+	renew  time.Duration
+	retry  time.Duration
+
+	mu    sync.Mutex
+	token uint64        // fencing token of the current lease. zero means not held.
+	lost  chan struct{} // closed when the current lease is lost.
+	stop  chan struct{} // closed by Unlock to stop the renewal.
+	done  chan struct{} // closed when the renewal returns.
+}
+
+// Init creates the name key and the fencing token counter of the mutex of the name unless
+// they exist. Call it once, for example when the application is deployed, before any
+// Mutex of the name is locked. Existing keys are never reset.
+func Init(ctx context.Context, c *k2hdkc.Client, name string) error {
+	for _, key := range []string{name, name + "/fence"} {
+		_, err := c.CasGetContext(ctx, key)
+		if errors.Is(err, k2hdkc.ErrNotFound) {
+			err = c.CasInitContext(ctx, key, 0)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// NewMutex returns the pointer to a Mutex of the name. Init must have created the keys of the name.
+func NewMutex(c *k2hdkc.Client, name string, opts ...Option) *Mutex {
+	m := &Mutex{
+		client: c,
+		name:   name,
+		owner:  defaultOwner(),
+		ttl:    defaultTTL,
+		retry:  defaultRetryInterval,
+	}
+	for _, opt := range opts {
+		if opt != nil {
+			opt(m)
+		}
+	}
+	if m.renew <= 0 || m.renew >= m.ttl {
+		m.renew = m.ttl / 3
+	}
+	return m
+}
+
+// String returns a text representation of the object.
+func (m *Mutex) String() string {
+	return fmt.Sprintf("[%v, %v, %v]", m.name, m.owner, m.ttl)
+}
+
+// fenceKey returns the key of the fencing token counter.
+func (m *Mutex) fenceKey() string {
+	return m.name + "/fence"
+}
+
+// leaseKey returns the key of the lease of the token.
+func (m *Mutex) leaseKey(token uint64) string {
+	return m.name + "/lease/" + strconv.FormatUint(token, 10)
+}
+
+// Lock acquires the mutex. It waits until the mutex is released or the context is done.
+// ErrNotInitialized returns if Init hasn't been called for the name.
+func (m *Mutex) Lock(ctx context.Context) error {
+	for {
+		ok, err := m.TryLockContext(ctx)
+		if err != nil {
+			return err
+		}
+		if ok {
+			return nil
+		}
+		t := time.NewTimer(m.retry)
+		select {
+		case <-t.C:
+		case <-ctx.Done():
+			t.Stop()
+			return ctx.Err()
+		}
+	}
+}
+
+// TryLock tries to acquire the mutex and returns false if it's held by another.
+func (m *Mutex) TryLock() (bool, error) {
+	return m.TryLockContext(context.Background())
+}
+
+// TryLockContext tries to acquire the mutex and returns false if it's held by another.
+// ErrNotInitialized returns if Init hasn't been called for the name.
+func (m *Mutex) TryLockContext(ctx context.Context) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.token != 0 {
+		select {
+		case <-m.lost:
+			// the renewal has returned after closing lost.
+			<-m.done
+			m.token = 0
+		default:
+			return false, nil
+		}
+	}
+	cur, err := m.client.CasGetContext(ctx, m.name)
+	if err != nil {
+		return false, m.notInitialized(err)
+	}
+	if cur != 0 {
+		_, err := m.client.GetValueContext(ctx, m.leaseKey(cur))
+		if err == nil {
+			return false, nil
+		}
+		if !errors.Is(err, k2hdkc.ErrNotFound) {
+			return false, err
+		}
+	}
+	token, err := m.nextToken(ctx, cur)
+	if err != nil {
+		return false, err
+	}
+	lease := m.leaseKey(token)
+	if err := m.client.SetValueContext(ctx, lease, []byte(m.owner), k2hdkc.WithExpire(m.ttl)); err != nil {
+		return false, err
+	}
+	if err := m.client.CasSetContext(ctx, m.name, cur, token); err != nil {
+		m.client.RemoveContext(ctx, lease)
+		if errors.Is(err, k2hdkc.ErrCasConflict) {
+			return false, nil
+		}
+		return false, err
+	}
+	m.token = token
+	m.lost = make(chan struct{})
+	m.stop = make(chan struct{})
+	m.done = make(chan struct{})
+	go m.keepAlive(token, m.lost, m.stop, m.done)
+	return true, nil
+}
+
+// notInitialized returns ErrNotInitialized if err means a key of the mutex is missing, otherwise err.
+func (m *Mutex) notInitialized(err error) error {
+	if errors.Is(err, k2hdkc.ErrNotFound) {
+		return fmt.Errorf("%w: %v", ErrNotInitialized, m.name)
+	}
+	return err
+}
+
+// nextToken increments the fencing token counter and returns the new value, which
+// is greater than cur. The counter is raised to cur first if it's behind.
+func (m *Mutex) nextToken(ctx context.Context, cur uint64) (uint64, error) {
+	key := m.fenceKey()
+	for {
+		v, err := m.client.CasGetContext(ctx, key)
+		if err != nil {
+			return 0, m.notInitialized(err)
+		}
+		next := v + 1
+		if v < cur {
+			next = cur + 1
+		}
+		err = m.client.CasSetContext(ctx, key, v, next)
+		if err == nil {
+			return next, nil
+		}
+		if !errors.Is(err, k2hdkc.ErrCasConflict) {
+			return 0, err
+		}
+		if err := ctx.Err(); err != nil {
+			return 0, err
+		}
+	}
+}
+
+// keepAlive renews the lease of the token until stop is closed. lost is closed if
+// the lease is lost or it can't be renewed before it expires. The holder gives up the
+// lease a renewal interval before the server expires it, so two holders don't overlap
+// even if the requests hang while chmpx rejoins.
+func (m *Mutex) keepAlive(token uint64, lost chan struct{}, stop chan struct{}, done chan struct{}) {
+	defer close(done)
+	t := time.NewTicker(m.renew)
+	defer t.Stop()
+	renewed := time.Now()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-t.C:
+		}
+		start := time.Now()
+		ctx, cancel := context.WithDeadline(context.Background(), renewed.Add(m.ttl-m.renew))
+		held, err := m.held(ctx, token)
+		if err == nil && held {
+			err = m.client.SetValueContext(ctx, m.leaseKey(token), []byte(m.owner), k2hdkc.WithExpire(m.ttl))
+			if err == nil {
+				renewed = start
+				cancel()
+				continue
+			}
+		}
+		cancel()
+		if err == nil || time.Since(renewed)+m.renew >= m.ttl {
+			close(lost)
+			return
+		}
+	}
+}
+
+// held returns true if the token is in the name key and its lease exists.
+func (m *Mutex) held(ctx context.Context, token uint64) (bool, error) {
+	cur, err := m.client.CasGetContext(ctx, m.name)
+	if err != nil {
+		if errors.Is(err, k2hdkc.ErrNotFound) {
+			return false, nil
+		}
+		return false, err
+	}
+	if cur != token {
+		return false, nil
+	}
+	if _, err := m.client.GetValueContext(ctx, m.leaseKey(token)); err != nil {
+		if errors.Is(err, k2hdkc.ErrNotFound) {
+			return false, nil
+		}
+		return false, err
+	}
+	return true, nil
+}
+
+// Unlock releases the mutex.
+func (m *Mutex) Unlock() error {
+	return m.UnlockContext(context.Background())
+}
+
+// UnlockContext releases the mutex. ErrLeaseLost returns if the lease has been lost.
+func (m *Mutex) UnlockContext(ctx context.Context) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.token == 0 {
+		return ErrNotLocked
+	}
+	token := m.token
+	close(m.stop)
+	<-m.done
+	m.token = 0
+	select {
+	case <-m.lost:
+		return ErrLeaseLost
+	default:
+	}
+	// the lost channel is closed for the watchers.
+	close(m.lost)
+	held, err := m.held(ctx, token)
+	if err != nil {
+		return err
+	}
+	if !held {
+		return ErrLeaseLost
+	}
+	// the name key keeps the token to let the next token be greater.
+	return m.client.RemoveContext(ctx, m.leaseKey(token))
+}
+
+// Holder returns the owner and the fencing token of the current lease.
+// An empty owner and zero return if no one holds the mutex.
+func (m *Mutex) Holder(ctx context.Context) (string, uint64, error) {
+	cur, err := m.client.CasGetContext(ctx, m.name)
+	if errors.Is(err, k2hdkc.ErrNotFound) || (err == nil && cur == 0) {
+		return "", 0, nil
+	}
+	if err != nil {
+		return "", 0, err
+	}
+	owner, err := m.client.GetValueContext(ctx, m.leaseKey(cur))
+	if errors.Is(err, k2hdkc.ErrNotFound) {
+		return "", 0, nil
+	}
+	if err != nil {
+		return "", 0, err
+	}
+	return string(owner), cur, nil
+}
+
+// Token returns the fencing token of the current lease. Zero returns if the mutex isn't held
+// or the lease has been lost.
+func (m *Mutex) Token() uint64 {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.token == 0 {
+		return 0
+	}
+	select {
+	case <-m.lost:
+		return 0
+	default:
+		return m.token
+	}
+}
+
+// Lost returns a channel which is closed when the current lease is lost or released.
+// A nil channel returns if the mutex isn't held.
+func (m *Mutex) Lost() <-chan struct{} {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.token == 0 {
+		return nil
+	}
+	return m.lost
+}
+
+// Local Variables:
+// c-basic-offset: 4
+// tab-width: 4
+// indent-tabs-mode: t
+// End:
+// vim600: noexpandtab sw=4 ts=4 fdm=marker
+// vim<600: noexpandtab sw=4 ts=4
@@ -0,0 +1,351 @@
+//
+// k2hdkc_go
+//
+// Copyright 2018 Yahoo Japan Corporation.
+//
+// Go driver for k2hdkc that is a highly available and scalable distributed
+// KVS clustering system. For k2hdkc, see
+// https://github.com/yahoojapan/k2hdkc for the details.
+//
+// For the full copyright and license information, please view
+// the license file that was distributed with this source code.
+//
+// AUTHOR:   Hirotaka Wakabayashi
+// CREATE:   Fri, 16 Oct 2026
+// REVISION:
+//
+
+package lock
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/yahoojapan/k2hdkc_go/k2hdkc"
+	"github.com/yahoojapan/k2hdkc_go/k2hdkc/k2hdkctest"
+)
+
+// initMutex creates the keys of the mutex of the name.
+func initMutex(t *testing.T, c *k2hdkc.Client, name string) {
+	if err := Init(context.Background(), c, name); err != nil {
+		t.Fatalf("Init(ctx, c, %v) = %v", name, err)
+	}
+}
+
+// TestMutexInit tests mutexes racing on a fresh name never lock it until Init creates the keys,
+// and then only one of them holds it.
+func TestMutexInit(t *testing.T) {
+	s := k2hdkctest.NewServer()
+	c := s.NewClient()
+	defer c.Close()
+
+	race := func(ms ...*Mutex) (int, []error) {
+		var wg sync.WaitGroup
+		oks := make([]bool, len(ms))
+		errs := make([]error, len(ms))
+		for i, m := range ms {
+			wg.Add(1)
+			go func(i int, m *Mutex) {
+				defer wg.Done()
+				oks[i], errs[i] = m.TryLock()
+			}(i, m)
+		}
+		wg.Wait()
+		n := 0
+		for _, ok := range oks {
+			if ok {
+				n++
+			}
+		}
+		return n, errs
+	}
+	m1 := NewMutex(c, "fresh")
+	m2 := NewMutex(c, "fresh")
+	n, errs := race(m1, m2)
+	for _, err := range errs {
+		if !errors.Is(err, ErrNotInitialized) {
+			t.Errorf("m.TryLock() of a fresh name = %v, want ErrNotInitialized", err)
+		}
+	}
+	if n != 0 {
+		t.Fatalf("%v mutexes hold a fresh name, want none", n)
+	}
+	if _, err := c.CasGet("fresh"); !errors.Is(err, k2hdkc.ErrNotFound) {
+		t.Errorf("c.CasGet(fresh) after TryLock = %v, want ErrNotFound", err)
+	}
+
+	initMutex(t, c, "fresh")
+	for i := 0; i < 10; i++ {
+		n, errs := race(m1, m2)
+		for _, err := range errs {
+			if err != nil {
+				t.Errorf("m.TryLock() = %v", err)
+			}
+		}
+		if n != 1 {
+			t.Fatalf("%v mutexes hold the name, want 1", n)
+		}
+		held, other := m1, m2
+		if held.Token() == 0 {
+			held, other = m2, m1
+		}
+		if other.Token() != 0 {
+			t.Errorf("other.Token() = %v, want 0", other.Token())
+		}
+		token := held.Token()
+		// Init again doesn't reset the keys of the holder.
+		initMutex(t, c, "fresh")
+		if _, cur, err := held.Holder(context.Background()); err != nil || cur != token {
+			t.Errorf("held.Holder(ctx) = (%v, %v), want %v", cur, err, token)
+		}
+		if err := held.Unlock(); err != nil {
+			t.Fatalf("held.Unlock() = %v", err)
+		}
+	}
+}
+
+// TestMutexTryLock tests a mutex excludes another and the fencing token increases.
+func TestMutexTryLock(t *testing.T) {
+	s := k2hdkctest.NewServer()
+	c := s.NewClient()
+	defer c.Close()
+	initMutex(t, c, "mutex")
+
+	m1 := NewMutex(c, "mutex")
+	m2 := NewMutex(c, "mutex")
+	if ok, err := m1.TryLock(); !ok || err != nil {
+		t.Fatalf("m1.TryLock() = (%v, %v), want true", ok, err)
+	}
+	if ok, err := m2.TryLock(); ok || err != nil {
+		t.Errorf("m2.TryLock() = (%v, %v), want false", ok, err)
+	}
+	token := m1.Token()
+	if token == 0 {
+		t.Errorf("m1.Token() = 0, want non-zero")
+	}
+	lost := m1.Lost()
+	if err := m1.Unlock(); err != nil {
+		t.Fatalf("m1.Unlock() = %v", err)
+	}
+	select {
+	case <-lost:
+	default:
+		t.Errorf("m1.Lost() is not closed after Unlock")
+	}
+	if err := m1.Unlock(); err != ErrNotLocked {
+		t.Errorf("m1.Unlock() twice = %v, want ErrNotLocked", err)
+	}
+	if ok, err := m2.TryLock(); !ok || err != nil {
+		t.Fatalf("m2.TryLock() after unlock = (%v, %v), want true", ok, err)
+	}
+	defer m2.Unlock()
+	if m2.Token() <= token {
+		t.Errorf("m2.Token() = %v, want greater than %v", m2.Token(), token)
+	}
+}
+
+// TestMutexLock tests Lock waits for Unlock or the context.
+func TestMutexLock(t *testing.T) {
+	s := k2hdkctest.NewServer()
+	c := s.NewClient()
+	defer c.Close()
+	initMutex(t, c, "mutex")
+
+	m1 := NewMutex(c, "mutex")
+	m2 := NewMutex(c, "mutex", WithRetryInterval(5*time.Millisecond))
+	if err := m1.Lock(context.Background()); err != nil {
+		t.Fatalf("m1.Lock(ctx) = %v", err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := m2.Lock(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("m2.Lock(ctx) = %v, want DeadlineExceeded", err)
+	}
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		m1.Unlock()
+	}()
+	if err := m2.Lock(context.Background()); err != nil {
+		t.Fatalf("m2.Lock(ctx) = %v", err)
+	}
+	if err := m2.Unlock(); err != nil {
+		t.Errorf("m2.Unlock() = %v", err)
+	}
+}
+
+// TestMutexLost tests an expired lease is reported and taken by another.
+func TestMutexLost(t *testing.T) {
+	s := k2hdkctest.NewServer()
+	var offset int64
+	s.SetNow(func() time.Time { return time.Now().Add(time.Duration(atomic.LoadInt64(&offset))) })
+	in := k2hdkctest.NewInjector(s)
+	c := in.NewClient()
+	defer c.Close()
+	initMutex(t, c, "mutex")
+
+	m1 := NewMutex(c, "mutex", WithTTL(time.Second), WithRenewInterval(10*time.Millisecond))
+	m2 := NewMutex(c, "mutex")
+	if ok, err := m1.TryLock(); !ok || err != nil {
+		t.Fatalf("m1.TryLock() = (%v, %v), want true", ok, err)
+	}
+	lost := m1.Lost()
+	time.Sleep(30 * time.Millisecond)
+	select {
+	case <-lost:
+		t.Fatalf("m1.Lost() is closed while the lease is renewed")
+	default:
+	}
+	// a renewal in flight would set the expired lease again.
+	in.Add(k2hdkctest.Fault{Op: k2hdkc.OpSet, Key: regexp.MustCompile("^mutex/lease/"), Fail: true, SubCode: k2hdkc.SubCodeTimeout})
+	atomic.StoreInt64(&offset, int64(time.Hour))
+	select {
+	case <-lost:
+	case <-time.After(time.Second):
+		t.Fatalf("m1.Lost() is not closed after the lease expired")
+	}
+	in.Clear()
+	if ok, err := m2.TryLock(); !ok || err != nil {
+		t.Errorf("m2.TryLock() = (%v, %v), want true", ok, err)
+	}
+	if err := m1.Unlock(); err != ErrLeaseLost {
+		t.Errorf("m1.Unlock() = %v, want ErrLeaseLost", err)
+	}
+	if err := m2.Unlock(); err != nil {
+		t.Errorf("m2.Unlock() = %v", err)
+	}
+}
+
+// TestMutexConcurrent tests mutexes locking the name concurrently take unique tokens and exclude each other.
+func TestMutexConcurrent(t *testing.T) {
+	s := k2hdkctest.NewServer()
+	c := s.NewClient()
+	defer c.Close()
+	initMutex(t, c, "mutex")
+
+	m := NewMutex(c, "mutex")
+	if err := m.Lock(context.Background()); err != nil {
+		t.Fatalf("m.Lock(ctx) = %v", err)
+	}
+	if err := m.Unlock(); err != nil {
+		t.Fatalf("m.Unlock() = %v", err)
+	}
+	var (
+		wg      sync.WaitGroup
+		mu      sync.Mutex
+		holders int32
+		tokens  = make(map[uint64]bool)
+	)
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			m := NewMutex(c, "mutex", WithRetryInterval(time.Millisecond))
+			for j := 0; j < 5; j++ {
+				if err := m.Lock(context.Background()); err != nil {
+					t.Errorf("m.Lock(ctx) = %v", err)
+					return
+				}
+				if n := atomic.AddInt32(&holders, 1); n != 1 {
+					t.Errorf("%v holders, want 1", n)
+				}
+				mu.Lock()
+				if tokens[m.Token()] {
+					t.Errorf("m.Token() = %v, which has been taken", m.Token())
+				}
+				tokens[m.Token()] = true
+				mu.Unlock()
+				atomic.AddInt32(&holders, -1)
+				if err := m.Unlock(); err != nil {
+					t.Errorf("m.Unlock() = %v", err)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+// TestMutexFenceReset tests the tokens stay greater than the token of the holder after the counter is reset.
+func TestMutexFenceReset(t *testing.T) {
+	s := k2hdkctest.NewServer()
+	c := s.NewClient()
+	defer c.Close()
+	initMutex(t, c, "mutex")
+
+	m1 := NewMutex(c, "mutex")
+	m2 := NewMutex(c, "mutex")
+	if ok, err := m1.TryLock(); !ok || err != nil {
+		t.Fatalf("m1.TryLock() = (%v, %v), want true", ok, err)
+	}
+	token := m1.Token()
+	if err := m1.Unlock(); err != nil {
+		t.Fatalf("m1.Unlock() = %v", err)
+	}
+	if err := c.CasInit("mutex/fence", 0); err != nil {
+		t.Fatalf("c.CasInit(mutex/fence, 0) = %v", err)
+	}
+	if ok, err := m2.TryLock(); !ok || err != nil {
+		t.Fatalf("m2.TryLock() = (%v, %v), want true", ok, err)
+	}
+	defer m2.Unlock()
+	if m2.Token() <= token {
+		t.Errorf("m2.Token() = %v, want greater than %v", m2.Token(), token)
+	}
+}
+
+// TestMutexRelock tests a mutex whose lease has been lost can be locked again without Unlock.
+func TestMutexRelock(t *testing.T) {
+	s := k2hdkctest.NewServer()
+	c := s.NewClient()
+	defer c.Close()
+	initMutex(t, c, "mutex")
+
+	m1 := NewMutex(c, "mutex", WithRenewInterval(10*time.Millisecond))
+	m2 := NewMutex(c, "mutex")
+	if ok, err := m1.TryLock(); !ok || err != nil {
+		t.Fatalf("m1.TryLock() = (%v, %v), want true", ok, err)
+	}
+	lost := m1.Lost()
+	// another holder takes the mutex after the lease is removed.
+	if err := c.Remove("mutex/lease/" + strconv.FormatUint(m1.Token(), 10)); err != nil {
+		t.Fatalf("c.Remove(lease) = %v", err)
+	}
+	if ok, err := m2.TryLock(); !ok || err != nil {
+		t.Fatalf("m2.TryLock() = (%v, %v), want true", ok, err)
+	}
+	select {
+	case <-lost:
+	case <-time.After(time.Second):
+		t.Fatalf("m1.Lost() is not closed after the lease is taken")
+	}
+	if token := m1.Token(); token != 0 {
+		t.Errorf("m1.Token() after lost = %v, want 0", token)
+	}
+	if ok, err := m1.TryLock(); ok || err != nil {
+		t.Errorf("m1.TryLock() while m2 holds = (%v, %v), want false", ok, err)
+	}
+	if err := m2.Unlock(); err != nil {
+		t.Fatalf("m2.Unlock() = %v", err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := m1.Lock(ctx); err != nil {
+		t.Fatalf("m1.Lock(ctx) after lost = %v", err)
+	}
+	if err := m1.Unlock(); err != nil {
+		t.Errorf("m1.Unlock() = %v", err)
+	}
+}
+
+// Local Variables:
+// c-basic-offset: 4
+// tab-width: 4
+// indent-tabs-mode: t
+// End:
+// vim600: noexpandtab sw=4 ts=4 fdm=marker
+// vim<600: noexpandtab sw=4 ts=4
@@ -0,0 +1,263 @@
+//
+// k2hdkc_go
+//
+// Copyright 2018 Yahoo Japan Corporation.
+//
+// Go driver for k2hdkc that is a highly available and scalable distributed
+// KVS clustering system. For k2hdkc, see
+// https://github.com/yahoojapan/k2hdkc for the details.
+//
+// For the full copyright and license information, please view
+// the license file that was distributed with this source code.
+//
+// AUTHOR:   Hirotaka Wakabayashi
+// CREATE:   Fri, 16 Oct 2026
+// REVISION:
+//
+
+package k2hdkc
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Level is the level of a structured log message. The values are the same as the levels of log/slog.
+type Level int
+
+// LevelDebug is for messages of each request.
+// LevelInfo is for normal messages.
+// LevelWarn is for failures which the caller handles.
+// LevelError is for failures of the library.
+const (
+	LevelDebug Level = -4
+	LevelInfo  Level = 0
+	LevelWarn  Level = 4
+	LevelError Level = 8
+)
+
+var levelText = map[Level]string{
+	LevelDebug: "DEBUG",
+	LevelInfo:  "INFO",
+	LevelWarn:  "WARN",
+	LevelError: "ERROR",
+}
+
+// String returns the name of the level.
+func (l Level) String() string {
+	if s, ok := levelText[l]; ok {
+		return s
+	}
+	return fmt.Sprintf("LEVEL(%d)", int(l))
+}
+
+// Field is a key and a value of a structured log message.
+type Field struct {
+	Key   string
+	Value interface{}
+}
+
+// Field keys of the messages of this package.
+const (
+	FieldCommand   = "command"   // type name of the command, such as Get.
+	FieldKey       = "key"       // key of the command, which may be hashed.
+	FieldResCode   = "resCode"   // result code of the request.
+	FieldSubCode   = "subCode"   // sub code of the request.
+	FieldSession   = "session"   // id of the session.
+	FieldError     = "error"     // error text.
+	FieldComponent = "component" // C library which wrote the message, such as chmpx.
+)
+
+// Logger is the interface of structured loggers. A K2hLog is a Logger.
+type Logger interface {
+	Enabled(ctx context.Context, level Level) bool
+	Log(ctx context.Context, level Level, msg string, fields ...Field)
+}
+
+// Enabled returns true if the severity of the K2hLog prints messages of the level.
+func (l *K2hLog) Enabled(ctx context.Context, level Level) bool {
+	_, severity := l.output()
+	return enabled(severity, level)
+}
+
+// enabled returns true if the severity prints messages of the level.
+func enabled(severity logSeverity, level Level) bool {
+	switch {
+	case level >= LevelError:
+		return severity&(SeverityDump|SeverityInfo|SeverityError) != 0
+	case level >= LevelWarn:
+		return severity&(SeverityDump|SeverityInfo|SeverityError|SeverityWarning) != 0
+	case level >= LevelInfo:
+		return severity&(SeverityDump|SeverityInfo) != 0
+	default:
+		return severity&(SeverityDump) != 0
+	}
+}
+
+// Log prints the message with the fields in key=value format.
+func (l *K2hLog) Log(ctx context.Context, level Level, msg string, fields ...Field) {
+	logger, severity := l.output()
+	if !enabled(severity, level) {
+		return
+	}
+	var p logSeverity
+	switch {
+	case level >= LevelError:
+		p = SeverityError
+	case level >= LevelWarn:
+		p = SeverityWarning
+	case level >= LevelInfo:
+		p = SeverityInfo
+	default:
+		p = SeverityDump
+	}
+	var b strings.Builder
+	b.WriteString(msg)
+	for _, f := range fields {
+		b.WriteByte(' ')
+		b.WriteString(f.Key)
+		b.WriteByte('=')
+		b.WriteString(fieldText(f.Value))
+	}
+	logger.Printf("[%v] %v", logSeverityText[p], b.String())
+}
+
+// fieldText returns the value in text. It's quoted if it has spaces or quotes.
+func fieldText(v interface{}) string {
+	var s string
+	switch t := v.(type) {
+	case string:
+		s = t
+	case error:
+		s = t.Error()
+	case fmt.Stringer:
+		s = t.String()
+	default:
+		s = fmt.Sprint(v)
+	}
+	if s == "" || strings.ContainsAny(s, " \t\r\n\"=") {
+		return fmt.Sprintf("%q", s)
+	}
+	return s
+}
+
+// jsonLogger writes messages in JSON lines.
+type jsonLogger struct {
+	mu    sync.Mutex
+	w     io.Writer
+	level Level
+}
+
+// NewJSONLogger returns a Logger which writes the messages of the level or higher to w,
+// one JSON object in a line with the time, level and msg keys and the fields.
+func NewJSONLogger(w io.Writer, level Level) Logger {
+	return &jsonLogger{w: w, level: level}
+}
+
+// Enabled returns true if the level is the level of the logger or higher.
+func (l *jsonLogger) Enabled(ctx context.Context, level Level) bool {
+	return level >= l.level
+}
+
+// Log writes the message in a line.
+func (l *jsonLogger) Log(ctx context.Context, level Level, msg string, fields ...Field) {
+	if !l.Enabled(ctx, level) {
+		return
+	}
+	var b bytes.Buffer
+	b.WriteString(`{"time":`)
+	writeJSON(&b, time.Now().Format(time.RFC3339Nano))
+	b.WriteString(`,"level":`)
+	writeJSON(&b, level.String())
+	b.WriteString(`,"msg":`)
+	writeJSON(&b, msg)
+	for _, f := range fields {
+		b.WriteByte(',')
+		writeJSON(&b, f.Key)
+		b.WriteByte(':')
+		switch v := f.Value.(type) {
+		case error:
+			writeJSON(&b, v.Error())
+		case fmt.Stringer:
+			writeJSON(&b, v.String())
+		default:
+			writeJSON(&b, v)
+		}
+	}
+	b.WriteString("}\n")
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.w.Write(b.Bytes())
+}
+
+// writeJSON writes v in JSON. The text of v is written if it can't be encoded.
+func writeJSON(b *bytes.Buffer, v interface{}) {
+	j, err := json.Marshal(v)
+	if err != nil {
+		j, _ = json.Marshal(fmt.Sprint(v))
+	}
+	b.Write(j)
+}
+
+// SetStructuredLogger sets the Logger of the messages of the client.
+// nil, the default, means the messages go to the K2hLog of the client.
+func (c *Client) SetStructuredLogger(l Logger) *Client {
+	c.logger = l
+	return c
+}
+
+// SetLogKeyHash sets whether keys are hashed in log messages, which hides keys holding
+// personal data. The hash is the first 8 bytes of SHA-256 in hex.
+func (c *Client) SetLogKeyHash(b bool) *Client {
+	c.hashKeys = b
+	return c
+}
+
+// Logger returns the Logger of the messages of the client.
+func (c *Client) Logger() Logger {
+	if c.logger != nil {
+		return c.logger
+	}
+	if c.log != nil {
+		return c.log
+	}
+	return K2hLogInstance()
+}
+
+// keyField returns the key field of k.
+func (c *Client) keyField(k []byte) Field {
+	if c.hashKeys {
+		sum := sha256.Sum256(k)
+		return Field{FieldKey, "sha256:" + hex.EncodeToString(sum[:8])}
+	}
+	return Field{FieldKey, string(trimNul(k))}
+}
+
+// commandFields returns the fields of the command and its result.
+// The result of a command abandoned by the context isn't read.
+func (c *Client) commandFields(cmd Command, err error) []Field {
+	fields := []Field{{FieldCommand, commandName(cmd)}, c.keyField(CommandKey(cmd))}
+	if isContextError(err) {
+		return fields
+	}
+	if code, sub, ok := CommandResult(cmd); ok {
+		fields = append(fields, Field{FieldResCode, code.String()}, Field{FieldSubCode, sub.String()})
+	}
+	return fields
+}
+
+// Local Variables:
+// c-basic-offset: 4
+// tab-width: 4
+// indent-tabs-mode: t
+// End:
+// vim600: noexpandtab sw=4 ts=4 fdm=marker
+// vim<600: noexpandtab sw=4 ts=4
@@ -0,0 +1,67 @@
+//
+// k2hdkc_go
+//
+// Copyright 2018 Yahoo Japan Corporation.
+//
+// Go driver for k2hdkc that is a highly available and scalable distributed
+// KVS clustering system. For k2hdkc, see
+// https://github.com/yahoojapan/k2hdkc for the details.
+//
+// For the full copyright and license information, please view
+// the license file that was distributed with this source code.
+//
+// AUTHOR:   Hirotaka Wakabayashi
+// CREATE:   Fri, 16 Oct 2026
+// REVISION:
+//
+
+//go:build go1.21
+// +build go1.21
+
+package k2hdkc
+
+import (
+	"context"
+	"log/slog"
+	"time"
+)
+
+// slogLogger sends the messages to a slog.Handler.
+type slogLogger struct {
+	h slog.Handler
+}
+
+// NewSlogLogger returns a Logger which sends the messages to the slog.Handler, for example
+// slog.NewJSONHandler or the handler of slog.Default().
+func NewSlogLogger(h slog.Handler) Logger {
+	return &slogLogger{h: h}
+}
+
+// Enabled returns true if the handler handles records of the level.
+func (l *slogLogger) Enabled(ctx context.Context, level Level) bool {
+	return l.h.Enabled(ctx, slog.Level(level))
+}
+
+// Log sends a record with the fields as attributes.
+func (l *slogLogger) Log(ctx context.Context, level Level, msg string, fields ...Field) {
+	if !l.Enabled(ctx, level) {
+		return
+	}
+	r := slog.NewRecord(time.Now(), slog.Level(level), msg, 0)
+	for _, f := range fields {
+		if err, ok := f.Value.(error); ok {
+			r.AddAttrs(slog.String(f.Key, err.Error()))
+			continue
+		}
+		r.AddAttrs(slog.Any(f.Key, f.Value))
+	}
+	l.h.Handle(ctx, r)
+}
+
+// Local Variables:
+// c-basic-offset: 4
+// tab-width: 4
+// indent-tabs-mode: t
+// End:
+// vim600: noexpandtab sw=4 ts=4 fdm=marker
+// vim<600: noexpandtab sw=4 ts=4
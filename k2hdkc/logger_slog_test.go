@@ -0,0 +1,51 @@
+//
+// k2hdkc_go
+//
+// Copyright 2018 Yahoo Japan Corporation.
+//
+// Go driver for k2hdkc that is a highly available and scalable distributed
+// KVS clustering system. For k2hdkc, see
+// https://github.com/yahoojapan/k2hdkc for the details.
+//
+// For the full copyright and license information, please view
+// the license file that was distributed with this source code.
+//
+// AUTHOR:   Hirotaka Wakabayashi
+// CREATE:   Fri, 16 Oct 2026
+// REVISION:
+//
+
+//go:build go1.21
+// +build go1.21
+
+package k2hdkc_test
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"testing"
+
+	"github.com/yahoojapan/k2hdkc_go/k2hdkc"
+)
+
+// TestSlogLogger tests the messages go to a slog.Handler.
+func TestSlogLogger(t *testing.T) {
+	var buf bytes.Buffer
+	l := k2hdkc.NewSlogLogger(slog.NewJSONHandler(&buf, &slog.HandlerOptions{Level: slog.LevelWarn}))
+	l.Log(context.Background(), k2hdkc.LevelInfo, "hidden")
+	l.Log(context.Background(), k2hdkc.LevelWarn, "command failed", k2hdkc.Field{Key: k2hdkc.FieldCommand, Value: "Get"})
+
+	lines := logLines(t, &buf)
+	if len(lines) != 1 || lines[0]["level"] != "WARN" || lines[0]["msg"] != "command failed" || lines[0][k2hdkc.FieldCommand] != "Get" {
+		t.Errorf("lines = %v", lines)
+	}
+}
+
+// Local Variables:
+// c-basic-offset: 4
+// tab-width: 4
+// indent-tabs-mode: t
+// End:
+// vim600: noexpandtab sw=4 ts=4 fdm=marker
+// vim<600: noexpandtab sw=4 ts=4
@@ -0,0 +1,99 @@
+//
+// k2hdkc_go
+//
+// Copyright 2018 Yahoo Japan Corporation.
+//
+// Go driver for k2hdkc that is a highly available and scalable distributed
+// KVS clustering system. For k2hdkc, see
+// https://github.com/yahoojapan/k2hdkc for the details.
+//
+// For the full copyright and license information, please view
+// the license file that was distributed with this source code.
+//
+// AUTHOR:   Hirotaka Wakabayashi
+// CREATE:   Fri, 16 Oct 2026
+// REVISION:
+//
+
+package k2hdkc_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/yahoojapan/k2hdkc_go/k2hdkc"
+	"github.com/yahoojapan/k2hdkc_go/k2hdkc/k2hdkctest"
+)
+
+// logLines decodes the JSON lines.
+func logLines(t *testing.T, buf *bytes.Buffer) []map[string]interface{} {
+	var lines []map[string]interface{}
+	for _, l := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		if l == "" {
+			continue
+		}
+		m := make(map[string]interface{})
+		if err := json.Unmarshal([]byte(l), &m); err != nil {
+			t.Fatalf("json.Unmarshal(%q) = %v", l, err)
+		}
+		lines = append(lines, m)
+	}
+	return lines
+}
+
+// TestJSONLogger tests the JSON lines and the level.
+func TestJSONLogger(t *testing.T) {
+	var buf bytes.Buffer
+	l := k2hdkc.NewJSONLogger(&buf, k2hdkc.LevelInfo)
+	if l.Enabled(context.Background(), k2hdkc.LevelDebug) {
+		t.Errorf("LevelDebug is enabled")
+	}
+	l.Log(context.Background(), k2hdkc.LevelDebug, "hidden")
+	l.Log(context.Background(), k2hdkc.LevelWarn, "failed", k2hdkc.Field{Key: "n", Value: 3}, k2hdkc.Field{Key: "error", Value: errors.New("e")})
+	lines := logLines(t, &buf)
+	if len(lines) != 1 {
+		t.Fatalf("lines = %v, want 1 line", lines)
+	}
+	if m := lines[0]; m["level"] != "WARN" || m["msg"] != "failed" || m["n"] != 3.0 || m["error"] != "e" || m["time"] == nil {
+		t.Errorf("line = %v", m)
+	}
+}
+
+// TestClientLogger tests the fields of a failed command.
+func TestClientLogger(t *testing.T) {
+	in := k2hdkctest.NewInjector(k2hdkctest.NewServer())
+	var buf bytes.Buffer
+	c := in.NewClient().SetStructuredLogger(k2hdkc.NewJSONLogger(&buf, k2hdkc.LevelWarn))
+	defer c.Close()
+
+	in.Add(k2hdkctest.Fault{Op: k2hdkc.OpSet, Fail: true, SubCode: k2hdkc.SubCodeTimeout})
+	c.SetValue("secret", []byte("v"))
+	c.SetLogKeyHash(true)
+	c.SetValue("secret", []byte("v"))
+
+	lines := logLines(t, &buf)
+	if len(lines) != 2 {
+		t.Fatalf("lines = %v, want 2 lines", lines)
+	}
+	m := lines[0]
+	if m["msg"] != "command failed" || m[k2hdkc.FieldCommand] != "Set" || m[k2hdkc.FieldKey] != "secret" ||
+		m[k2hdkc.FieldResCode] != "DKC_RES_ERROR" || m[k2hdkc.FieldSubCode] != "DKC_RES_SUBCODE_TIMEOUT" ||
+		m[k2hdkc.FieldSession] == nil || m[k2hdkc.FieldError] == nil {
+		t.Errorf("line = %v", m)
+	}
+	if k, _ := lines[1][k2hdkc.FieldKey].(string); !strings.HasPrefix(k, "sha256:") || len(k) != len("sha256:")+16 {
+		t.Errorf("the hashed key = %q", k)
+	}
+}
+
+// Local Variables:
+// c-basic-offset: 4
+// tab-width: 4
+// indent-tabs-mode: t
+// End:
+// vim600: noexpandtab sw=4 ts=4 fdm=marker
+// vim<600: noexpandtab sw=4 ts=4
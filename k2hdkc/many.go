@@ -0,0 +1,119 @@
+//
+// k2hdkc_go
+//
+// Copyright 2018 Yahoo Japan Corporation.
+//
+// Go driver for k2hdkc that is a highly available and scalable distributed
+// KVS clustering system. For k2hdkc, see
+// https://github.com/yahoojapan/k2hdkc for the details.
+//
+// For the full copyright and license information, please view
+// the license file that was distributed with this source code.
+//
+// AUTHOR:   Hirotaka Wakabayashi
+// CREATE:   Fri, 16 Oct 2026
+// REVISION:
+//
+
+package k2hdkc
+
+import (
+	"context"
+	"errors"
+)
+
+// GetMany returns the values of the keys. The missing slice holds the keys which don't exist
+// in the order of keys and the errors map holds the keys which failed to be read.
+func (c *Client) GetMany(keys []string, opts ...Option) (vals map[string][]byte, missing []string, errs map[string]error) {
+	return c.GetManyContext(context.Background(), keys, opts...)
+}
+
+// GetManyContext returns the values of the keys. The missing slice holds the keys which don't
+// exist in the order of keys and the errors map holds the keys which failed to be read.
+func (c *Client) GetManyContext(ctx context.Context, keys []string, opts ...Option) (vals map[string][]byte, missing []string, errs map[string]error) {
+	o := newOptions(opts)
+	vals = make(map[string][]byte, len(keys))
+	errs = make(map[string]error)
+	b := NewBatch(c).SetPolicy(BatchContinueOnError).SetParallelism(o.concurrency)
+	names := make([]string, 0, len(keys))
+	for _, k := range keys {
+		cmd, err := NewGet(c.key(k))
+		if err != nil {
+			errs[k] = err
+			continue
+		}
+		cmd.SetEncPass(o.pass)
+		b.Add(cmd)
+		names = append(names, k)
+	}
+	res, err := b.Run(ctx)
+	for i, out := range res.Outcomes {
+		k := names[i]
+		// a backend reports a missing key as a success with SubCodeNoData or as ErrNotFound.
+		switch {
+		case err != nil:
+			errs[k] = err
+		case !out.OK && errors.Is(out.Err, ErrNotFound):
+			missing = append(missing, k)
+		case !out.OK:
+			errs[k] = out.Err
+		case out.Command.(*Get).Result().SubCode() == SubCodeNoData:
+			missing = append(missing, k)
+		default:
+			vals[k] = out.Command.(*Get).Result().Bytes()
+		}
+	}
+	if len(errs) != 0 {
+		c.Logger().Log(ctx, LevelWarn, "GetMany failed", Field{"failed", len(errs)}, Field{"keys", len(keys)})
+	}
+	return vals, missing, errs
+}
+
+// SetMany sets the values of the keys. The map returned holds the keys which failed to be
+// written and it is nil if all keys were written.
+func (c *Client) SetMany(kv map[string][]byte, opts ...Option) map[string]error {
+	return c.SetManyContext(context.Background(), kv, opts...)
+}
+
+// SetManyContext sets the values of the keys. The map returned holds the keys which failed to
+// be written and it is nil if all keys were written.
+func (c *Client) SetManyContext(ctx context.Context, kv map[string][]byte, opts ...Option) map[string]error {
+	o := newOptions(opts)
+	errs := make(map[string]error)
+	b := NewBatch(c).SetPolicy(BatchContinueOnError).SetParallelism(o.concurrency)
+	names := make([]string, 0, len(kv))
+	for k, v := range kv {
+		cmd, err := NewSet(c.key(k), v)
+		if err != nil {
+			errs[k] = err
+			continue
+		}
+		cmd.SetEncPass(o.pass)
+		cmd.SetExpire(o.expire)
+		cmd.SetRmSubKeyList(o.rmSubKeyList)
+		b.Add(cmd)
+		names = append(names, k)
+	}
+	res, err := b.Run(ctx)
+	for i, out := range res.Outcomes {
+		switch {
+		case err != nil:
+			errs[names[i]] = err
+		case !out.OK:
+			errs[names[i]] = out.Err
+		}
+	}
+	if len(errs) == 0 {
+		return nil
+	}
+	c.Logger().Log(ctx, LevelWarn, "SetMany failed", Field{"failed", len(errs)}, Field{"keys", len(kv)})
+	return errs
+}
+
+// Local Variables:
+// c-basic-offset: 4
+// tab-width: 4
+// indent-tabs-mode: t
+// End:
+// vim600: noexpandtab sw=4 ts=4 fdm=marker
+// vim<600: noexpandtab sw=4 ts=4
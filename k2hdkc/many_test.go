@@ -0,0 +1,103 @@
+//
+// k2hdkc_go
+//
+// Copyright 2018 Yahoo Japan Corporation.
+//
+// Go driver for k2hdkc that is a highly available and scalable distributed
+// KVS clustering system. For k2hdkc, see
+// https://github.com/yahoojapan/k2hdkc for the details.
+//
+// For the full copyright and license information, please view
+// the license file that was distributed with this source code.
+//
+// AUTHOR:   Hirotaka Wakabayashi
+// CREATE:   Fri, 16 Oct 2026
+// REVISION:
+//
+
+package k2hdkc_test
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"regexp"
+	"testing"
+
+	"github.com/yahoojapan/k2hdkc_go/k2hdkc"
+	"github.com/yahoojapan/k2hdkc_go/k2hdkc/k2hdkctest"
+)
+
+// TestSetManyGetMany tests the values SetMany writes are read by GetMany.
+func TestSetManyGetMany(t *testing.T) {
+	s := k2hdkctest.NewServer()
+	c := s.NewClient()
+	defer c.Close()
+
+	kv := make(map[string][]byte)
+	keys := make([]string, 0, 50)
+	for i := 0; i < 50; i++ {
+		k := fmt.Sprintf("many%d", i)
+		kv[k] = []byte(fmt.Sprintf("v%d", i))
+		keys = append(keys, k)
+	}
+	if errs := c.SetMany(kv, k2hdkc.WithConcurrency(3)); errs != nil {
+		t.Fatalf("c.SetMany() = %v", errs)
+	}
+	vals, missing, errs := c.GetMany(append(keys, "nokey"), k2hdkc.WithConcurrency(3))
+	if len(errs) != 0 || len(vals) != len(kv) {
+		t.Fatalf("c.GetMany() = %v values, %v", len(vals), errs)
+	}
+	if !reflect.DeepEqual(missing, []string{"nokey"}) {
+		t.Errorf("missing = %v, want [nokey]", missing)
+	}
+	for k, v := range kv {
+		if string(vals[k]) != string(v) {
+			t.Errorf("vals[%v] = %q, want %q", k, vals[k], v)
+		}
+	}
+	if _, ok := vals["nokey"]; ok {
+		t.Errorf("vals holds the missing key")
+	}
+}
+
+// TestGetManyErrors tests GetMany reports failures apart from missing keys.
+func TestGetManyErrors(t *testing.T) {
+	in := k2hdkctest.NewInjector(k2hdkctest.NewServer())
+	in.Add(k2hdkctest.Fault{Op: k2hdkc.OpGet, Key: regexp.MustCompile("^bad"), Fail: true, SubCode: k2hdkc.SubCodeInternal})
+	c := in.NewClient()
+	defer c.Close()
+
+	if err := c.SetValue("good", []byte("v")); err != nil {
+		t.Fatalf("c.SetValue(good) = %v", err)
+	}
+	// a key read as no data is missing, not failed.
+	in.Add(k2hdkctest.Fault{Op: k2hdkc.OpGet, Key: regexp.MustCompile("^gone"), Fail: true, SubCode: k2hdkc.SubCodeNoData})
+	if err := c.SetValue("gone", []byte("v")); err != nil {
+		t.Fatalf("c.SetValue(gone) = %v", err)
+	}
+	vals, missing, errs := c.GetMany([]string{"good", "bad", "missing", "gone"})
+	if len(vals) != 1 || string(vals["good"]) != "v" {
+		t.Errorf("vals = %v, want good only", vals)
+	}
+	if !reflect.DeepEqual(missing, []string{"missing", "gone"}) {
+		t.Errorf("missing = %v, want [missing gone]", missing)
+	}
+	if len(errs) != 1 || !errors.Is(errs["bad"], k2hdkc.ErrInternal) {
+		t.Errorf("errs = %v, want bad only", errs)
+	}
+
+	in.Add(k2hdkctest.Fault{Op: k2hdkc.OpSet, Key: regexp.MustCompile("^bad"), Fail: true, SubCode: k2hdkc.SubCodeInternal})
+	errs = c.SetMany(map[string][]byte{"good": []byte("w"), "bad": []byte("w")})
+	if len(errs) != 1 || !errors.Is(errs["bad"], k2hdkc.ErrInternal) {
+		t.Errorf("c.SetMany() = %v, want bad only", errs)
+	}
+}
+
+// Local Variables:
+// c-basic-offset: 4
+// tab-width: 4
+// indent-tabs-mode: t
+// End:
+// vim600: noexpandtab sw=4 ts=4 fdm=marker
+// vim<600: noexpandtab sw=4 ts=4
@@ -0,0 +1,309 @@
+//
+// k2hdkc_go
+//
+// Copyright 2018 Yahoo Japan Corporation.
+//
+// Go driver for k2hdkc that is a highly available and scalable distributed
+// KVS clustering system. For k2hdkc, see
+// https://github.com/yahoojapan/k2hdkc for the details.
+//
+// For the full copyright and license information, please view
+// the license file that was distributed with this source code.
+//
+// AUTHOR:   Hirotaka Wakabayashi
+// CREATE:   Fri, 16 Oct 2026
+// REVISION:
+//
+
+package k2hdkc
+
+import (
+	"errors"
+	"expvar"
+	"fmt"
+	"io"
+	"net/http"
+	"reflect"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// MetricsBuckets are the upper bounds in seconds of the latency histogram.
+var MetricsBuckets = []float64{0.0005, 0.001, 0.0025, 0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+// Metrics counts the commands which clients execute and the sessions they open.
+// Each execution of a command on a session is counted, so a retried command is counted
+// for each attempt. A Metrics is safe for concurrent use and can be shared by clients.
+type Metrics struct {
+	mu           sync.Mutex
+	commands     map[string]*commandMetrics // by the command type.
+	errors       map[errorKey]int64         // by the command type and the result codes.
+	opened       int64
+	closed       int64
+	openFailures int64
+}
+
+// commandMetrics holds the counts of a command type.
+type commandMetrics struct {
+	count   int64
+	buckets []int64 // counts of the executions not longer than MetricsBuckets.
+	sum     float64 // seconds
+}
+
+// errorKey is a label set of the errors.
+type errorKey struct {
+	command string
+	code    string
+	subCode string
+}
+
+// NewMetrics returns the pointer to an empty Metrics.
+func NewMetrics() *Metrics {
+	return &Metrics{
+		commands: make(map[string]*commandMetrics),
+		errors:   make(map[errorKey]int64),
+	}
+}
+
+// String returns a text representation of the object.
+func (m *Metrics) String() string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return fmt.Sprintf("[%v, %v, %v, %v, %v]", len(m.commands), len(m.errors), m.opened, m.closed, m.openFailures)
+}
+
+// SetMetrics sets the Metrics which counts the commands and the sessions of the client.
+// nil, the default, disables the metrics.
+func (c *Client) SetMetrics(m *Metrics) *Client {
+	c.metrics = m
+	return c
+}
+
+// commandName returns the type name of the command, such as Get.
+func commandName(cmd Command) string {
+	t := reflect.TypeOf(cmd)
+	if t == nil {
+		return ""
+	}
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return t.Name()
+}
+
+// errorCodes returns the result code and the sub code of err as labels.
+func errorCodes(err error) (string, string) {
+	var re *ResError
+	switch {
+	case errors.As(err, &re):
+		return re.Code.String(), re.SubCode.String()
+	case isContextError(err):
+		return "", "context"
+	default:
+		return "", "other"
+	}
+}
+
+// observe counts an execution of the command.
+func (m *Metrics) observe(cmd Command, d time.Duration, ok bool, err error) {
+	if m == nil {
+		return
+	}
+	name := commandName(cmd)
+	sec := d.Seconds()
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	cm := m.commands[name]
+	if cm == nil {
+		cm = &commandMetrics{buckets: make([]int64, len(MetricsBuckets))}
+		m.commands[name] = cm
+	}
+	cm.count++
+	cm.sum += sec
+	for i, b := range MetricsBuckets {
+		if sec <= b {
+			cm.buckets[i]++
+		}
+	}
+	if !ok {
+		code, sub := errorCodes(err)
+		m.errors[errorKey{name, code, sub}]++
+	}
+}
+
+// sessionOpened counts a session opened or failed to open.
+func (m *Metrics) sessionOpened(err error) {
+	if m == nil {
+		return
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if err != nil {
+		m.openFailures++
+	} else {
+		m.opened++
+	}
+}
+
+// sessionClosed counts a session closed.
+func (m *Metrics) sessionClosed() {
+	if m == nil {
+		return
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.closed++
+}
+
+// Snapshot returns the current values in a map which encodes into JSON.
+func (m *Metrics) Snapshot() map[string]interface{} {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	commands := make(map[string]interface{}, len(m.commands))
+	for name, cm := range m.commands {
+		buckets := make(map[string]int64, len(MetricsBuckets))
+		for i, b := range MetricsBuckets {
+			buckets[strconv.FormatFloat(b, 'g', -1, 64)] = cm.buckets[i]
+		}
+		commands[name] = map[string]interface{}{
+			"count":           cm.count,
+			"latency_sum":     cm.sum,
+			"latency_buckets": buckets,
+		}
+	}
+	errs := make(map[string]int64, len(m.errors))
+	for k, n := range m.errors {
+		errs[k.command+" "+k.code+" "+k.subCode] = n
+	}
+	return map[string]interface{}{
+		"commands": commands,
+		"errors":   errs,
+		"sessions": map[string]int64{
+			"opened":        m.opened,
+			"closed":        m.closed,
+			"open_failures": m.openFailures,
+		},
+	}
+}
+
+// Publish exports the metrics as an expvar variable of the name.
+// It panics if the name is already used, as expvar.Publish does.
+func (m *Metrics) Publish(name string) {
+	expvar.Publish(name, expvar.Func(func() interface{} {
+		return m.Snapshot()
+	}))
+}
+
+// Handler returns an http.Handler which writes the metrics in the Prometheus text exposition format.
+func (m *Metrics) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		m.WriteTo(w)
+	})
+}
+
+// clone returns a copy of the counters, which is read without the lock.
+func (m *Metrics) clone() *Metrics {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	c := &Metrics{
+		commands:     make(map[string]*commandMetrics, len(m.commands)),
+		errors:       make(map[errorKey]int64, len(m.errors)),
+		opened:       m.opened,
+		closed:       m.closed,
+		openFailures: m.openFailures,
+	}
+	for name, cm := range m.commands {
+		c.commands[name] = &commandMetrics{count: cm.count, buckets: append([]int64(nil), cm.buckets...), sum: cm.sum}
+	}
+	for k, n := range m.errors {
+		c.errors[k] = n
+	}
+	return c
+}
+
+// WriteTo writes the metrics in the Prometheus text exposition format.
+// The counters are copied first, so a slow writer doesn't block the commands.
+func (m *Metrics) WriteTo(w io.Writer) (int64, error) {
+	m = m.clone()
+	pw := &promWriter{w: w}
+
+	names := make([]string, 0, len(m.commands))
+	for name := range m.commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	pw.header("k2hdkc_commands_total", "counter", "Number of commands executed on sessions.")
+	for _, name := range names {
+		pw.printf("k2hdkc_commands_total{command=%q} %d\n", name, m.commands[name].count)
+	}
+	pw.header("k2hdkc_command_duration_seconds", "histogram", "Latency of commands executed on sessions.")
+	for _, name := range names {
+		cm := m.commands[name]
+		for i, b := range MetricsBuckets {
+			pw.printf("k2hdkc_command_duration_seconds_bucket{command=%q,le=%q} %d\n", name, strconv.FormatFloat(b, 'g', -1, 64), cm.buckets[i])
+		}
+		pw.printf("k2hdkc_command_duration_seconds_bucket{command=%q,le=\"+Inf\"} %d\n", name, cm.count)
+		pw.printf("k2hdkc_command_duration_seconds_sum{command=%q} %v\n", name, cm.sum)
+		pw.printf("k2hdkc_command_duration_seconds_count{command=%q} %d\n", name, cm.count)
+	}
+
+	keys := make([]errorKey, 0, len(m.errors))
+	for k := range m.errors {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		a, b := keys[i], keys[j]
+		if a.command != b.command {
+			return a.command < b.command
+		}
+		if a.code != b.code {
+			return a.code < b.code
+		}
+		return a.subCode < b.subCode
+	})
+	pw.header("k2hdkc_command_errors_total", "counter", "Number of failed commands by the result code.")
+	for _, k := range keys {
+		pw.printf("k2hdkc_command_errors_total{command=%q,code=%q,subcode=%q} %d\n", k.command, k.code, k.subCode, m.errors[k])
+	}
+
+	pw.header("k2hdkc_sessions_opened_total", "counter", "Number of sessions opened.")
+	pw.printf("k2hdkc_sessions_opened_total %d\n", m.opened)
+	pw.header("k2hdkc_sessions_closed_total", "counter", "Number of sessions closed.")
+	pw.printf("k2hdkc_sessions_closed_total %d\n", m.closed)
+	pw.header("k2hdkc_session_open_failures_total", "counter", "Number of sessions failed to open.")
+	pw.printf("k2hdkc_session_open_failures_total %d\n", m.openFailures)
+	return pw.n, pw.err
+}
+
+// promWriter writes lines and keeps the first error.
+type promWriter struct {
+	w   io.Writer
+	n   int64
+	err error
+}
+
+// header writes the HELP and TYPE lines of a metric.
+func (pw *promWriter) header(name, typ, help string) {
+	pw.printf("# HELP %s %s\n# TYPE %s %s\n", name, help, name, typ)
+}
+
+// printf writes a formatted line unless an error has happened.
+func (pw *promWriter) printf(format string, v ...interface{}) {
+	if pw.err != nil {
+		return
+	}
+	n, err := fmt.Fprintf(pw.w, format, v...)
+	pw.n += int64(n)
+	pw.err = err
+}
+
+// Local Variables:
+// c-basic-offset: 4
+// tab-width: 4
+// indent-tabs-mode: t
+// End:
+// vim600: noexpandtab sw=4 ts=4 fdm=marker
+// vim<600: noexpandtab sw=4 ts=4
@@ -0,0 +1,148 @@
+//
+// k2hdkc_go
+//
+// Copyright 2018 Yahoo Japan Corporation.
+//
+// Go driver for k2hdkc that is a highly available and scalable distributed
+// KVS clustering system. For k2hdkc, see
+// https://github.com/yahoojapan/k2hdkc for the details.
+//
+// For the full copyright and license information, please view
+// the license file that was distributed with this source code.
+//
+// AUTHOR:   Hirotaka Wakabayashi
+// CREATE:   Fri, 16 Oct 2026
+// REVISION:
+//
+
+package k2hdkc_test
+
+import (
+	"encoding/json"
+	"expvar"
+	"fmt"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/yahoojapan/k2hdkc_go/k2hdkc"
+	"github.com/yahoojapan/k2hdkc_go/k2hdkc/k2hdkctest"
+)
+
+// published counts the expvar variables the tests have published. expvar can't
+// publish a name twice even if the test runs again with -count.
+var published int32
+
+// TestMetrics tests commands, errors and sessions are counted and exposed.
+func TestMetrics(t *testing.T) {
+	in := k2hdkctest.NewInjector(k2hdkctest.NewServer())
+	m := k2hdkc.NewMetrics()
+	c := in.NewClient().SetMetrics(m)
+
+	for i := 0; i < 3; i++ {
+		if err := c.SetValue("metrics", []byte("v")); err != nil {
+			t.Fatalf("c.SetValue(metrics) = %v", err)
+		}
+	}
+	in.Add(k2hdkctest.Fault{Op: k2hdkc.OpGet, Times: 1, Fail: true, SubCode: k2hdkc.SubCodeTimeout})
+	c.GetValue("metrics")
+	c.GetValue("metrics")
+	c.Close()
+
+	rec := httptest.NewRecorder()
+	m.Handler().ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+	body := rec.Body.String()
+	if ct := rec.Header().Get("Content-Type"); !strings.HasPrefix(ct, "text/plain; version=0.0.4") {
+		t.Errorf("Content-Type = %v", ct)
+	}
+	for _, want := range []string{
+		"# TYPE k2hdkc_commands_total counter\n",
+		`k2hdkc_commands_total{command="Get"} 2` + "\n",
+		`k2hdkc_commands_total{command="Set"} 3` + "\n",
+		"# TYPE k2hdkc_command_duration_seconds histogram\n",
+		`k2hdkc_command_duration_seconds_bucket{command="Set",le="+Inf"} 3` + "\n",
+		`k2hdkc_command_duration_seconds_count{command="Get"} 2` + "\n",
+		`k2hdkc_command_errors_total{command="Get",code="DKC_RES_ERROR",subcode="DKC_RES_SUBCODE_TIMEOUT"} 1` + "\n",
+		"k2hdkc_sessions_opened_total ",
+		"k2hdkc_session_open_failures_total 0\n",
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("the exposition has no %q\n%v", want, body)
+		}
+	}
+	if strings.Contains(body, `errors_total{command="Set"`) {
+		t.Errorf("the exposition has errors of Set\n%v", body)
+	}
+
+	name := fmt.Sprintf("k2hdkc_test%d", atomic.AddInt32(&published, 1))
+	m.Publish(name)
+	var snap struct {
+		Commands map[string]struct {
+			Count int64 `json:"count"`
+		} `json:"commands"`
+		Sessions map[string]int64 `json:"sessions"`
+	}
+	if err := json.Unmarshal([]byte(expvar.Get(name).String()), &snap); err != nil {
+		t.Fatalf("json.Unmarshal(expvar) = %v", err)
+	}
+	if snap.Commands["Set"].Count != 3 || snap.Sessions["opened"] == 0 || snap.Sessions["opened"] != snap.Sessions["closed"] {
+		t.Errorf("the expvar snapshot = %+v", snap)
+	}
+}
+
+// blockingWriter blocks the first write until release is closed.
+type blockingWriter struct {
+	writing chan struct{}
+	release chan struct{}
+	once    sync.Once
+}
+
+func (w *blockingWriter) Write(p []byte) (int, error) {
+	w.once.Do(func() {
+		close(w.writing)
+		<-w.release
+	})
+	return len(p), nil
+}
+
+// TestMetricsSlowWriter tests a slow writer of the exposition doesn't block commands.
+func TestMetricsSlowWriter(t *testing.T) {
+	s := k2hdkctest.NewServer()
+	m := k2hdkc.NewMetrics()
+	c := s.NewClient().SetMetrics(m)
+	defer c.Close()
+
+	if err := c.SetValue("metrics", []byte("v")); err != nil {
+		t.Fatalf("c.SetValue(metrics) = %v", err)
+	}
+	w := &blockingWriter{writing: make(chan struct{}), release: make(chan struct{})}
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		m.WriteTo(w)
+	}()
+	<-w.writing
+	set := make(chan error, 1)
+	go func() { set <- c.SetValue("metrics", []byte("w")) }()
+	select {
+	case err := <-set:
+		if err != nil {
+			t.Errorf("c.SetValue(metrics) while writing = %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Errorf("c.SetValue(metrics) is blocked by the writer")
+	}
+	close(w.release)
+	<-done
+}
+
+// Local Variables:
+// c-basic-offset: 4
+// tab-width: 4
+// indent-tabs-mode: t
+// End:
+// vim600: noexpandtab sw=4 ts=4 fdm=marker
+// vim<600: noexpandtab sw=4 ts=4
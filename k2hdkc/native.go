@@ -0,0 +1,217 @@
+//
+// k2hdkc_go
+//
+// Copyright 2018 Yahoo Japan Corporation.
+//
+// Go driver for k2hdkc that is a highly available and scalable distributed
+// KVS clustering system. For k2hdkc, see
+// https://github.com/yahoojapan/k2hdkc for the details.
+//
+// For the full copyright and license information, please view
+// the license file that was distributed with this source code.
+//
+// AUTHOR:   Hirotaka Wakabayashi
+// CREATE:   Fri, 16 Oct 2026
+// REVISION:
+//
+
+//go:build cgo && !nok2hdkc
+// +build cgo,!nok2hdkc
+
+package k2hdkc
+
+/*
+#cgo CFLAGS: -g -O2 -Wall -Wextra -Wno-unused-variable -Wno-unused-parameter -I. -I/usr/include/k2hdkc
+#cgo LDFLAGS: -L/usr/lib -lk2hdkc
+#cgo linux LDFLAGS: -ldl
+#include <dlfcn.h>
+#include <stdio.h>
+#include <stdlib.h>
+#include "k2hdkc.h"
+#include "k2hmacro.h"
+static int dlopen_k2hdkc() {
+  dlerror();
+  void* handler = dlopen("libk2hdkc.so", RTLD_LAZY);
+  if (handler == NULL) {
+    char* error = dlerror();
+    if (error != NULL) {
+      fprintf(stderr, "dlerror() %s\n", error);
+      return -1;
+    }
+  }
+  dlclose(handler);
+  return 0;
+}
+*/
+import "C"
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"runtime"
+	"unsafe"
+)
+
+// init checks if OS supports epoll system call and alignment of 64 bit data in memory is on little endian.
+func init() {
+	if runtime.GOOS != "linux" && runtime.GOARCH != "amd64" {
+		fmt.Fprintf(os.Stderr, "k2hdkc currently works on linux only")
+		unSupportedOs = true
+	}
+	i := uint32(1)
+	b := (*[4]byte)(unsafe.Pointer(&i))
+	if b[0] != 1 {
+		fmt.Fprintf(os.Stderr, "k2hdkc_go currently works on little endian alignment only")
+		unSupportedEndian = true
+	}
+	// rpm or deb install the k2hdkc.so in /usr/lib.
+	if C.dlopen_k2hdkc() < 0 {
+		fmt.Fprintf(os.Stderr, "Please install the k2hdkc package at first")
+		isNotExistLibK2hdkc = true
+	}
+	defaultBackend = nativeBackend{}
+}
+
+// nativeBackend opens chmpx handlers with the k2hdkc C library.
+type nativeBackend struct{}
+
+// Open opens a chmpx handler with the configurations of the client.
+func (nativeBackend) Open(c *Client) (Conn, error) {
+	if unSupportedOs {
+		return nil, errors.New("k2hdkc currently works on linux only")
+	}
+	if unSupportedEndian {
+		return nil, errors.New("k2hdkc_go currently works on little endian alignment only")
+	}
+	if isNotExistLibK2hdkc {
+		return nil, errors.New("Please install the k2hdkc package at first")
+	}
+	file := C.CString(c.file)
+	defer C.free(unsafe.Pointer(file))
+	cuk := C.CString(c.cuk)
+	defer C.free(unsafe.Pointer(cuk))
+	handler := C.k2hdkc_open_chmpx_full(file, C.short(c.port), cuk, C._Bool(c.rejoin), C._Bool(c.rejoinRetry), C._Bool(c.cleanup))
+	if handler == C.K2HDKC_INVALID_HANDLE {
+		return nil, &OpenError{Err: fmt.Errorf("k2hdkc_open_chmpx_ex() = %v", handler)}
+	}
+	return &nativeConn{handler: handler, cleanup: c.cleanup}, nil
+}
+
+// nativeConn holds a chmpx handler.
+type nativeConn struct {
+	handler C.k2hdkc_chmpx_h // uint64_t
+	cleanup bool
+}
+
+// String returns a text representation of the object.
+func (c *nativeConn) String() string {
+	return fmt.Sprintf("[%v, %v]", c.handler, c.cleanup)
+}
+
+// Close closes the chmpx handler.
+func (c *nativeConn) Close() error {
+	if c.handler == C.K2HDKC_INVALID_HANDLE {
+		return nil
+	}
+	result := C.k2hdkc_close_chmpx_ex(c.handler, C._Bool(c.cleanup))
+	c.handler = C.K2HDKC_INVALID_HANDLE
+	if !result {
+		return fmt.Errorf("C.k2hdkc_close_chmpx_ex() = %v", result)
+	}
+	return nil
+}
+
+// result sets ok and the response codes of the last call on the handler to the response.
+func (c *nativeConn) result(res *Response, ok C._Bool) *Response {
+	code := C.k2hdkc_get_res_code(c.handler)
+	subCode := C.k2hdkc_get_res_subcode(c.handler)
+	res.OK = (bool)(ok)
+	res.Code = toResCode(code)
+	res.SubCode = toSubCode(subCode)
+	res.RawCode = uint64(code)
+	res.RawSubCode = uint64(subCode)
+	return res
+}
+
+// toResCode converts the result code of the library to a ResCode.
+func toResCode(code C.dkcres_type_t) ResCode {
+	switch code {
+	case C.DKC_RES_SUCCESS:
+		return ResCodeSuccess
+	case C.DKC_RES_ERROR:
+		return ResCodeError
+	default:
+		return ResCodeUnknown
+	}
+}
+
+// toSubCode converts the sub code of the library to a SubCode.
+func toSubCode(subCode C.dkcres_type_t) SubCode {
+	switch subCode {
+	case C.DKC_RES_SUBCODE_NOTHING:
+		return SubCodeNothing
+	case C.DKC_RES_SUBCODE_NODATA:
+		return SubCodeNoData
+	case C.DKC_RES_SUBCODE_NOSUBKEY:
+		return SubCodeNoSubKey
+	case C.DKC_RES_SUBCODE_INVAL:
+		return SubCodeInvalid
+	case C.DKC_RES_SUBCODE_NOMEM:
+		return SubCodeNoMemory
+	case C.DKC_RES_SUBCODE_INTERNAL:
+		return SubCodeInternal
+	case C.DKC_RES_SUBCODE_NOSERVER:
+		return SubCodeNoServer
+	case C.DKC_RES_SUBCODE_SENDERR:
+		return SubCodeSend
+	case C.DKC_RES_SUBCODE_RCVERR:
+		return SubCodeReceive
+	case C.DKC_RES_SUBCODE_TIMEOUT:
+		return SubCodeTimeout
+	case C.DKC_RES_SUBCODE_DIFFVAL:
+		return SubCodeCasMismatch
+	case C.DKC_RES_SUBCODE_ALREADYEXIST:
+		return SubCodeExists
+	default:
+		return SubCodeUnknown
+	}
+}
+
+// expirePointer returns the pointer of the expire, or nil if the expire is zero.
+func expirePointer(expire *int64) *C.time_t {
+	// WARNING: You can't set zero expire.
+	if *expire == 0 {
+		return nil
+	}
+	return (*C.time_t)(expire)
+}
+
+// newKeyPack returns a C array of the keys and a function to free it.
+func newKeyPack(keys [][]byte) (C.PK2HDKCKEYPCK, func()) {
+	if len(keys) == 0 {
+		return nil, func() {}
+	}
+	length := len(keys)
+	pack := (C.PK2HDKCKEYPCK)(C.malloc(C.size_t(length) * C.size_t(unsafe.Sizeof(C.K2HKEYPCK{}))))
+	// See https://github.com/golang/go/wiki/cgo#turning-c-arrays-into-go-slices
+	cslice := (*[1 << 30]C.K2HKEYPCK)(unsafe.Pointer(pack))[:length:length]
+	for i, key := range keys {
+		cslice[i].pkey = (*C.uchar)(C.CBytes(key))
+		cslice[i].length = C.size_t(len(key))
+	}
+	return pack, func() {
+		for i := range cslice {
+			C.free(unsafe.Pointer(cslice[i].pkey))
+		}
+		C.free(unsafe.Pointer(pack))
+	}
+}
+
+// Local Variables:
+// c-basic-offset: 4
+// tab-width: 4
+// indent-tabs-mode: t
+// End:
+// vim600: noexpandtab sw=4 ts=4 fdm=marker
+// vim<600: noexpandtab sw=4 ts=4
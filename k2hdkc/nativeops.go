@@ -0,0 +1,569 @@
+//
+// k2hdkc_go
+//
+// Copyright 2018 Yahoo Japan Corporation.
+//
+// Go driver for k2hdkc that is a highly available and scalable distributed
+// KVS clustering system. For k2hdkc, see
+// https://github.com/yahoojapan/k2hdkc for the details.
+//
+// For the full copyright and license information, please view
+// the license file that was distributed with this source code.
+//
+// AUTHOR:   Hirotaka Wakabayashi
+// CREATE:   Fri, 16 Oct 2026
+// REVISION:
+//
+
+//go:build cgo && !nok2hdkc
+// +build cgo,!nok2hdkc
+
+package k2hdkc
+
+import (
+	// #cgo CFLAGS: -g -O2 -Wall -Wextra -Wno-unused-variable -Wno-unused-parameter -I. -I/usr/include/k2hdkc
+	// #cgo LDFLAGS: -L/usr/lib -lk2hdkc
+	// #include <stdlib.h>
+	// #include "k2hdkc.h"
+	// #include "k2hmacro.h"
+	"C"
+)
+
+import (
+	"unsafe"
+)
+
+// Do calls the k2hdkc C API of the operation.
+func (c *nativeConn) Do(req *Request) *Response {
+	switch req.Op {
+	case OpGet:
+		return c.get(req)
+	case OpSet:
+		return c.set(req)
+	case OpRemove:
+		return c.remove(req)
+	case OpRename:
+		return c.rename(req)
+	case OpGetSubKeys:
+		return c.getSubKeys(req)
+	case OpSetSubKeys, OpClearSubKeys:
+		return c.setSubKeys(req)
+	case OpAddSubKey:
+		return c.addSubKey(req)
+	case OpRemoveSubKey:
+		return c.removeSubKey(req)
+	case OpSetAll:
+		return c.setAll(req)
+	case OpGetAttrs:
+		return c.getAttrs(req)
+	case OpCasInit:
+		return c.casInit(req)
+	case OpCasGet:
+		return c.casGet(req)
+	case OpCasSet:
+		return c.casSet(req)
+	case OpCasIncrement, OpCasDecrement:
+		return c.casIncDec(req)
+	case OpQueuePush:
+		return c.queuePush(req)
+	case OpQueuePop:
+		return c.queuePop(req)
+	case OpQueueRemove:
+		return c.queueRemove(req)
+	}
+	return &Response{OK: false, Code: ResCodeError, SubCode: SubCodeInvalid}
+}
+
+// get calls the C.k2hdkc_pm_get_value_wp function which is the lowest C API.
+func (c *nativeConn) get(req *Request) *Response {
+	cKey := C.CBytes(req.Key) // func C.CBytes([]byte) unsafe.Pointer
+	defer C.free(cKey)
+	cPass := C.CString(req.Pass) // func C.CString(string) *C.char
+	defer C.free(unsafe.Pointer(cPass))
+
+	var cRetValue *C.uchar
+	var valLen C.size_t
+	ok := C.k2hdkc_pm_get_value_wp(
+		c.handler,
+		(*C.uchar)(cKey),
+		C.size_t(len(req.Key)),
+		cPass,
+		&cRetValue,
+		&valLen)
+	defer C.free(unsafe.Pointer(cRetValue))
+	res := c.result(&Response{}, ok)
+	if res.OK {
+		res.Val = C.GoBytes(unsafe.Pointer(cRetValue), C.int(valLen))
+	}
+	return res
+}
+
+// set calls the C.k2hdkc_pm_set_value_wa function.
+func (c *nativeConn) set(req *Request) *Response {
+	cKey := C.CBytes(req.Key)
+	defer C.free(cKey)
+	cVal := C.CBytes(req.Val)
+	defer C.free(cVal)
+	cPass := C.CString(req.Pass)
+	defer C.free(unsafe.Pointer(cPass))
+	expire := req.Expire
+	ok := C.k2hdkc_pm_set_value_wa(
+		c.handler,
+		(*C.uchar)(cKey),
+		C.size_t(len(req.Key)),
+		(*C.uchar)(cVal),
+		C.size_t(len(req.Val)),
+		C._Bool(req.RmSubKeyList),
+		cPass,
+		expirePointer(&expire))
+	return c.result(&Response{}, ok)
+}
+
+// remove calls the C.k2hdkc_pm_remove function which is the lowest C API.
+func (c *nativeConn) remove(req *Request) *Response {
+	cKey := C.CBytes(req.Key)
+	defer C.free(cKey)
+
+	// bool k2hdkc_pm_remove(k2hdkc_chmpx_h handle, const unsigned char* pkey, size_t keylength)
+	ok := C.k2hdkc_pm_remove(
+		c.handler,
+		(*C.uchar)(cKey),
+		C.size_t(len(req.Key)))
+	return c.result(&Response{}, ok)
+}
+
+// rename calls the C.k2hdkc_pm_rename_with_parent_wa function.
+func (c *nativeConn) rename(req *Request) *Response {
+	cOldKey := C.CBytes(req.Key)
+	defer C.free(cOldKey)
+	cNewKey := C.CBytes(req.NewKey)
+	defer C.free(cNewKey)
+	// parent(default is nil) is optional(null is acceptable for the k2hdkc C API).
+	// For cgo, Go nil is equal to C NULL.
+	cParentKey := C.CBytes(req.ParentKey)
+	defer C.free(cParentKey)
+	// pass(default is nil) is optional(null is acceptable for the k2hdkc C API).
+	// For cgo, Go nil is equal to C NULL.
+	cPass := C.CString(req.Pass)
+	defer C.free(unsafe.Pointer(cPass))
+	expire := req.Expire
+	ok := C.k2hdkc_pm_rename_with_parent_wa(
+		c.handler,
+		(*C.uchar)(cOldKey),
+		C.size_t(len(req.Key)),
+		(*C.uchar)(cNewKey),
+		C.size_t(len(req.NewKey)),
+		(*C.uchar)(cParentKey),
+		C.size_t(len(req.ParentKey)),
+		C._Bool(req.Attr),
+		cPass,
+		expirePointer(&expire))
+	return c.result(&Response{}, ok)
+}
+
+// getSubKeys calls the C.k2hdkc_pm_get_subkeys function.
+func (c *nativeConn) getSubKeys(req *Request) *Response {
+	cKey := C.CBytes(req.Key)
+	defer C.free(cKey)
+	var keypack C.PK2HDKCKEYPCK
+	var keypackLen C.int
+
+	ok := C.k2hdkc_pm_get_subkeys(
+		c.handler,
+		(*C.uchar)(cKey),
+		C.size_t(len(req.Key)),
+		&keypack,
+		&keypackLen,
+	)
+	defer C.dkc_free_keypack(keypack, keypackLen)
+	res := c.result(&Response{}, ok)
+	if !res.OK || keypackLen == 0 {
+		return res
+	}
+
+	// See https://github.com/golang/go/wiki/cgo#turning-c-arrays-into-go-slices
+	var theCArray C.PK2HDKCKEYPCK = keypack
+	length := (int)(keypackLen)
+	cslice := (*[1 << 30]C.K2HKEYPCK)(unsafe.Pointer(theCArray))[:length:length]
+	res.SubKeys = make([][]byte, length) // copy
+	for i, data := range cslice {
+		res.SubKeys[i] = C.GoBytes(unsafe.Pointer(data.pkey), (C.int)(data.length))
+	}
+	return res
+}
+
+// setSubKeys calls the C.k2hdkc_pm_set_subkeys function. The subkey list is cleared if req.SubKeys is empty.
+func (c *nativeConn) setSubKeys(req *Request) *Response {
+	cKey := C.CBytes(req.Key)
+	defer C.free(cKey)
+	pack, free := newKeyPack(req.SubKeys)
+	defer free()
+
+	ok := C.k2hdkc_pm_set_subkeys(
+		c.handler,
+		(*C.uchar)(cKey),
+		(C.size_t)(len(req.Key)),
+		pack,
+		(C.int)(len(req.SubKeys)))
+	return c.result(&Response{}, ok)
+}
+
+// addSubKey calls the C.k2hdkc_pm_set_subkey_wa function.
+func (c *nativeConn) addSubKey(req *Request) *Response {
+	cKey := C.CBytes(req.Key)
+	defer C.free(cKey)
+	cSkey := C.CBytes(req.SubKey)
+	defer C.free(cSkey)
+	cSval := C.CBytes(req.Val)
+	defer C.free(cSval)
+	cPass := C.CString(req.Pass)
+	defer C.free(unsafe.Pointer(cPass))
+	expire := req.Expire
+	ok := C.k2hdkc_pm_set_subkey_wa(
+		c.handler,
+		(*C.uchar)(cKey),
+		(C.size_t)(len(req.Key)),
+		(*C.uchar)(cSkey),
+		(C.size_t)(len(req.SubKey)),
+		(*C.uchar)(cSval),
+		(C.size_t)(len(req.Val)),
+		(C._Bool)(req.Attr),
+		cPass,
+		expirePointer(&expire))
+	return c.result(&Response{}, ok)
+}
+
+// removeSubKey calls the C.k2hdkc_pm_remove_subkey function.
+func (c *nativeConn) removeSubKey(req *Request) *Response {
+	cKey := C.CBytes(req.Key)
+	defer C.free(cKey)
+	cSkey := C.CBytes(req.SubKey)
+	defer C.free(cSkey)
+
+	ok := C.k2hdkc_pm_remove_subkey(
+		c.handler,
+		(*C.uchar)(cKey),
+		(C.size_t)(len(req.Key)),
+		(*C.uchar)(cSkey),
+		(C.size_t)(len(req.SubKey)),
+		(C._Bool)(req.Nest))
+	return c.result(&Response{}, ok)
+}
+
+// setAll calls the C.k2hdkc_pm_set_all_wa function.
+func (c *nativeConn) setAll(req *Request) *Response {
+	pack, free := newKeyPack(req.SubKeys)
+	defer free()
+	cKey := C.CBytes(req.Key)
+	defer C.free(cKey)
+	cVal := C.CBytes(req.Val)
+	defer C.free(cVal)
+	// pass(default is nil) is optional. Go nil is eqaul to C NULL.
+	// The pass argment of NULL is acceptable for the k2hdkc C API.
+	cPass := C.CString(req.Pass)
+	defer C.free(unsafe.Pointer(cPass))
+	expire := req.Expire
+	ok := C.k2hdkc_pm_set_all_wa(
+		c.handler,
+		(*C.uchar)(cKey),
+		(C.size_t)(len(req.Key)),
+		(*C.uchar)(cVal),
+		(C.size_t)(len(req.Val)),
+		pack,
+		(C.int)(len(req.SubKeys)),
+		cPass,
+		expirePointer(&expire))
+	return c.result(&Response{}, ok)
+}
+
+// getAttrs calls the C.k2hdkc_pm_get_attrs function.
+func (c *nativeConn) getAttrs(req *Request) *Response {
+	cKey := C.CBytes(req.Key)
+	defer C.free(cKey)
+	var attrpack C.PK2HDKCATTRPCK
+	var attrpackLen C.int
+	ok := C.k2hdkc_pm_get_attrs(
+		c.handler,
+		(*C.uchar)(cKey),
+		C.size_t(len(req.Key)),
+		&attrpack,
+		&attrpackLen,
+	)
+	defer C.dkc_free_attrpack(attrpack, attrpackLen)
+	res := c.result(&Response{}, ok)
+	if !res.OK || attrpackLen == 0 {
+		return res
+	}
+	/*
+	   typedef struct k2h_attr_pack{
+	   	unsigned char*pkey;
+	   	size_t keylength;
+	   	unsigned char*pval;
+	   	size_t vallength;
+	   }K2HATTRPCK, *PK2HATTRPCK;
+	*/
+	// See https://github.com/golang/go/wiki/cgo#turning-c-arrays-into-go-slices
+	var theCArray C.PK2HDKCATTRPCK = attrpack
+	length := (int)(attrpackLen)
+	cslice := (*[1 << 30]C.K2HATTRPCK)(unsafe.Pointer(theCArray))[:length:length]
+	res.Attrs = make([]*Attr, length) // copy
+	for i, data := range cslice {
+		akey := C.GoBytes(unsafe.Pointer(data.pkey), (C.int)(data.keylength))
+		aval := C.GoBytes(unsafe.Pointer(data.pval), (C.int)(data.vallength))
+		res.Attrs[i] = NewAttr(akey, aval)
+	}
+	return res
+}
+
+// casInit calls the C.k2hdkc_pm_cas{8,16,32,64}_init_wa function.
+func (c *nativeConn) casInit(req *Request) *Response {
+	cKey := C.CBytes(req.Key)
+	defer C.free(cKey)
+	cPass := C.CString(req.Pass)
+	defer C.free(unsafe.Pointer(cPass))
+	expire := req.Expire
+	val := casUint64(req.Val)
+
+	var ok C._Bool
+	switch len(req.Val) {
+	default:
+		return &Response{OK: false, Code: ResCodeError, SubCode: SubCodeInvalid}
+	case 1:
+		// bool k2hdkc_pm_cas8_init_wa(k2hdkc_chmpx_h handle, const unsigned char* pkey, size_t keylength, uint8_t val, const char* encpass, const time_t* expire)
+		ok = C.k2hdkc_pm_cas8_init_wa(c.handler, (*C.uchar)(cKey), C.size_t(len(req.Key)), (C.uint8_t)(val), cPass, expirePointer(&expire))
+	case 2:
+		// bool k2hdkc_pm_cas16_init_wa(k2hdkc_chmpx_h handle, const unsigned char* pkey, size_t keylength, uint16_t val, const char* encpass, const time_t* expire)
+		ok = C.k2hdkc_pm_cas16_init_wa(c.handler, (*C.uchar)(cKey), C.size_t(len(req.Key)), (C.uint16_t)(val), cPass, expirePointer(&expire))
+	case 4:
+		// bool k2hdkc_pm_cas32_init_wa(k2hdkc_chmpx_h handle, const unsigned char* pkey, size_t keylength, uint32_t val, const char* encpass, const time_t* expire)
+		ok = C.k2hdkc_pm_cas32_init_wa(c.handler, (*C.uchar)(cKey), C.size_t(len(req.Key)), (C.uint32_t)(val), cPass, expirePointer(&expire))
+	case 8:
+		// bool k2hdkc_pm_cas64_init_wa(k2hdkc_chmpx_h handle, const unsigned char* pkey, size_t keylength, uint64_t val, const char* encpass, const time_t* expire)
+		ok = C.k2hdkc_pm_cas64_init_wa(c.handler, (*C.uchar)(cKey), C.size_t(len(req.Key)), (C.uint64_t)(val), cPass, expirePointer(&expire))
+	}
+	return c.result(&Response{}, ok)
+}
+
+// casGet calls the C.k2hdkc_pm_cas{8,16,32,64}_get_wa function.
+func (c *nativeConn) casGet(req *Request) *Response {
+	cKey := C.CBytes(req.Key)
+	defer C.free(cKey)
+	cPass := C.CString(req.Pass)
+	defer C.free(unsafe.Pointer(cPass))
+
+	var ok C._Bool
+	var val uint64
+	var vlen int
+	switch req.CasType {
+	default:
+		return &Response{OK: false, Code: ResCodeError, SubCode: SubCodeInvalid}
+	case CasType8:
+		// bool k2hdkc_pm_cas8_get_wa(k2hdkc_chmpx_h handle, const unsigned char* pkey, size_t keylength, const char* encpass, uint8_t* pval)
+		var cVal C.uint8_t
+		ok = C.k2hdkc_pm_cas8_get_wa(c.handler, (*C.uchar)(cKey), C.size_t(len(req.Key)), cPass, &cVal)
+		val, vlen = (uint64)(cVal), 1
+	case CasType16:
+		var cVal C.uint16_t
+		ok = C.k2hdkc_pm_cas16_get_wa(c.handler, (*C.uchar)(cKey), C.size_t(len(req.Key)), cPass, &cVal)
+		val, vlen = (uint64)(cVal), 2
+	case CasType32:
+		var cVal C.uint32_t
+		ok = C.k2hdkc_pm_cas32_get_wa(c.handler, (*C.uchar)(cKey), C.size_t(len(req.Key)), cPass, &cVal)
+		val, vlen = (uint64)(cVal), 4
+	case CasType64:
+		var cVal C.uint64_t
+		ok = C.k2hdkc_pm_cas64_get_wa(c.handler, (*C.uchar)(cKey), C.size_t(len(req.Key)), cPass, &cVal)
+		val, vlen = (uint64)(cVal), 8
+	}
+	res := c.result(&Response{}, ok)
+	if res.OK {
+		res.Val = make([]byte, vlen)
+		for i := 0; i < vlen; i++ {
+			res.Val[i] = (uint8)(val >> (uint64)(8*i))
+		}
+	}
+	return res
+}
+
+// casSet calls the C.k2hdkc_pm_cas{8,16,32,64}_set_wa function.
+func (c *nativeConn) casSet(req *Request) *Response {
+	cKey := C.CBytes(req.Key)
+	defer C.free(cKey)
+	cPass := C.CString(req.Pass)
+	defer C.free(unsafe.Pointer(cPass))
+	expire := req.Expire
+	if len(req.Old) != len(req.Val) {
+		return &Response{OK: false, Code: ResCodeError, SubCode: SubCodeInvalid}
+	}
+	oldVal := casUint64(req.Old)
+	newVal := casUint64(req.Val)
+
+	var ok C._Bool
+	switch len(req.Old) {
+	default:
+		return &Response{OK: false, Code: ResCodeError, SubCode: SubCodeInvalid}
+	case 1:
+		// bool k2hdkc_pm_cas8_set_wa(k2hdkc_chmpx_h handle, const unsigned char* pkey, size_t keylength, uint8_t oldval, uint8_t newval, const char* encpass, const time_t* expire)
+		ok = C.k2hdkc_pm_cas8_set_wa(c.handler, (*C.uchar)(cKey), C.size_t(len(req.Key)), (C.uint8_t)(oldVal), (C.uint8_t)(newVal), cPass, expirePointer(&expire))
+	case 2:
+		ok = C.k2hdkc_pm_cas16_set_wa(c.handler, (*C.uchar)(cKey), C.size_t(len(req.Key)), (C.uint16_t)(oldVal), (C.uint16_t)(newVal), cPass, expirePointer(&expire))
+	case 4:
+		ok = C.k2hdkc_pm_cas32_set_wa(c.handler, (*C.uchar)(cKey), C.size_t(len(req.Key)), (C.uint32_t)(oldVal), (C.uint32_t)(newVal), cPass, expirePointer(&expire))
+	case 8:
+		ok = C.k2hdkc_pm_cas64_set_wa(c.handler, (*C.uchar)(cKey), C.size_t(len(req.Key)), (C.uint64_t)(oldVal), (C.uint64_t)(newVal), cPass, expirePointer(&expire))
+	}
+	return c.result(&Response{}, ok)
+}
+
+// casIncDec calls the C.k2hdkc_pm_cas_{in,de}crement_wa function.
+func (c *nativeConn) casIncDec(req *Request) *Response {
+	cKey := C.CBytes(req.Key)
+	defer C.free(cKey)
+	cPass := C.CString(req.Pass)
+	defer C.free(unsafe.Pointer(cPass))
+	expire := req.Expire
+
+	var ok C._Bool
+	if req.Op == OpCasIncrement {
+		// bool k2hdkc_pm_cas_increment_wa(k2hdkc_chmpx_h handle, const unsigned char* pkey, size_t keylength, const char* encpass, const time_t* expire)
+		ok = C.k2hdkc_pm_cas_increment_wa(c.handler, (*C.uchar)(cKey), C.size_t(len(req.Key)), cPass, expirePointer(&expire))
+	} else {
+		// bool k2hdkc_pm_cas_decrement_wa(k2hdkc_chmpx_h handle, const unsigned char* pkey, size_t keylength, const char* encpass, const time_t* expire)
+		ok = C.k2hdkc_pm_cas_decrement_wa(c.handler, (*C.uchar)(cKey), C.size_t(len(req.Key)), cPass, expirePointer(&expire))
+	}
+	return c.result(&Response{}, ok)
+}
+
+// queuePush calls the C.k2hdkc_pm_q_push_wa or C.k2hdkc_pm_keyq_push_wa function.
+func (c *nativeConn) queuePush(req *Request) *Response {
+	cPrefix := C.CBytes(req.Prefix)
+	defer C.free(cPrefix)
+	cVal := C.CBytes(req.Val)
+	defer C.free(cVal)
+	// pass(default is nil) is optional. Go nil is eqaul to C NULL.
+	// The pass argment of NULL is acceptable for the k2hdkc C API.
+	cPass := C.CString(req.Pass)
+	defer C.free(unsafe.Pointer(cPass))
+	expire := req.Expire
+
+	var ok C._Bool
+	if len(req.Key) == 0 {
+		// bool k2hdkc_pm_q_push_wa(k2hdkc_chmpx_h handle, const unsigned char* pprefix, size_t prefixlength, const unsigned char* pval, size_t vallength, bool is_fifo, bool checkattr, const char* encpass, const time_t* expire)
+		ok = C.k2hdkc_pm_q_push_wa(
+			c.handler,
+			(*C.uchar)(cPrefix),
+			C.size_t(len(req.Prefix)),
+			(*C.uchar)(cVal),
+			C.size_t(len(req.Val)),
+			C._Bool(req.Fifo),
+			C._Bool(req.Attr),
+			cPass,
+			expirePointer(&expire))
+	} else {
+		cKey := C.CBytes(req.Key)
+		defer C.free(cKey)
+		// bool k2hdkc_pm_keyq_push_wa(k2hdkc_chmpx_h handle, const unsigned char* pprefix, size_t prefixlength, const unsigned char* pkey, size_t keylength, const unsigned char* pval, size_t vallength, bool is_fifo, bool checkattr, const char* encpass, const time_t* expire)
+		ok = C.k2hdkc_pm_keyq_push_wa(
+			c.handler,
+			(*C.uchar)(cPrefix),
+			C.size_t(len(req.Prefix)),
+			(*C.uchar)(cKey),
+			C.size_t(len(req.Key)),
+			(*C.uchar)(cVal),
+			C.size_t(len(req.Val)),
+			C._Bool(req.Fifo),
+			C._Bool(req.Attr),
+			cPass,
+			expirePointer(&expire))
+	}
+	return c.result(&Response{}, ok)
+}
+
+// queuePop calls the C.k2hdkc_pm_q_pop_wp or C.k2hdkc_pm_keyq_pop_wp function.
+func (c *nativeConn) queuePop(req *Request) *Response {
+	cPrefix := C.CBytes(req.Prefix)
+	defer C.free(cPrefix)
+	cPass := C.CString(req.Pass)
+	defer C.free(unsafe.Pointer(cPass))
+	var cRetKey (*C.uchar)
+	var cRetKeyLen C.size_t
+	var cRetVal (*C.uchar)
+	var cRetValLen C.size_t
+
+	var ok C._Bool
+	if req.KeyQueue {
+		// bool k2hdkc_pm_keyq_pop_wp(
+		//   k2hdkc_chmpx_h handle, const unsigned char* pprefix, size_t prefixlength, bool is_fifo, const char* encpass,
+		//   unsigned char** ppkey, size_t* pkeylength, unsigned char** ppval, size_t* pvallength);
+		ok = C.k2hdkc_pm_keyq_pop_wp(
+			c.handler,
+			(*C.uchar)(cPrefix),
+			C.size_t(len(req.Prefix)),
+			C._Bool(req.Fifo),
+			cPass,
+			&cRetKey,
+			&cRetKeyLen,
+			&cRetVal,
+			&cRetValLen)
+		defer C.free(unsafe.Pointer(cRetKey))
+	} else {
+		// bool k2hdkc_pm_q_pop_wp(
+		//   k2hdkc_chmpx_h handle, const unsigned char* pprefix, size_t prefixlength, bool is_fifo, const char* encpass,
+		//   unsigned char** ppval, size_t* pvallength);
+		ok = C.k2hdkc_pm_q_pop_wp(
+			c.handler,
+			(*C.uchar)(cPrefix),
+			C.size_t(len(req.Prefix)),
+			C._Bool(req.Fifo),
+			cPass,
+			&cRetVal,
+			&cRetValLen)
+	}
+	defer C.free(unsafe.Pointer(cRetVal))
+	res := c.result(&Response{}, ok)
+	if res.OK {
+		if req.KeyQueue {
+			res.Key = C.GoBytes(unsafe.Pointer(cRetKey), C.int(cRetKeyLen))
+		}
+		res.Val = C.GoBytes(unsafe.Pointer(cRetVal), C.int(cRetValLen))
+	}
+	return res
+}
+
+// queueRemove calls the C.k2hdkc_pm_q_remove_wp or C.k2hdkc_pm_keyq_remove_wp function.
+func (c *nativeConn) queueRemove(req *Request) *Response {
+	cPrefix := C.CBytes(req.Prefix)
+	defer C.free(cPrefix)
+	cPass := C.CString(req.Pass)
+	defer C.free(unsafe.Pointer(cPass))
+
+	var ok C._Bool
+	if !req.KeyQueue {
+		// bool k2hdkc_pm_q_remove_wp(k2hdkc_chmpx_h handle, const unsigned char* pprefix, size_t prefixlength, int count, bool is_fifo, const char* encpass)
+		ok = C.k2hdkc_pm_q_remove_wp(
+			c.handler,
+			(*C.uchar)(cPrefix),
+			C.size_t(len(req.Prefix)),
+			C.int(req.Count),
+			C._Bool(req.Fifo),
+			cPass)
+	} else {
+		// bool k2hdkc_pm_keyq_remove_wp(k2hdkc_chmpx_h handle, const unsigned char* pprefix, size_t prefixlength, int count, bool is_fifo, const char* encpass)
+		ok = C.k2hdkc_pm_keyq_remove_wp(
+			c.handler,
+			(*C.uchar)(cPrefix),
+			C.size_t(len(req.Prefix)),
+			C.int(req.Count),
+			C._Bool(req.Fifo),
+			cPass)
+	}
+	return c.result(&Response{}, ok)
+}
+
+// Local Variables:
+// c-basic-offset: 4
+// tab-width: 4
+// indent-tabs-mode: t
+// End:
+// vim600: noexpandtab sw=4 ts=4 fdm=marker
+// vim<600: noexpandtab sw=4 ts=4
@@ -0,0 +1,148 @@
+//
+// k2hdkc_go
+//
+// Copyright 2018 Yahoo Japan Corporation.
+//
+// Go driver for k2hdkc that is a highly available and scalable distributed
+// KVS clustering system. For k2hdkc, see
+// https://github.com/yahoojapan/k2hdkc for the details.
+//
+// For the full copyright and license information, please view
+// the license file that was distributed with this source code.
+//
+// AUTHOR:   Hirotaka Wakabayashi
+// CREATE:   Fri, 16 Oct 2026
+// REVISION:
+//
+
+package k2hdkc
+
+import (
+	"time"
+)
+
+// Option sets an optional parameter of a Client method.
+type Option func(*options)
+
+// options holds the optional parameters of a Client method. Each method uses the members which its command supports.
+type options struct {
+	pass         string  // passphrase to encrypt the value.
+	expire       int64   // expiration time in seconds.
+	fifo         bool    // queue order.
+	keyQueue     bool    // true if the queue holds keys and values.
+	casType      CasType // length of cas values.
+	rmSubKeyList bool    // true if Set removes the subkey list.
+	nest         bool    // true if RemoveSubKey removes subkeys of the subkey.
+	parentKey    string  // parent key of a renamed key.
+	codec        Codec   // codec of SetObject and GetObject.
+	concurrency  int     // number of sessions GetMany and SetMany use.
+}
+
+// newOptions returns the options with default values and the opts applied.
+func newOptions(opts []Option) *options {
+	o := &options{
+		pass:        "",
+		expire:      0,
+		fifo:        defaultOrderFIFO,
+		keyQueue:    defaultUseKeyQueue,
+		casType:     CasType64,
+		concurrency: defaultConcurrency,
+	}
+	for _, opt := range opts {
+		if opt != nil {
+			opt(o)
+		}
+	}
+	return o
+}
+
+// WithPass sets the passphrase to encrypt or decrypt the value.
+func WithPass(p string) Option {
+	return func(o *options) {
+		o.pass = p
+	}
+}
+
+// WithExpire sets the expiration time of the value. A duration less than a second is rounded up to a second.
+func WithExpire(d time.Duration) Option {
+	return func(o *options) {
+		if d <= 0 {
+			o.expire = 0
+			return
+		}
+		o.expire = (int64)((d + time.Second - 1) / time.Second)
+	}
+}
+
+// WithFifo makes QueuePush push values to the tail of the queue. Values are always popped and removed from the head.
+func WithFifo() Option {
+	return func(o *options) {
+		o.fifo = true
+	}
+}
+
+// WithLifo makes QueuePush push values to the head of the queue. Values are always popped and removed from the head.
+func WithLifo() Option {
+	return func(o *options) {
+		o.fifo = false
+	}
+}
+
+// WithKeyQueue makes QueuePop and QueueRemove handle a queue which holds keys and values.
+func WithKeyQueue() Option {
+	return func(o *options) {
+		o.keyQueue = true
+	}
+}
+
+// WithCasType sets the length of cas values. CasType64 is the default.
+func WithCasType(ct CasType) Option {
+	return func(o *options) {
+		o.casType = ct
+	}
+}
+
+// WithRmSubKeyList makes Set remove the subkey list of the key.
+func WithRmSubKeyList() Option {
+	return func(o *options) {
+		o.rmSubKeyList = true
+	}
+}
+
+// WithNest makes RemoveSubKey remove the subkeys of the subkey.
+func WithNest() Option {
+	return func(o *options) {
+		o.nest = true
+	}
+}
+
+// WithParentKey makes Rename replace the old key with the new key in the subkey list of the parent key.
+func WithParentKey(p string) Option {
+	return func(o *options) {
+		o.parentKey = p
+	}
+}
+
+// WithCodec sets the codec SetObject and GetObject use instead of the codec of the client.
+func WithCodec(c Codec) Option {
+	return func(o *options) {
+		o.codec = c
+	}
+}
+
+// WithConcurrency sets the maximum number of sessions GetMany and SetMany use at once.
+func WithConcurrency(n int) Option {
+	return func(o *options) {
+		if n > 0 {
+			o.concurrency = n
+		}
+	}
+}
+
+// Local Variables:
+// c-basic-offset: 4
+// tab-width: 4
+// indent-tabs-mode: t
+// End:
+// vim600: noexpandtab sw=4 ts=4 fdm=marker
+// vim<600: noexpandtab sw=4 ts=4
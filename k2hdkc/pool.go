@@ -0,0 +1,380 @@
+//
+// k2hdkc_go
+//
+// Copyright 2018 Yahoo Japan Corporation.
+//
+// Go driver for k2hdkc that is a highly available and scalable distributed
+// KVS clustering system. For k2hdkc, see
+// https://github.com/yahoojapan/k2hdkc for the details.
+//
+// For the full copyright and license information, please view
+// the license file that was distributed with this source code.
+//
+// AUTHOR:   Hirotaka Wakabayashi
+// CREATE:   Fri, 16 Oct 2026
+// REVISION:
+//
+
+package k2hdkc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+const (
+	defaultMinSession  = 0
+	defaultMaxSession  = 8
+	defaultMaxIdleTime = 60 * time.Second
+)
+
+// ErrPoolClosed is returned when a session is requested after the Client has been closed.
+var ErrPoolClosed = errors.New("k2hdkc: session pool is closed")
+
+// PoolStats holds statistics of the session pool of a Client.
+type PoolStats struct {
+	MaxOpen   int   // maximum number of open sessions
+	Open      int   // number of open sessions both in use and idle
+	InUse     int   // number of sessions currently in use
+	Idle      int   // number of idle sessions
+	WaitCount int64 // total number of borrows which waited for a free session
+	Opened    int64 // total number of sessions opened
+	Closed    int64 // total number of sessions closed
+	Abandoned int64 // total number of sessions given back while an abandoned C call was running
+	Busy      int   // number of sessions waiting for an abandoned C call to return
+}
+
+// String returns a text representation of the object.
+func (s PoolStats) String() string {
+	return fmt.Sprintf("[%v, %v, %v, %v, %v, %v, %v, %v, %v]", s.MaxOpen, s.Open, s.InUse, s.Idle, s.WaitCount, s.Opened, s.Closed, s.Abandoned, s.Busy)
+}
+
+// sessionPool keeps chmpx sessions open between requests of a Client.
+// A session is borrowed by get() and given back by put(). The sem channel holds
+// a token for every borrowed session, so that at most max sessions are in use.
+type sessionPool struct {
+	client      *Client
+	min         int
+	max         int
+	maxIdleTime time.Duration
+	validator   func(*Session) bool
+	sem         chan struct{}
+	stop        chan struct{}
+
+	mu          sync.Mutex
+	idle        []*Session // LIFO, the most recently used session is the last one.
+	open        int        // number of sessions both in use and idle
+	closed      bool
+	waitCount   int64
+	openCount   int64
+	closeCount  int64
+	abandoned   int64
+	busy        int
+	janitorDone chan struct{}
+}
+
+// newSessionPool returns a new sessionPool with the pool settings of the Client.
+func newSessionPool(c *Client) *sessionPool {
+	max := c.maxSession
+	if max <= 0 {
+		max = defaultMaxSession
+	}
+	min := c.minSession
+	if min < 0 {
+		min = 0
+	}
+	if min > max {
+		min = max
+	}
+	p := &sessionPool{
+		client:      c,
+		min:         min,
+		max:         max,
+		maxIdleTime: c.maxIdleTime,
+		validator:   c.validator,
+		sem:         make(chan struct{}, max),
+		stop:        make(chan struct{}),
+		idle:        make([]*Session, 0, max),
+	}
+	if p.min > 0 || p.maxIdleTime > 0 {
+		p.janitorDone = make(chan struct{})
+		go p.janitor()
+	}
+	return p
+}
+
+// String returns a text representation of the object.
+func (p *sessionPool) String() string {
+	return p.stats().String()
+}
+
+// get borrows a session from the pool. It opens a new session if no idle session
+// is available and the number of open sessions is less than max. Otherwise it
+// blocks until another session is given back or the context is done.
+func (p *sessionPool) get(ctx context.Context) (*Session, error) {
+	select {
+	case p.sem <- struct{}{}:
+	default:
+		p.mu.Lock()
+		p.waitCount++
+		p.mu.Unlock()
+		select {
+		case p.sem <- struct{}{}:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+	for {
+		p.mu.Lock()
+		if p.closed {
+			p.mu.Unlock()
+			<-p.sem
+			return nil, ErrPoolClosed
+		}
+		n := len(p.idle)
+		if n == 0 {
+			p.open++
+			p.mu.Unlock()
+			break
+		}
+		s := p.idle[n-1]
+		p.idle[n-1] = nil
+		p.idle = p.idle[:n-1]
+		p.mu.Unlock()
+		if p.valid(s) {
+			return s, nil
+		}
+		p.client.Logger().Log(ctx, LevelInfo, "closing an invalid session", Field{FieldSession, s.ID()})
+		p.discard(s)
+	}
+	return p.openContext(ctx)
+}
+
+// openContext opens a new session for get(). If the context is done before
+// NewSession returns, the session is kept in the pool when it's opened.
+func (p *sessionPool) openContext(ctx context.Context) (*Session, error) {
+	type opened struct {
+		s   *Session
+		err error
+	}
+	ch := make(chan opened, 1)
+	go func() {
+		s, err := NewSession(p.client)
+		if err != nil {
+			p.mu.Lock()
+			p.open--
+			p.mu.Unlock()
+		} else {
+			p.mu.Lock()
+			p.openCount++
+			p.mu.Unlock()
+		}
+		ch <- opened{s, err}
+	}()
+	select {
+	case o := <-ch:
+		if o.err != nil {
+			<-p.sem
+		}
+		return o.s, o.err
+	case <-ctx.Done():
+		go func() {
+			if o := <-ch; o.err == nil {
+				p.put(o.s, true)
+			} else {
+				<-p.sem
+			}
+		}()
+		return nil, ctx.Err()
+	}
+}
+
+// put gives a borrowed session back to the pool. The session is closed instead
+// of being kept if reuse is false or the pool has been closed.
+// A busy session is given back after the abandoned C call returns.
+func (p *sessionPool) put(s *Session, reuse bool) {
+	if s != nil && s.Busy() {
+		p.mu.Lock()
+		p.abandoned++
+		p.busy++
+		p.mu.Unlock()
+		go func() {
+			s.wait(context.Background())
+			p.mu.Lock()
+			p.busy--
+			p.mu.Unlock()
+			p.put(s, reuse)
+		}()
+		return
+	}
+	defer func() { <-p.sem }()
+	if s == nil {
+		p.mu.Lock()
+		p.open--
+		p.mu.Unlock()
+		return
+	}
+	p.mu.Lock()
+	if !reuse || p.closed {
+		p.mu.Unlock()
+		p.discard(s)
+		return
+	}
+	s.lastUsed = time.Now()
+	p.idle = append(p.idle, s)
+	p.mu.Unlock()
+}
+
+// valid returns true if the session can be used for the next request.
+func (p *sessionPool) valid(s *Session) bool {
+	if !s.valid() {
+		return false
+	}
+	if p.maxIdleTime > 0 && time.Since(s.lastUsed) > p.maxIdleTime {
+		return false
+	}
+	if p.validator != nil && !p.validator(s) {
+		return false
+	}
+	return true
+}
+
+// discard closes the session and forgets it.
+func (p *sessionPool) discard(s *Session) {
+	if err := s.close(); err != nil {
+		p.client.Logger().Log(context.Background(), LevelWarn, "failed to close a session", Field{FieldSession, s.ID()}, Field{FieldError, err})
+	}
+	p.mu.Lock()
+	p.open--
+	p.closeCount++
+	p.mu.Unlock()
+}
+
+// janitor closes sessions idle longer than maxIdleTime and keeps at least min sessions open.
+func (p *sessionPool) janitor() {
+	defer close(p.janitorDone)
+	interval := p.maxIdleTime / 2
+	if interval <= 0 || interval > defaultMaxIdleTime {
+		interval = defaultMaxIdleTime / 2
+	}
+	if interval < time.Second {
+		interval = time.Second
+	}
+	t := time.NewTicker(interval)
+	defer t.Stop()
+	for {
+		p.evict()
+		p.fill()
+		select {
+		case <-t.C:
+		case <-p.stop:
+			return
+		}
+	}
+}
+
+// evict closes idle sessions which have been unused longer than maxIdleTime.
+func (p *sessionPool) evict() {
+	if p.maxIdleTime <= 0 {
+		return
+	}
+	var expired []*Session
+	p.mu.Lock()
+	kept := p.idle[:0]
+	for _, s := range p.idle {
+		// p.idle is ordered from the oldest to the newest.
+		if p.open-len(expired) > p.min && time.Since(s.lastUsed) > p.maxIdleTime {
+			expired = append(expired, s)
+		} else {
+			kept = append(kept, s)
+		}
+	}
+	// kept shares the array with p.idle. clear the rest only.
+	for i := len(kept); i < len(p.idle); i++ {
+		p.idle[i] = nil
+	}
+	p.idle = kept
+	p.mu.Unlock()
+	for _, s := range expired {
+		p.discard(s)
+	}
+}
+
+// fill opens sessions until min sessions are open.
+func (p *sessionPool) fill() {
+	for {
+		p.mu.Lock()
+		if p.closed || p.open >= p.min {
+			p.mu.Unlock()
+			return
+		}
+		p.open++
+		p.mu.Unlock()
+		s, err := NewSession(p.client)
+		if err != nil {
+			p.client.Logger().Log(context.Background(), LevelWarn, "failed to open a session", Field{FieldError, err})
+			p.mu.Lock()
+			p.open--
+			p.mu.Unlock()
+			return
+		}
+		s.lastUsed = time.Now()
+		p.mu.Lock()
+		p.openCount++
+		if p.closed {
+			p.mu.Unlock()
+			p.discard(s)
+			return
+		}
+		p.idle = append(p.idle, s)
+		p.mu.Unlock()
+	}
+}
+
+// stats returns the current statistics of the pool.
+func (p *sessionPool) stats() PoolStats {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return PoolStats{
+		MaxOpen:   p.max,
+		Open:      p.open,
+		InUse:     p.open - len(p.idle),
+		Idle:      len(p.idle),
+		WaitCount: p.waitCount,
+		Opened:    p.openCount,
+		Closed:    p.closeCount,
+		Abandoned: p.abandoned,
+		Busy:      p.busy,
+	}
+}
+
+// close closes all idle sessions. Sessions in use are closed when they are given back.
+func (p *sessionPool) close() {
+	p.mu.Lock()
+	if p.closed {
+		p.mu.Unlock()
+		return
+	}
+	p.closed = true
+	idle := p.idle
+	p.idle = nil
+	p.mu.Unlock()
+	close(p.stop)
+	if p.janitorDone != nil {
+		<-p.janitorDone
+	}
+	for _, s := range idle {
+		p.discard(s)
+	}
+}
+
+// Local Variables:
+// c-basic-offset: 4
+// tab-width: 4
+// indent-tabs-mode: t
+// End:
+// vim600: noexpandtab sw=4 ts=4 fdm=marker
+// vim<600: noexpandtab sw=4 ts=4
@@ -17,19 +17,11 @@
 
 package k2hdkc
 
-import (
-	// #cgo CFLAGS: -g -O2 -Wall -Wextra -Wno-unused-variable -Wno-unused-parameter -I. -I/usr/include/k2hdkc
-	// #cgo LDFLAGS: -L/usr/lib -lk2hdkc
-	// #include <stdlib.h>
-	// #include "k2hdkc.h"
-	// #include "k2hmacro.h"
-	"C"
-)
 import (
 	"bytes"
+	"context"
 	"errors"
 	"fmt"
-	"unsafe"
 )
 
 // QueuePop holds arguments for C.k2hdkc_pm_q_pop_wa and C.k2hdkc_pm_keyq_pop_wa.
@@ -51,9 +43,9 @@ func (r *QueuePop) String() string {
 type QueuePopResult struct {
 	key        []byte
 	val        []byte
-	ok         C._Bool
-	resCode    C.dkcres_type_t // response
-	subResCode C.dkcres_type_t // response(details)
+	ok         bool
+	resCode    ResCode // response
+	subResCode SubCode // response(details)
 }
 
 // String returns a text representation of the object.
@@ -129,61 +121,24 @@ func (r *QueuePop) Execute(s *Session) (bool, error) {
 	if r.prefix == nil || len(r.prefix) == 0 || r.result == nil {
 		return false, fmt.Errorf("required members nil, r.prefix %v, r.result %v", r.prefix, r.result)
 	}
-	cPrefix := C.CBytes(r.prefix)
-	defer C.free(cPrefix)
-	cPass := C.CString(r.pass)
-	defer C.free(unsafe.Pointer(cPass))
-	var cRetKey (*C.uchar)
-	var cRetKeyLen C.size_t
-	var cRetVal (*C.uchar)
-	var cRetValLen C.size_t
-	if r.useKq {
-		// bool k2hdkc_pm_keyq_pop_wp(
-		//   k2hdkc_chmpx_h handle, const unsigned char* pprefix, size_t prefixlength, bool is_fifo, const char* encpass,
-		//   unsigned char** ppkey, size_t* pkeylength, unsigned char** ppval, size_t* pvallength);
-		r.result.ok = C.k2hdkc_pm_keyq_pop_wp(
-			s.handler,
-			(*C.uchar)(cPrefix),
-			C.size_t(len(r.prefix)),
-			C._Bool(r.fifo),
-			cPass,
-			&cRetKey,
-			&cRetKeyLen,
-			&cRetVal,
-			&cRetValLen)
-		r.result.resCode = C.k2hdkc_get_res_code(s.handler)
-		r.result.subResCode = C.k2hdkc_get_res_subcode(s.handler)
-		defer C.free(unsafe.Pointer(cRetKey))
-		defer C.free(unsafe.Pointer(cRetVal))
-		if !r.result.ok {
-			return false, errors.New("C.k2hdkc_pm_keyq_pop_wp returned false")
-		}
-		r.result.key = C.GoBytes(unsafe.Pointer(cRetKey), C.int(cRetKeyLen))
-		r.result.val = C.GoBytes(unsafe.Pointer(cRetVal), C.int(cRetValLen))
-
-	} else {
-		// bool k2hdkc_pm_q_pop_wp(
-		//   k2hdkc_chmpx_h handle, const unsigned char* pprefix, size_t prefixlength, bool is_fifo, const char* encpass,
-		//   unsigned char** ppval, size_t* pvallength);
-		r.result.ok = C.k2hdkc_pm_q_pop_wp(
-			s.handler,
-			(*C.uchar)(cPrefix),
-			C.size_t(len(r.prefix)),
-			C._Bool(r.fifo),
-			cPass,
-			&cRetVal,
-			&cRetValLen)
-		r.result.resCode = C.k2hdkc_get_res_code(s.handler)
-		r.result.subResCode = C.k2hdkc_get_res_subcode(s.handler)
-		defer C.free(unsafe.Pointer(cRetVal))
-		if !r.result.ok {
-			return false, errors.New("C.k2hdkc_pm_q_pop_wp returned false")
+	res := s.do(&Request{Op: OpQueuePop, Prefix: r.prefix, Fifo: r.fifo, KeyQueue: r.useKq, Pass: r.pass})
+	r.result.ok, r.result.resCode, r.result.subResCode = res.OK, res.Code, res.SubCode
+	if !r.result.ok {
+		if r.useKq {
+			return false, resError("C.k2hdkc_pm_keyq_pop_wp", res)
 		}
-		r.result.val = C.GoBytes(unsafe.Pointer(cRetVal), C.int(cRetValLen))
+		return false, resError("C.k2hdkc_pm_q_pop_wp", res)
 	}
+	r.result.key = res.Key
+	r.result.val = res.Val
 	return true, nil
 }
 
+// ExecuteContext calls Execute in a goroutine and returns the context error if the context is done before Execute returns.
+func (r *QueuePop) ExecuteContext(ctx context.Context, s *Session) (bool, error) {
+	return executeContext(ctx, s, r)
+}
+
 // Result returns the pointer of QueuePopResult that has the result of Execute method.
 func (r *QueuePop) Result() *QueuePopResult {
 	if r.result != nil {
@@ -202,9 +157,17 @@ func (r *QueuePopResult) Bool() bool {
 
 // Error returns the errno of C.k2hdkc_pm_q_pop_wp and C.k2hdkc_pm_keyq_pop_wp in string format.
 func (r *QueuePopResult) Error() string {
-	return fmt.Sprintf("%v %v",
-		C.GoString(C.str_dkcres_result_type(r.resCode)),
-		C.GoString(C.str_dkcres_subcode_type(r.subResCode)))
+	return fmt.Sprintf("%v %v", r.resCode, r.subResCode)
+}
+
+// Code returns the result code of the request.
+func (r *QueuePopResult) Code() ResCode {
+	return r.resCode
+}
+
+// SubCode returns the sub code of the request.
+func (r *QueuePopResult) SubCode() SubCode {
+	return r.subResCode
 }
 
 // KeyBytes returns the key data in C.k2hdkc_pm_q_pop_wp and C.k2hdkc_pm_keyq_pop_wp response in binary format.
@@ -213,12 +176,9 @@ func (r *QueuePopResult) KeyBytes() []byte {
 }
 
 // KeyString returns the key data in C.k2hdkc_pm_q_pop_wp and C.k2hdkc_pm_keyq_pop_wp response in text format.
+// The terminating NUL is removed if it exists.
 func (r *QueuePopResult) KeyString() string {
-	keyLen := len(r.key)
-	if keyLen > 0 {
-		return string(r.key[:keyLen-1]) // make a new string without the null termination
-	}
-	return ""
+	return string(trimNul(r.key))
 }
 
 // ValBytes returns the value data in C.k2hdkc_pm_q_pop_wp and C.k2hdkc_pm_keyq_pop_wp response in string format.
@@ -227,12 +187,9 @@ func (r *QueuePopResult) ValBytes() []byte {
 }
 
 // ValString returns the value data in C.k2hdkc_pm_q_pop_wp and C.k2hdkc_pm_keyq_pop_wp response in text format.
+// The terminating NUL is removed if it exists.
 func (r *QueuePopResult) ValString() string {
-	valLen := len(r.val)
-	if valLen > 0 {
-		return string(r.val[:valLen-1]) // make a new string without the null termination
-	}
-	return ""
+	return string(trimNul(r.val))
 }
 
 // Local Variables:
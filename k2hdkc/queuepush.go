@@ -17,19 +17,11 @@
 
 package k2hdkc
 
-import (
-	// #cgo CFLAGS: -g -O2 -Wall -Wextra -Wno-unused-variable -Wno-unused-parameter -I. -I/usr/include/k2hdkc
-	// #cgo LDFLAGS: -L/usr/lib -lk2hdkc
-	// #include <stdlib.h>
-	// #include "k2hdkc.h"
-	// #include "k2hmacro.h"
-	"C"
-)
 import (
 	"bytes"
+	"context"
 	"errors"
 	"fmt"
-	"unsafe"
 )
 
 // QueuePush holds arguments for C.k2hdkc_pm_q_push_wa and C.k2hdkc_pm_keyq_push_wa.
@@ -52,9 +44,9 @@ func (r *QueuePush) String() string {
 
 // QueuePushResult holds the result of QueuePush.Execute().
 type QueuePushResult struct {
-	ok         C._Bool
-	resCode    C.dkcres_type_t // response
-	subResCode C.dkcres_type_t // response(details)
+	ok         bool
+	resCode    ResCode // response
+	subResCode SubCode // response(details)
 }
 
 // String returns a text representation of the object.
@@ -176,66 +168,36 @@ func (r *QueuePush) SetExpire(t int64) {
 
 // Execute calls the C.k2hdkc_pm_q_push_wa or C.k2hdkc_pm_keyq_push_wa function that push data to a queue.
 func (r *QueuePush) Execute(s *Session) (bool, error) {
-	// key(default is nil) is optional. Go nil is eqaul to C NULL.                                                                                            // The key argment of NULL is acceptable for the k2hdkc C API.
+	// key(default is nil) is optional.
 	if r.prefix == nil || len(r.prefix) == 0 || r.val == nil || len(r.val) == 0 || r.result == nil {
 		return false, fmt.Errorf("some required members nil, r.prefix %v, r.val %v, r.result %v", r.prefix, r.val, r.result)
 	}
-	cPrefix := C.CBytes(r.prefix)
-	defer C.free(cPrefix)
-	cVal := C.CBytes(r.val)
-	defer C.free(cVal)
-	cKey := C.CBytes(r.key)
-	defer C.free(cKey)
-	// pass(default is nil) is optional. Go nil is eqaul to C NULL.                                                                                            // The pass argment of NULL is acceptable for the k2hdkc C API.
-	cPass := C.CString(r.pass)
-	defer C.free(unsafe.Pointer(cPass))
-	var expire *C.time_t
-	// WARNING: You can't set zero expire.
-	if r.expire != 0 {
-		expire = (*C.time_t)(&r.expire)
-	}
-	if len(r.key) == 0 {
-		// bool k2hdkc_pm_q_push_wa(k2hdkc_chmpx_h handle, const unsigned char* pprefix, size_t prefixlength, const unsigned char* pval, size_t vallength, bool is_fifo, bool checkattr, const char* encpass, const time_t* expire)
-		r.result.ok = C.k2hdkc_pm_q_push_wa(
-			s.handler,
-			(*C.uchar)(cPrefix),
-			C.size_t(len(r.prefix)),
-			(*C.uchar)(cVal),
-			C.size_t(len(r.val)),
-			C._Bool(r.fifo),
-			C._Bool(r.attr),
-			cPass,
-			expire)
-		r.result.resCode = C.k2hdkc_get_res_code(s.handler)
-		r.result.subResCode = C.k2hdkc_get_res_subcode(s.handler)
-
-		if r.result.ok == false {
-			return false, errors.New("C.k2hdkc_pm_q_push_wa returned false")
-		}
-	} else {
-		// bool k2hdkc_pm_keyq_push_wa(k2hdkc_chmpx_h handle, const unsigned char* pprefix, size_t prefixlength, const unsigned char* pkey, size_t keylength, const unsigned char* pval, size_t vallength, bool is_fifo, bool checkattr, const char* encpass, const time_t* expire)
-		r.result.ok = C.k2hdkc_pm_keyq_push_wa(
-			s.handler,
-			(*C.uchar)(cPrefix),
-			C.size_t(len(r.prefix)),
-			(*C.uchar)(cKey),
-			C.size_t(len(r.key)),
-			(*C.uchar)(cVal),
-			C.size_t(len(r.val)),
-			C._Bool(r.fifo),
-			C._Bool(r.attr),
-			cPass,
-			expire)
-		r.result.resCode = C.k2hdkc_get_res_code(s.handler)
-		r.result.subResCode = C.k2hdkc_get_res_subcode(s.handler)
-
-		if r.result.ok == false {
-			return false, errors.New("C.k2hdkc_pm_keyq_push_wa returned false")
+	res := s.do(&Request{
+		Op:       OpQueuePush,
+		Prefix:   r.prefix,
+		Key:      r.key,
+		Val:      r.val,
+		Fifo:     r.fifo,
+		KeyQueue: len(r.key) != 0,
+		Attr:     r.attr,
+		Pass:     r.pass,
+		Expire:   r.expire,
+	})
+	r.result.ok, r.result.resCode, r.result.subResCode = res.OK, res.Code, res.SubCode
+	if r.result.ok == false {
+		if len(r.key) == 0 {
+			return false, resError("C.k2hdkc_pm_q_push_wa", res)
 		}
+		return false, resError("C.k2hdkc_pm_keyq_push_wa", res)
 	}
 	return true, nil
 }
 
+// ExecuteContext calls Execute in a goroutine and returns the context error if the context is done before Execute returns.
+func (r *QueuePush) ExecuteContext(ctx context.Context, s *Session) (bool, error) {
+	return executeContext(ctx, s, r)
+}
+
 // Result returns the pointer of QueuePushResult that has the result of Execute method.
 func (r *QueuePush) Result() *QueuePushResult {
 	if r.result != nil {
@@ -254,9 +216,17 @@ func (r *QueuePushResult) Bool() bool {
 
 // Error returns the errno of C.k2hdkc_pm_keyq_push_wa and C.k2hdkc_pm_q_push_wa in string format.
 func (r *QueuePushResult) Error() string {
-	return fmt.Sprintf("%v %v",
-		C.GoString(C.str_dkcres_result_type(r.resCode)),
-		C.GoString(C.str_dkcres_subcode_type(r.subResCode)))
+	return fmt.Sprintf("%v %v", r.resCode, r.subResCode)
+}
+
+// Code returns the result code of the request.
+func (r *QueuePushResult) Code() ResCode {
+	return r.resCode
+}
+
+// SubCode returns the sub code of the request.
+func (r *QueuePushResult) SubCode() SubCode {
+	return r.subResCode
 }
 
 // Local Variables:
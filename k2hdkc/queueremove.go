@@ -17,19 +17,11 @@
 
 package k2hdkc
 
-import (
-	// #cgo CFLAGS: -g -O2 -Wall -Wextra -Wno-unused-variable -Wno-unused-parameter -I. -I/usr/include/k2hdkc
-	// #cgo LDFLAGS: -L/usr/lib -lk2hdkc
-	// #include <stdlib.h>
-	// #include "k2hdkc.h"
-	// #include "k2hmacro.h"
-	"C"
-)
 import (
 	"bytes"
+	"context"
 	"errors"
 	"fmt"
-	"unsafe"
 )
 
 // QueueRemove holds arguments for C.k2hdkc_pm_q_remove_wa and C.k2hdkc_pm_keyq_remove_wa.
@@ -50,9 +42,9 @@ func (r *QueueRemove) String() string {
 
 // QueueRemoveResult holds the result of QueueRemove.Execute().
 type QueueRemoveResult struct {
-	ok         C._Bool
-	resCode    C.dkcres_type_t // response
-	subResCode C.dkcres_type_t // response(details)
+	ok         bool
+	resCode    ResCode // response
+	subResCode SubCode // response(details)
 }
 
 // String returns a text representation of the object.
@@ -127,45 +119,22 @@ func (r *QueueRemove) Execute(s *Session) (bool, error) {
 	if r.prefix == nil || len(r.prefix) == 0 || r.result == nil {
 		return false, fmt.Errorf("required members nil, r.prefix %v, r.result %v", r.prefix, r.result)
 	}
-	cPrefix := C.CBytes(r.prefix)
-	defer C.free(cPrefix)
-	cPass := C.CString(r.pass)
-	defer C.free(unsafe.Pointer(cPass))
-	if !r.useKq {
-		// bool k2hdkc_pm_q_remove_wp(k2hdkc_chmpx_h handle, const unsigned char* pprefix, size_t prefixlength, int count, bool is_fifo, const char* encpass)
-		r.result.ok = C.k2hdkc_pm_q_remove_wp(
-			s.handler,
-			(*C.uchar)(cPrefix),
-			C.size_t(len(r.prefix)),
-			C.int(r.count),
-			C._Bool(r.fifo),
-			cPass)
-		r.result.resCode = C.k2hdkc_get_res_code(s.handler)
-		r.result.subResCode = C.k2hdkc_get_res_subcode(s.handler)
-
-		if r.result.ok == false {
-			return false, errors.New("C.k2hdkc_pm_q_remove_wp returned false")
-		}
-	} else {
-		// bool k2hdkc_pm_keyq_remove_wp(k2hdkc_chmpx_h handle, const unsigned char* pprefix, size_t prefixlength, int count, bool is_fifo, const char* encpass)
-		r.result.ok = C.k2hdkc_pm_keyq_remove_wp(
-			s.handler,
-			(*C.uchar)(cPrefix),
-			C.size_t(len(r.prefix)),
-			C.int(r.count),
-			C._Bool(r.fifo),
-			cPass)
-		r.result.resCode = C.k2hdkc_get_res_code(s.handler)
-		r.result.subResCode = C.k2hdkc_get_res_subcode(s.handler)
-
-		if r.result.ok == false {
-			return false, errors.New("C.C.k2hdkc_pm_keyq_remove_wp returned false")
-
+	res := s.do(&Request{Op: OpQueueRemove, Prefix: r.prefix, Count: r.count, Fifo: r.fifo, KeyQueue: r.useKq, Pass: r.pass})
+	r.result.ok, r.result.resCode, r.result.subResCode = res.OK, res.Code, res.SubCode
+	if r.result.ok == false {
+		if r.useKq {
+			return false, resError("C.k2hdkc_pm_keyq_remove_wp", res)
 		}
+		return false, resError("C.k2hdkc_pm_q_remove_wp", res)
 	}
 	return true, nil
 }
 
+// ExecuteContext calls Execute in a goroutine and returns the context error if the context is done before Execute returns.
+func (r *QueueRemove) ExecuteContext(ctx context.Context, s *Session) (bool, error) {
+	return executeContext(ctx, s, r)
+}
+
 // Result returns the pointer of QueueRemoveResult that has the result of Execute method.
 func (r *QueueRemove) Result() *QueueRemoveResult {
 	if r.result != nil {
@@ -184,9 +153,17 @@ func (r *QueueRemoveResult) Bool() bool {
 
 // Error returns the errno of C.k2hdkc_pm_q_remove_wp and C.k2hdkc_pm_keyq_remove_wp in string format.
 func (r *QueueRemoveResult) Error() string {
-	return fmt.Sprintf("%v %v",
-		C.GoString(C.str_dkcres_result_type(r.resCode)),
-		C.GoString(C.str_dkcres_subcode_type(r.subResCode)))
+	return fmt.Sprintf("%v %v", r.resCode, r.subResCode)
+}
+
+// Code returns the result code of the request.
+func (r *QueueRemoveResult) Code() ResCode {
+	return r.resCode
+}
+
+// SubCode returns the sub code of the request.
+func (r *QueueRemoveResult) SubCode() SubCode {
+	return r.subResCode
 }
 
 // Local Variables:
@@ -17,19 +17,11 @@
 
 package k2hdkc
 
-import (
-	// #cgo CFLAGS: -g -O2 -Wall -Wextra -Wno-unused-variable -Wno-unused-parameter -I. -I/usr/include/k2hdkc
-	// #cgo LDFLAGS: -L/usr/lib -lk2hdkc
-	// #include <stdlib.h>
-	// #include "k2hdkc.h"
-	// #include "k2hmacro.h"
-	"C"
-)
 import (
 	"bytes"
+	"context"
 	"errors"
 	"fmt"
-	"unsafe"
 )
 
 // RemoveSubKey holds arguments C.k2hdkc_pm_remove_subkey for and a pointer of RemoveSubKeyResult.
@@ -47,9 +39,9 @@ func (r *RemoveSubKey) String() string {
 
 // RemoveSubKeyResult holds the result of RemoveSubKey.Execute().
 type RemoveSubKeyResult struct {
-	ok         C._Bool
-	resCode    C.dkcres_type_t // response
-	subResCode C.dkcres_type_t // response(details)
+	ok         bool
+	resCode    ResCode // response
+	subResCode SubCode // response(details)
 }
 
 // String returns a text representation of the object.
@@ -109,32 +101,29 @@ func NewRemoveSubKey(k interface{}, sk interface{}) (*RemoveSubKey, error) {
 	return c, nil
 }
 
+// SetNest sets the nest member.
+func (r *RemoveSubKey) SetNest(b bool) {
+	r.nest = b
+}
+
 // Execute calls C.k2hdkc_pm_remove_subkey that remove a subkey.
 func (r *RemoveSubKey) Execute(s *Session) (bool, error) {
 	if r.key == nil || len(r.key) == 0 || r.skey == nil || len(r.skey) == 0 || r.result == nil {
 		return false, fmt.Errorf("required members nil, r.key %v, r.skey %v, r.result %v", r.key, r.skey, r.result)
 	}
-	cKey := C.CBytes(r.key)
-	defer C.free(unsafe.Pointer(cKey))
-	cSkey := C.CBytes(r.skey)
-	defer C.free(unsafe.Pointer(cSkey))
-
-	r.result.ok = C.k2hdkc_pm_remove_subkey(
-		s.handler,
-		(*C.uchar)(cKey),
-		(C.size_t)(len(r.key)),
-		(*C.uchar)(cSkey),
-		(C.size_t)(len(r.skey)),
-		(C._Bool)(r.nest))
-	r.result.resCode = C.k2hdkc_get_res_code(s.handler)
-	r.result.subResCode = C.k2hdkc_get_res_subcode(s.handler)
-
+	res := s.do(&Request{Op: OpRemoveSubKey, Key: r.key, SubKey: r.skey, Nest: r.nest})
+	r.result.ok, r.result.resCode, r.result.subResCode = res.OK, res.Code, res.SubCode
 	if r.result.ok == false {
-		return false, fmt.Errorf("C.k2hdkc_pm_remove_subkey() = %v", r.result.ok)
+		return false, resError("C.k2hdkc_pm_remove_subkey", res)
 	}
 	return true, nil
 }
 
+// ExecuteContext calls Execute in a goroutine and returns the context error if the context is done before Execute returns.
+func (r *RemoveSubKey) ExecuteContext(ctx context.Context, s *Session) (bool, error) {
+	return executeContext(ctx, s, r)
+}
+
 // Result returns the pointer of RemoveSubKeyResult that has the result of Execute method.
 func (r *RemoveSubKey) Result() *RemoveSubKeyResult {
 	if r.result != nil {
@@ -153,9 +142,17 @@ func (r *RemoveSubKeyResult) Bool() bool {
 
 // Error returns the errno of C.k2hdkc_pm_remove_subkey() in string format.
 func (r *RemoveSubKeyResult) Error() string {
-	return fmt.Sprintf("%v %v",
-		C.GoString(C.str_dkcres_result_type(r.resCode)),
-		C.GoString(C.str_dkcres_subcode_type(r.subResCode)))
+	return fmt.Sprintf("%v %v", r.resCode, r.subResCode)
+}
+
+// Code returns the result code of the request.
+func (r *RemoveSubKeyResult) Code() ResCode {
+	return r.resCode
+}
+
+// SubCode returns the sub code of the request.
+func (r *RemoveSubKeyResult) SubCode() SubCode {
+	return r.subResCode
 }
 
 // Local Variables:
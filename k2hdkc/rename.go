@@ -17,19 +17,11 @@
 
 package k2hdkc
 
-import (
-	// #cgo CFLAGS: -g -O2 -Wall -Wextra -Wno-unused-variable -Wno-unused-parameter -I. -I/usr/include/k2hdkc
-	// #cgo LDFLAGS: -L/usr/lib -lk2hdkc
-	// #include <stdlib.h>
-	// #include "k2hdkc.h"
-	// #include "k2hmacro.h"
-	"C"
-)
 import (
 	"bytes"
+	"context"
 	"errors"
 	"fmt"
-	"unsafe"
 )
 
 // Rename holds arguments for C.k2hdkc_pm_rename_with_parent_wa and a pointer of RenameResult.
@@ -50,9 +42,9 @@ func (r *Rename) String() string {
 
 // RenameResult holds the result of Rename.Execute().
 type RenameResult struct {
-	ok         C._Bool
-	resCode    C.dkcres_type_t // response
-	subResCode C.dkcres_type_t // response(details)
+	ok         bool
+	resCode    ResCode // response
+	subResCode SubCode // response(details)
 }
 
 // String returns a text representation of the object.
@@ -162,43 +154,28 @@ func (r *Rename) Execute(s *Session) (bool, error) {
 	if r.oldKey == nil || len(r.oldKey) == 0 || r.newKey == nil || len(r.newKey) == 0 || r.result == nil {
 		return false, fmt.Errorf("some required members nil, r.key %v, r.val %v, r.result %v", r.newKey, r.oldKey, r.result)
 	}
-	cOldKey := C.CBytes(r.oldKey)
-	defer C.free(cOldKey)
-	cNewKey := C.CBytes(r.newKey)
-	defer C.free(cNewKey)
-	// parent(default is nil) is optional(null is acceptable for the k2hdkc C API).
-	// For cgo, Go nil is equal to C NULL.
-	cParentKey := C.CBytes(r.parentKey)
-	defer C.free(cParentKey)
-	// pass(default is nil) is optional(null is acceptable for the k2hdkc C API).
-	// For cgo, Go nil is equal to C NULL.
-	cPass := C.CString(r.pass)
-	defer C.free(unsafe.Pointer(cPass))
-	var expire *C.time_t
-	// WARNING: You can't set zero expire.
-	if r.expire != 0 {
-		expire = (*C.time_t)(&r.expire)
-	}
-	r.result.ok = C.k2hdkc_pm_rename_with_parent_wa(
-		s.handler,
-		(*C.uchar)(cOldKey),
-		C.size_t(len(r.oldKey)),
-		(*C.uchar)(cNewKey),
-		C.size_t(len(r.newKey)),
-		(*C.uchar)(cParentKey),
-		C.size_t(len(r.parentKey)),
-		C._Bool(r.attr),
-		cPass,
-		expire)
-	r.result.resCode = C.k2hdkc_get_res_code(s.handler)
-	r.result.subResCode = C.k2hdkc_get_res_subcode(s.handler)
-
+	// parent(default is nil) is optional.
+	res := s.do(&Request{
+		Op:        OpRename,
+		Key:       r.oldKey,
+		NewKey:    r.newKey,
+		ParentKey: r.parentKey,
+		Attr:      r.attr,
+		Pass:      r.pass,
+		Expire:    r.expire,
+	})
+	r.result.ok, r.result.resCode, r.result.subResCode = res.OK, res.Code, res.SubCode
 	if r.result.ok == false {
-		return false, errors.New("C.k2hdkc_pm_rename_with_parent_wa returned false")
+		return false, resError("C.k2hdkc_pm_rename_with_parent_wa", res)
 	}
 	return true, nil
 }
 
+// ExecuteContext calls Execute in a goroutine and returns the context error if the context is done before Execute returns.
+func (r *Rename) ExecuteContext(ctx context.Context, s *Session) (bool, error) {
+	return executeContext(ctx, s, r)
+}
+
 // Result returns the pointer of RenameResult that has the result of Execute method.
 func (r *Rename) Result() *RenameResult {
 	if r.result != nil {
@@ -217,9 +194,17 @@ func (r *RenameResult) Bool() bool {
 
 // Error returns the errno of C.k2hdkc_pm_rename_with_parent_wa in string format.
 func (r *RenameResult) Error() string {
-	return fmt.Sprintf("%v %v",
-		C.GoString(C.str_dkcres_result_type(r.resCode)),
-		C.GoString(C.str_dkcres_subcode_type(r.subResCode)))
+	return fmt.Sprintf("%v %v", r.resCode, r.subResCode)
+}
+
+// Code returns the result code of the request.
+func (r *RenameResult) Code() ResCode {
+	return r.resCode
+}
+
+// SubCode returns the sub code of the request.
+func (r *RenameResult) SubCode() SubCode {
+	return r.subResCode
 }
 
 // Local Variables:
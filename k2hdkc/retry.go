@@ -0,0 +1,157 @@
+//
+// k2hdkc_go
+//
+// Copyright 2018 Yahoo Japan Corporation.
+//
+// Go driver for k2hdkc that is a highly available and scalable distributed
+// KVS clustering system. For k2hdkc, see
+// https://github.com/yahoojapan/k2hdkc for the details.
+//
+// For the full copyright and license information, please view
+// the license file that was distributed with this source code.
+//
+// AUTHOR:   Hirotaka Wakabayashi
+// CREATE:   Fri, 16 Oct 2026
+// REVISION:
+//
+
+package k2hdkc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"time"
+)
+
+// RetryPolicy defines how a Client retries a failed command.
+// A command is retried if its ResError has one of Codes, or if the Backend failed to open a
+// session for it with an OpenError. Commands which are not idempotent, such as QueuePop, CasSet
+// and Remove, are retried only when the session failed to open unless RetryNonIdempotent is true.
+type RetryPolicy struct {
+	MaxAttempts        int           // attempts including the first one. 1 or less means no retry.
+	MinBackoff         time.Duration // wait before the first retry.
+	MaxBackoff         time.Duration // the wait doubles up to this value.
+	Jitter             float64       // fraction of the wait which is randomized, from 0 to 1.
+	Codes              []SubCode     // sub codes of retryable failures.
+	RetryNonIdempotent bool          // true if commands which are not idempotent are retried too.
+}
+
+// DefaultRetryPolicy returns a policy which retries the temporary failures twice.
+func DefaultRetryPolicy() *RetryPolicy {
+	return &RetryPolicy{
+		MaxAttempts: 3,
+		MinBackoff:  50 * time.Millisecond,
+		MaxBackoff:  time.Second,
+		Jitter:      0.5,
+		Codes:       []SubCode{SubCodeNoServer, SubCodeSend, SubCodeReceive, SubCodeTimeout},
+	}
+}
+
+// String returns a text representation of the object.
+func (p *RetryPolicy) String() string {
+	return fmt.Sprintf("[%v, %v, %v, %v, %v, %v]", p.MaxAttempts, p.MinBackoff, p.MaxBackoff, p.Jitter, p.Codes, p.RetryNonIdempotent)
+}
+
+// retryable returns true if the command failed with err may be retried.
+// sent is false if the command failed before it was sent.
+func (p *RetryPolicy) retryable(cmd Command, sent bool, err error) bool {
+	if err == nil || isContextError(err) || errors.Is(err, ErrBreakerOpen) {
+		return false
+	}
+	if !sent {
+		// the other errors before sending, such as ErrPoolClosed, don't go away.
+		return isOpenError(err)
+	}
+	if !p.RetryNonIdempotent && !idempotent(cmd) {
+		return false
+	}
+	var re *ResError
+	if !errors.As(err, &re) {
+		return false
+	}
+	for _, code := range p.Codes {
+		if re.SubCode == code {
+			return true
+		}
+	}
+	return false
+}
+
+// backoff returns the wait before the nth retry, which starts from 1.
+func (p *RetryPolicy) backoff(n int) time.Duration {
+	d := p.MinBackoff
+	for i := 1; i < n && d < p.MaxBackoff; i++ {
+		d *= 2
+	}
+	if p.MaxBackoff > 0 && d > p.MaxBackoff {
+		d = p.MaxBackoff
+	}
+	if p.Jitter > 0 && d > 0 {
+		j := p.Jitter
+		if j > 1 {
+			j = 1
+		}
+		d -= time.Duration(rand.Float64() * j * float64(d))
+	}
+	return d
+}
+
+// idempotent returns true if sending the command twice has the same effect as sending it once.
+// A command which fails if it has been applied, such as CasSet and Remove, isn't idempotent
+// because the second attempt reports a failure of the first one which has succeeded.
+func idempotent(cmd Command) bool {
+	switch cmd.(type) {
+	case *CasIncDec, *CasSet, *QueuePop, *QueuePush, *QueueRemove, *Remove, *RemoveSubKey, *Rename:
+		return false
+	default:
+		return true
+	}
+}
+
+// SetRetryPolicy sets the policy to retry failed commands. A copy of p is kept.
+// nil, the default, means commands are never retried.
+func (c *Client) SetRetryPolicy(p *RetryPolicy) *Client {
+	if p == nil {
+		c.retry = nil
+		return c
+	}
+	cp := *p
+	cp.Codes = append([]SubCode(nil), p.Codes...)
+	c.retry = &cp
+	return c
+}
+
+// withRetry calls run through the circuit breaker until it succeeds or the retry policy gives up.
+// run returns sent false if the command failed before it was sent.
+func (c *Client) withRetry(ctx context.Context, cmd Command, run func() (ok bool, sent bool, err error)) (bool, error) {
+	p := c.retry
+	for n := 1; ; n++ {
+		ok, sent, err := c.guard(run)
+		if ok || p == nil || n >= p.MaxAttempts || !p.retryable(cmd, sent, err) {
+			return ok, err
+		}
+		d := p.backoff(n)
+		c.Logger().Log(ctx, LevelInfo, "retrying command", append(c.commandFields(cmd, err), Field{"attempt", n}, Field{"backoff", d}, Field{FieldError, err})...)
+		_, span := TracerFromContext(ctx).Start(ctx, "k2hdkc.retry")
+		span.SetAttributes(Attribute{SpanAttempt, n}, Attribute{SpanBackoff, d})
+		t := time.NewTimer(d)
+		select {
+		case <-ctx.Done():
+			t.Stop()
+			span.End(ctx.Err())
+			return ok, err
+		case <-t.C:
+		}
+		span.End(nil)
+	}
+}
+
+// Local Variables:
+// c-basic-offset: 4
+// tab-width: 4
+// indent-tabs-mode: t
+// End:
+// vim600: noexpandtab sw=4 ts=4 fdm=marker
+// vim<600: noexpandtab sw=4 ts=4
@@ -0,0 +1,158 @@
+//
+// k2hdkc_go
+//
+// Copyright 2018 Yahoo Japan Corporation.
+//
+// Go driver for k2hdkc that is a highly available and scalable distributed
+// KVS clustering system. For k2hdkc, see
+// https://github.com/yahoojapan/k2hdkc for the details.
+//
+// For the full copyright and license information, please view
+// the license file that was distributed with this source code.
+//
+// AUTHOR:   Hirotaka Wakabayashi
+// CREATE:   Fri, 16 Oct 2026
+// REVISION:
+//
+
+package k2hdkc_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/yahoojapan/k2hdkc_go/k2hdkc"
+	"github.com/yahoojapan/k2hdkc_go/k2hdkc/k2hdkctest"
+)
+
+// retryPolicy returns a policy which retries quickly.
+func retryPolicy() *k2hdkc.RetryPolicy {
+	p := k2hdkc.DefaultRetryPolicy()
+	p.MinBackoff = time.Millisecond
+	p.MaxBackoff = 5 * time.Millisecond
+	return p
+}
+
+// TestRetryTemporary tests temporary failures are retried up to MaxAttempts.
+func TestRetryTemporary(t *testing.T) {
+	in := k2hdkctest.NewInjector(k2hdkctest.NewServer())
+	c := in.NewClient()
+	defer c.Close()
+	if err := c.SetValue("retry", []byte("v")); err != nil {
+		t.Fatalf("c.SetValue(retry) = %v", err)
+	}
+
+	in.Add(k2hdkctest.Fault{Op: k2hdkc.OpGet, Times: 2, Fail: true, SubCode: k2hdkc.SubCodeTimeout})
+	if _, err := c.GetValue("retry"); !errors.Is(err, k2hdkc.ErrTimeout) || in.Calls(k2hdkc.OpGet) != 1 {
+		t.Fatalf("no policy: c.GetValue(retry) = %v after %v calls", err, in.Calls(k2hdkc.OpGet))
+	}
+	c.SetRetryPolicy(retryPolicy())
+	if v, err := c.GetValue("retry"); err != nil || string(v) != "v" || in.Calls(k2hdkc.OpGet) != 3 {
+		t.Errorf("c.GetValue(retry) = %q, %v after %v calls, want 3 calls", v, err, in.Calls(k2hdkc.OpGet))
+	}
+
+	in.Clear()
+	in.Add(k2hdkctest.Fault{Op: k2hdkc.OpGet, Fail: true, SubCode: k2hdkc.SubCodeReceive})
+	if _, err := c.GetValue("retry"); !errors.Is(err, k2hdkc.ErrCommunication) || in.Calls(k2hdkc.OpGet) != 3 {
+		t.Errorf("c.GetValue(retry) = %v after %v calls, want 3 calls", err, in.Calls(k2hdkc.OpGet))
+	}
+}
+
+// TestRetryCodes tests failures of the other codes are not retried.
+func TestRetryCodes(t *testing.T) {
+	in := k2hdkctest.NewInjector(k2hdkctest.NewServer())
+	c := in.NewClient().SetRetryPolicy(retryPolicy())
+	defer c.Close()
+
+	in.Add(k2hdkctest.Fault{Op: k2hdkc.OpSet, Fail: true, SubCode: k2hdkc.SubCodeInternal})
+	if err := c.SetValue("retry", []byte("v")); !errors.Is(err, k2hdkc.ErrInternal) || in.Calls(k2hdkc.OpSet) != 1 {
+		t.Errorf("c.SetValue(retry) = %v after %v calls, want 1 call", err, in.Calls(k2hdkc.OpSet))
+	}
+	p := retryPolicy()
+	p.Codes = []k2hdkc.SubCode{k2hdkc.SubCodeInternal}
+	c.SetRetryPolicy(p)
+	if err := c.SetValue("retry", []byte("v")); !errors.Is(err, k2hdkc.ErrInternal) || in.Calls(k2hdkc.OpSet) != 4 {
+		t.Errorf("c.SetValue(retry) = %v after %v calls, want 4 calls", err, in.Calls(k2hdkc.OpSet))
+	}
+}
+
+// TestRetryNonIdempotent tests commands which are not idempotent are retried only if the policy allows it.
+func TestRetryNonIdempotent(t *testing.T) {
+	in := k2hdkctest.NewInjector(k2hdkctest.NewServer())
+	c := in.NewClient().SetRetryPolicy(retryPolicy())
+	defer c.Close()
+	if err := c.CasInit("cas", 1); err != nil {
+		t.Fatalf("c.CasInit(cas) = %v", err)
+	}
+
+	in.Add(k2hdkctest.Fault{Op: k2hdkc.OpCasIncrement, Times: 1, Fail: true, SubCode: k2hdkc.SubCodeTimeout})
+	if err := c.CasIncrement("cas"); !errors.Is(err, k2hdkc.ErrTimeout) || in.Calls(k2hdkc.OpCasIncrement) != 1 {
+		t.Errorf("c.CasIncrement(cas) = %v after %v calls, want 1 call", err, in.Calls(k2hdkc.OpCasIncrement))
+	}
+
+	in.Add(k2hdkctest.Fault{Op: k2hdkc.OpCasIncrement, Times: 1, Fail: true, SubCode: k2hdkc.SubCodeTimeout})
+	p := retryPolicy()
+	p.RetryNonIdempotent = true
+	c.SetRetryPolicy(p)
+	if err := c.CasIncrement("cas"); err != nil || in.Calls(k2hdkc.OpCasIncrement) != 3 {
+		t.Errorf("c.CasIncrement(cas) = %v after %v calls, want 3 calls", err, in.Calls(k2hdkc.OpCasIncrement))
+	}
+	if v, err := c.CasGet("cas"); err != nil || v != 2 {
+		t.Errorf("c.CasGet(cas) = %v, %v, want 2", v, err)
+	}
+}
+
+// TestRetryOpen tests a command is retried if its session fails to open, even if it's not idempotent.
+func TestRetryOpen(t *testing.T) {
+	in := k2hdkctest.NewInjector(k2hdkctest.NewServer()).FailOpen(2)
+	c := in.NewClient().SetMinSessions(0).SetRetryPolicy(retryPolicy())
+	defer c.Close()
+
+	if err := c.QueuePush("q", []byte("v")); err != nil || in.Calls(k2hdkc.OpQueuePush) != 1 {
+		t.Errorf("c.QueuePush(q) = %v after %v calls", err, in.Calls(k2hdkc.OpQueuePush))
+	}
+}
+
+// TestRetryRemove tests a command which fails if it has been applied isn't retried.
+func TestRetryRemove(t *testing.T) {
+	in := k2hdkctest.NewInjector(k2hdkctest.NewServer())
+	c := in.NewClient().SetRetryPolicy(retryPolicy())
+	defer c.Close()
+	if err := c.SetValue("key", []byte("v")); err != nil {
+		t.Fatalf("c.SetValue(key, v) = %v", err)
+	}
+
+	in.Add(k2hdkctest.Fault{Op: k2hdkc.OpRemove, Times: 1, Fail: true, SubCode: k2hdkc.SubCodeTimeout})
+	if err := c.Remove("key"); !errors.Is(err, k2hdkc.ErrTimeout) || in.Calls(k2hdkc.OpRemove) != 1 {
+		t.Errorf("c.Remove(key) = %v after %v calls, want 1 call", err, in.Calls(k2hdkc.OpRemove))
+	}
+}
+
+// TestRetryPermanent tests a command which failed before it was sent isn't retried unless the session failed to open.
+func TestRetryPermanent(t *testing.T) {
+	p := retryPolicy()
+	p.MinBackoff = time.Second
+	p.MaxBackoff = time.Second
+	c := k2hdkctest.NewServer().NewClient().SetRetryPolicy(p)
+	if err := c.SetValue("key", []byte("v")); err != nil {
+		t.Fatalf("c.SetValue(key, v) = %v", err)
+	}
+	c.Close()
+
+	start := time.Now()
+	if _, err := c.GetValue("key"); !errors.Is(err, k2hdkc.ErrPoolClosed) {
+		t.Errorf("c.GetValue(key) of a closed client = %v, want ErrPoolClosed", err)
+	}
+	if d := time.Since(start); d >= p.MinBackoff {
+		t.Errorf("c.GetValue(key) of a closed client took %v, want no retry", d)
+	}
+}
+
+// Local Variables:
+// c-basic-offset: 4
+// tab-width: 4
+// indent-tabs-mode: t
+// End:
+// vim600: noexpandtab sw=4 ts=4 fdm=marker
+// vim<600: noexpandtab sw=4 ts=4
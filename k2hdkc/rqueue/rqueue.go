@@ -0,0 +1,487 @@
+//
+// k2hdkc_go
+//
+// Copyright 2018 Yahoo Japan Corporation.
+//
+// Go driver for k2hdkc that is a highly available and scalable distributed
+// KVS clustering system. For k2hdkc, see
+// https://github.com/yahoojapan/k2hdkc for the details.
+//
+// For the full copyright and license information, please view
+// the license file that was distributed with this source code.
+//
+// AUTHOR:   Hirotaka Wakabayashi
+// CREATE:   Fri, 16 Oct 2026
+// REVISION:
+//
+
+// Package rqueue implements a reliable queue on a k2hdkc cluster.
+//
+// Pop moves a message to the in-flight area instead of removing it. The consumer
+// acks the message after processing it. A message which isn't acked before its
+// visibility deadline goes back to the queue, and a message delivered more than
+// the maximum times goes to the dead-letter queue. Messages are delivered at
+// least once.
+//
+// A Queue of the name uses these keys.
+//
+//	name/ready         key whose subkeys are the ready messages in the push order.
+//	name/ready/id      message id.
+//	name/msg/id        message body.
+//	name/count/id      cas64 delivery count.
+//	name/inflight      key whose subkeys are the in-flight messages.
+//	name/inflight/id/n visibility deadline of the n-th delivery of the message.
+//
+// Pop records the in-flight entry of a delivery and takes the delivery by incrementing
+// the count before the message leaves the ready list, so a message is never out of both
+// lists even if the consumer crashes in Pop. The delivery count identifies a delivery,
+// so Ack and Nack of a delivery whose message has been requeued and delivered again
+// fail with ErrNotInFlight.
+package rqueue
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/yahoojapan/k2hdkc_go/k2hdkc"
+)
+
+var (
+	// ErrEmpty is returned by Pop if no message is ready.
+	ErrEmpty = errors.New("rqueue: queue is empty")
+	// ErrNotInFlight is returned by Ack and Nack if the message has been requeued or acked.
+	ErrNotInFlight = errors.New("rqueue: message is not in flight")
+)
+
+const (
+	defaultVisibilityTimeout = 30 * time.Second
+	defaultMaxDeliveries     = 5
+	defaultScanInterval      = time.Second
+)
+
+// Option sets an optional parameter of a Queue.
+type Option func(*Queue)
+
+// WithVisibilityTimeout sets the time a consumer has to ack a popped message.
+func WithVisibilityTimeout(d time.Duration) Option {
+	return func(q *Queue) {
+		if d > 0 {
+			q.visibility = d
+		}
+	}
+}
+
+// WithMaxDeliveries sets the number of deliveries after which a message goes to the dead-letter queue.
+// Zero means messages are delivered forever.
+func WithMaxDeliveries(n int) Option {
+	return func(q *Queue) {
+		if n >= 0 {
+			q.maxDeliveries = n
+		}
+	}
+}
+
+// WithDeadLetter sets the name of the dead-letter queue. The default is "name/dead".
+func WithDeadLetter(name string) Option {
+	return func(q *Queue) {
+		if name != "" {
+			q.deadName = name
+		}
+	}
+}
+
+// WithScanInterval sets the minimum interval Pop requeues the expired in-flight messages.
+func WithScanInterval(d time.Duration) Option {
+	return func(q *Queue) {
+		if d > 0 {
+			q.scan = d
+		}
+	}
+}
+
+// Message is a message popped from a Queue.
+type Message struct {
+	ID         string
+	Body       []byte
+	Deliveries int       // number of times the message has been delivered including this one. It identifies the delivery.
+	Deadline   time.Time // the message is requeued if it isn't acked by the deadline.
+}
+
+// String returns a text representation of the object.
+func (m *Message) String() string {
+	return fmt.Sprintf("[%v, %v, %v]", m.ID, m.Deliveries, m.Deadline)
+}
+
+// Queue is a reliable queue of the name. A Queue is safe for concurrent use by multiple goroutines.
+type Queue struct {
+	client        *k2hdkc.Client
+	name          string
+	deadName      string
+	visibility    time.Duration
+	maxDeliveries int
+	scan          time.Duration
+
+	mu       sync.Mutex
+	scanned  time.Time
+	prepared bool
+}
+
+// New returns the pointer to a Queue of the name.
+func New(c *k2hdkc.Client, name string, opts ...Option) *Queue {
+	q := &Queue{
+		client:        c,
+		name:          name,
+		deadName:      name + "/dead",
+		visibility:    defaultVisibilityTimeout,
+		maxDeliveries: defaultMaxDeliveries,
+		scan:          defaultScanInterval,
+	}
+	for _, opt := range opts {
+		if opt != nil {
+			opt(q)
+		}
+	}
+	return q
+}
+
+// String returns a text representation of the object.
+func (q *Queue) String() string {
+	return fmt.Sprintf("[%v, %v, %v, %v]", q.name, q.deadName, q.visibility, q.maxDeliveries)
+}
+
+// DeadLetter returns the dead-letter queue. Messages in it are delivered forever.
+func (q *Queue) DeadLetter() *Queue {
+	return New(q.client, q.deadName, WithVisibilityTimeout(q.visibility), WithMaxDeliveries(0), WithScanInterval(q.scan))
+}
+
+func (q *Queue) readyKey() string            { return q.name + "/ready" }
+func (q *Queue) readyIDKey(id string) string { return q.readyKey() + "/" + id }
+func (q *Queue) msgKey(id string) string     { return q.name + "/msg/" + id }
+func (q *Queue) countKey(id string) string   { return q.name + "/count/" + id }
+func (q *Queue) inflightKey() string         { return q.name + "/inflight" }
+func (q *Queue) inflightIDKey(id string, n uint64) string {
+	return q.inflightKey() + "/" + id + "/" + strconv.FormatUint(n, 10)
+}
+
+// newID returns a random message id.
+func newID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// prepare creates the keys which hold the ready and the in-flight messages as subkeys.
+func (q *Queue) prepare(ctx context.Context) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.prepared {
+		return nil
+	}
+	// Set keeps the subkeys of an existing key.
+	for _, key := range []string{q.readyKey(), q.inflightKey()} {
+		if err := q.client.SetValueContext(ctx, key, []byte(q.name)); err != nil {
+			return err
+		}
+	}
+	q.prepared = true
+	return nil
+}
+
+// Push adds the message body to the queue and returns the message id.
+func (q *Queue) Push(ctx context.Context, body []byte) (string, error) {
+	id, err := newID()
+	if err != nil {
+		return "", err
+	}
+	if err := q.push(ctx, id, body, 0); err != nil {
+		return "", err
+	}
+	return id, nil
+}
+
+// push saves the message and adds it to the ready list.
+func (q *Queue) push(ctx context.Context, id string, body []byte, deliveries uint64) error {
+	if err := q.prepare(ctx); err != nil {
+		return err
+	}
+	if err := q.client.SetValueContext(ctx, q.msgKey(id), body); err != nil {
+		return err
+	}
+	if err := q.client.CasInitContext(ctx, q.countKey(id), deliveries); err != nil {
+		return err
+	}
+	return q.ready(ctx, id)
+}
+
+// ready adds the message to the ready list. A listed message keeps its place.
+func (q *Queue) ready(ctx context.Context, id string) error {
+	return q.client.AddSubKeyContext(ctx, q.readyKey(), q.readyIDKey(id), []byte(id))
+}
+
+// unready removes the message from the ready list.
+func (q *Queue) unready(ctx context.Context, id string) error {
+	err := q.client.RemoveSubKeyContext(ctx, q.readyKey(), q.readyIDKey(id))
+	if errors.Is(err, k2hdkc.ErrNotFound) {
+		return nil
+	}
+	return err
+}
+
+// Pop returns the next message and moves it to the in-flight area. ErrEmpty returns if no message is ready.
+// Expired in-flight messages are requeued at most once in the scan interval.
+func (q *Queue) Pop(ctx context.Context) (*Message, error) {
+	if err := q.prepare(ctx); err != nil {
+		return nil, err
+	}
+	q.mu.Lock()
+	scan := time.Since(q.scanned) >= q.scan
+	if scan {
+		q.scanned = time.Now()
+	}
+	q.mu.Unlock()
+	if scan {
+		if _, err := q.RequeueExpired(ctx); err != nil {
+			return nil, err
+		}
+	}
+	sks, err := q.client.GetSubKeyListContext(ctx, q.readyKey())
+	if err != nil {
+		return nil, err
+	}
+	prefix := q.readyKey() + "/"
+	for _, sk := range sks {
+		id := strings.TrimPrefix(sk, prefix)
+		if id == sk || id == "" || strings.Contains(id, "/") {
+			continue
+		}
+		m, err := q.take(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		if m != nil {
+			return m, nil
+		}
+	}
+	return nil, ErrEmpty
+}
+
+// take delivers the ready message of the id. nil returns if the message is skipped because
+// another consumer has taken it, it has been acked or it has been buried. A failure at
+// any step leaves the message in the ready list or in the in-flight area.
+func (q *Queue) take(ctx context.Context, id string) (*Message, error) {
+	n, err := q.client.CasGetContext(ctx, q.countKey(id))
+	if errors.Is(err, k2hdkc.ErrNotFound) {
+		// acked after it was requeued.
+		return nil, q.unready(ctx, id)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if n > 0 {
+		deadline, err := q.deadline(ctx, id, n)
+		switch {
+		case err == ErrNotInFlight:
+		case err != nil:
+			return nil, err
+		case time.Now().Before(deadline):
+			// another consumer is removing it from the ready list or it's being nacked.
+			return nil, nil
+		default:
+			// the last delivery has expired. Claiming it makes a late Ack of it fail.
+			if err := q.claim(ctx, id, n); err != nil {
+				if err == ErrNotInFlight {
+					return nil, nil
+				}
+				return nil, err
+			}
+		}
+	}
+	if q.maxDeliveries > 0 && n >= (uint64)(q.maxDeliveries) {
+		return nil, q.bury(ctx, id, n)
+	}
+	m := &Message{ID: id, Deliveries: (int)(n + 1), Deadline: time.Now().Add(q.visibility)}
+	deadline := strconv.FormatInt(m.Deadline.UnixNano(), 10)
+	// the entry is recorded first. Consumers racing for the delivery record the same entry.
+	if err := q.client.AddSubKeyContext(ctx, q.inflightKey(), q.inflightIDKey(id, n+1), []byte(deadline)); err != nil {
+		return nil, err
+	}
+	if err := q.client.CasSetContext(ctx, q.countKey(id), n, n+1); err != nil {
+		if errors.Is(err, k2hdkc.ErrCasConflict) {
+			// another consumer has taken the delivery and owns the entry.
+			return nil, nil
+		}
+		// RequeueExpired drops the entry of the delivery which hasn't been taken.
+		return nil, err
+	}
+	// the message left in the ready list by a failure is skipped while it's in flight.
+	q.unready(ctx, id)
+	body, err := q.client.GetValueContext(ctx, q.msgKey(id))
+	if errors.Is(err, k2hdkc.ErrNotFound) {
+		// acked after it was requeued.
+		q.claim(ctx, id, n+1)
+		return nil, nil
+	}
+	if err != nil {
+		// the message goes back to the queue after the deadline.
+		return nil, err
+	}
+	m.Body = body
+	return m, nil
+}
+
+// deadline returns the visibility deadline of the n-th delivery of the message. ErrNotInFlight
+// returns if the delivery isn't in flight. The zero time returns if the deadline is broken.
+func (q *Queue) deadline(ctx context.Context, id string, n uint64) (time.Time, error) {
+	v, err := q.client.GetValueContext(ctx, q.inflightIDKey(id, n))
+	if errors.Is(err, k2hdkc.ErrNotFound) {
+		return time.Time{}, ErrNotInFlight
+	}
+	if err != nil {
+		return time.Time{}, err
+	}
+	d, err := strconv.ParseInt(string(v), 10, 64)
+	if err != nil {
+		return time.Time{}, nil
+	}
+	return time.Unix(0, d), nil
+}
+
+// bury moves the message to the dead-letter queue keeping the delivery count.
+func (q *Queue) bury(ctx context.Context, id string, deliveries uint64) error {
+	body, err := q.client.GetValueContext(ctx, q.msgKey(id))
+	if errors.Is(err, k2hdkc.ErrNotFound) {
+		// acked after it was requeued.
+		if err := q.unready(ctx, id); err != nil {
+			return err
+		}
+		return q.remove(ctx, id)
+	}
+	if err != nil {
+		return err
+	}
+	if err := q.DeadLetter().push(ctx, id, body, deliveries); err != nil {
+		return err
+	}
+	if err := q.unready(ctx, id); err != nil {
+		return err
+	}
+	return q.remove(ctx, id)
+}
+
+// remove removes the message and its delivery count.
+func (q *Queue) remove(ctx context.Context, id string) error {
+	if err := q.client.RemoveContext(ctx, q.msgKey(id)); err != nil && !errors.Is(err, k2hdkc.ErrNotFound) {
+		return err
+	}
+	if err := q.client.RemoveContext(ctx, q.countKey(id)); err != nil && !errors.Is(err, k2hdkc.ErrNotFound) {
+		return err
+	}
+	return nil
+}
+
+// claim removes the n-th delivery of the message from the in-flight area. Only one of
+// the consumers racing for the delivery succeeds.
+func (q *Queue) claim(ctx context.Context, id string, n uint64) error {
+	err := q.client.RemoveSubKeyContext(ctx, q.inflightKey(), q.inflightIDKey(id, n))
+	if errors.Is(err, k2hdkc.ErrNotFound) {
+		return ErrNotInFlight
+	}
+	return err
+}
+
+// Ack removes the processed message. ErrNotInFlight returns if the message has been requeued.
+func (q *Queue) Ack(ctx context.Context, m *Message) error {
+	if err := q.claim(ctx, m.ID, (uint64)(m.Deliveries)); err != nil {
+		return err
+	}
+	return q.remove(ctx, m.ID)
+}
+
+// Nack requeues the message at once. The message is listed before its delivery is claimed
+// lest it be lost, and Pop skips it until the delivery is claimed.
+func (q *Queue) Nack(ctx context.Context, m *Message) error {
+	if err := q.ready(ctx, m.ID); err != nil {
+		return err
+	}
+	return q.claim(ctx, m.ID, (uint64)(m.Deliveries))
+}
+
+// RequeueExpired moves the in-flight messages whose deadline has passed to the queue and returns the number of them.
+// The expired entries of deliveries which Pop failed to take are dropped.
+func (q *Queue) RequeueExpired(ctx context.Context) (int, error) {
+	if err := q.prepare(ctx); err != nil {
+		return 0, err
+	}
+	sks, err := q.client.GetSubKeyListContext(ctx, q.inflightKey())
+	if err != nil {
+		return 0, err
+	}
+	prefix := q.inflightKey() + "/"
+	n := 0
+	now := time.Now()
+	for _, sk := range sks {
+		if !strings.HasPrefix(sk, prefix) {
+			continue
+		}
+		i := strings.LastIndex(sk, "/")
+		id := strings.TrimPrefix(sk[:i], prefix)
+		count, perr := strconv.ParseUint(sk[i+1:], 10, 64)
+		if perr != nil || id == "" {
+			continue
+		}
+		deadline, err := q.deadline(ctx, id, count)
+		if err == ErrNotInFlight || (err == nil && now.Before(deadline)) {
+			continue
+		}
+		if err != nil {
+			return n, err
+		}
+		cur, err := q.client.CasGetContext(ctx, q.countKey(id))
+		if err != nil && !errors.Is(err, k2hdkc.ErrNotFound) {
+			return n, err
+		}
+		requeue := err == nil && cur == count
+		if requeue {
+			// the message is listed before the delivery is claimed lest it be lost.
+			if err := q.ready(ctx, id); err != nil {
+				return n, err
+			}
+		}
+		if err := q.claim(ctx, id, count); err != nil {
+			if err == ErrNotInFlight {
+				continue
+			}
+			return n, err
+		}
+		if requeue {
+			n++
+		}
+	}
+	return n, nil
+}
+
+// Deliveries returns the number of times the message of the id has been delivered.
+func (q *Queue) Deliveries(ctx context.Context, id string) (int, error) {
+	n, err := q.client.CasGetContext(ctx, q.countKey(id))
+	if err != nil {
+		return 0, err
+	}
+	return (int)(n), nil
+}
+
+// Local Variables:
+// c-basic-offset: 4
+// tab-width: 4
+// indent-tabs-mode: t
+// End:
+// vim600: noexpandtab sw=4 ts=4 fdm=marker
+// vim<600: noexpandtab sw=4 ts=4
@@ -0,0 +1,299 @@
+//
+// k2hdkc_go
+//
+// Copyright 2018 Yahoo Japan Corporation.
+//
+// Go driver for k2hdkc that is a highly available and scalable distributed
+// KVS clustering system. For k2hdkc, see
+// https://github.com/yahoojapan/k2hdkc for the details.
+//
+// For the full copyright and license information, please view
+// the license file that was distributed with this source code.
+//
+// AUTHOR:   Hirotaka Wakabayashi
+// CREATE:   Fri, 16 Oct 2026
+// REVISION:
+//
+
+package rqueue
+
+import (
+	"context"
+	"regexp"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/yahoojapan/k2hdkc_go/k2hdkc"
+	"github.com/yahoojapan/k2hdkc_go/k2hdkc/k2hdkctest"
+)
+
+// TestQueueAck tests an acked message isn't delivered again.
+func TestQueueAck(t *testing.T) {
+	s := k2hdkctest.NewServer()
+	c := s.NewClient()
+	defer c.Close()
+	ctx := context.Background()
+
+	q := New(c, "rq")
+	id, err := q.Push(ctx, []byte("hello"))
+	if err != nil {
+		t.Fatalf("q.Push(ctx, hello) = %v", err)
+	}
+	m, err := q.Pop(ctx)
+	if err != nil {
+		t.Fatalf("q.Pop(ctx) = %v", err)
+	}
+	if m.ID != id || string(m.Body) != "hello" || m.Deliveries != 1 {
+		t.Errorf("q.Pop(ctx) = %v %v, want %v hello 1st delivery", m, string(m.Body), id)
+	}
+	if _, err := q.Pop(ctx); err != ErrEmpty {
+		t.Errorf("q.Pop(ctx) of an empty queue = %v, want ErrEmpty", err)
+	}
+	if err := q.Ack(ctx, m); err != nil {
+		t.Fatalf("q.Ack(ctx, m) = %v", err)
+	}
+	if err := q.Ack(ctx, m); err != ErrNotInFlight {
+		t.Errorf("q.Ack(ctx, m) twice = %v, want ErrNotInFlight", err)
+	}
+	if n, err := q.RequeueExpired(ctx); n != 0 || err != nil {
+		t.Errorf("q.RequeueExpired(ctx) = (%v, %v), want 0", n, err)
+	}
+}
+
+// TestQueueVisibility tests an expired message is delivered again and goes to the dead-letter queue at last.
+func TestQueueVisibility(t *testing.T) {
+	s := k2hdkctest.NewServer()
+	c := s.NewClient()
+	defer c.Close()
+	ctx := context.Background()
+
+	q := New(c, "rq", WithVisibilityTimeout(10*time.Millisecond), WithMaxDeliveries(2), WithScanInterval(time.Millisecond))
+	id, err := q.Push(ctx, []byte("hello"))
+	if err != nil {
+		t.Fatalf("q.Push(ctx, hello) = %v", err)
+	}
+	for i := 1; i <= 2; i++ {
+		m, err := q.Pop(ctx)
+		if err != nil {
+			t.Fatalf("q.Pop(ctx) #%v = %v", i, err)
+		}
+		if m.Deliveries != i {
+			t.Errorf("m.Deliveries = %v, want %v", m.Deliveries, i)
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	if _, err := q.Pop(ctx); err != ErrEmpty {
+		t.Errorf("q.Pop(ctx) after max deliveries = %v, want ErrEmpty", err)
+	}
+	dlq := q.DeadLetter()
+	m, err := dlq.Pop(ctx)
+	if err != nil {
+		t.Fatalf("dlq.Pop(ctx) = %v", err)
+	}
+	if m.ID != id || string(m.Body) != "hello" || m.Deliveries != 3 {
+		t.Errorf("dlq.Pop(ctx) = %v, want %v with 3 deliveries", m, id)
+	}
+	if err := dlq.Ack(ctx, m); err != nil {
+		t.Errorf("dlq.Ack(ctx, m) = %v", err)
+	}
+}
+
+// TestQueueNack tests a nacked message is delivered at once.
+func TestQueueNack(t *testing.T) {
+	s := k2hdkctest.NewServer()
+	c := s.NewClient()
+	defer c.Close()
+	ctx := context.Background()
+
+	q := New(c, "rq")
+	if _, err := q.Push(ctx, []byte("hello")); err != nil {
+		t.Fatalf("q.Push(ctx, hello) = %v", err)
+	}
+	m, err := q.Pop(ctx)
+	if err != nil {
+		t.Fatalf("q.Pop(ctx) = %v", err)
+	}
+	if err := q.Nack(ctx, m); err != nil {
+		t.Fatalf("q.Nack(ctx, m) = %v", err)
+	}
+	m, err = q.Pop(ctx)
+	if err != nil || m.Deliveries != 2 {
+		t.Fatalf("q.Pop(ctx) after Nack = (%v, %v), want the 2nd delivery", m, err)
+	}
+	if n, err := q.Deliveries(ctx, m.ID); n != 2 || err != nil {
+		t.Errorf("q.Deliveries(ctx, id) = (%v, %v), want 2", n, err)
+	}
+}
+
+// TestQueueLateAck tests an ack of a requeued delivery doesn't remove the next delivery.
+func TestQueueLateAck(t *testing.T) {
+	s := k2hdkctest.NewServer()
+	c := s.NewClient()
+	defer c.Close()
+	ctx := context.Background()
+
+	q := New(c, "rq", WithVisibilityTimeout(10*time.Millisecond), WithScanInterval(time.Hour))
+	if _, err := q.Push(ctx, []byte("hello")); err != nil {
+		t.Fatalf("q.Push(ctx, hello) = %v", err)
+	}
+	m1, err := q.Pop(ctx)
+	if err != nil {
+		t.Fatalf("q.Pop(ctx) = %v", err)
+	}
+	time.Sleep(20 * time.Millisecond)
+	if n, err := q.RequeueExpired(ctx); n != 1 || err != nil {
+		t.Fatalf("q.RequeueExpired(ctx) = (%v, %v), want 1", n, err)
+	}
+	m2, err := q.Pop(ctx)
+	if err != nil || m2.ID != m1.ID || m2.Deliveries != 2 {
+		t.Fatalf("q.Pop(ctx) after requeue = (%v, %v), want the 2nd delivery of %v", m2, err, m1.ID)
+	}
+	if err := q.Ack(ctx, m1); err != ErrNotInFlight {
+		t.Errorf("q.Ack(ctx, m1) of the requeued delivery = %v, want ErrNotInFlight", err)
+	}
+	if err := q.Nack(ctx, m1); err != ErrNotInFlight {
+		t.Errorf("q.Nack(ctx, m1) of the requeued delivery = %v, want ErrNotInFlight", err)
+	}
+	if err := q.Ack(ctx, m2); err != nil {
+		t.Errorf("q.Ack(ctx, m2) = %v", err)
+	}
+}
+
+// TestQueuePopFault tests a message isn't lost when a step of Pop fails, which is also
+// what a consumer crashing at the step leaves, since Pop never undoes a step.
+func TestQueuePopFault(t *testing.T) {
+	testData := []struct {
+		name      string
+		fault     k2hdkctest.Fault
+		delivered bool // Pop delivers the message in spite of the fault.
+	}{
+		{name: "GetSubKeys", fault: k2hdkctest.Fault{Op: k2hdkc.OpGetSubKeys, Key: regexp.MustCompile("^rq/ready")}},
+		{name: "CasGet", fault: k2hdkctest.Fault{Op: k2hdkc.OpCasGet, Key: regexp.MustCompile("^rq/count/")}},
+		{name: "AddSubKey", fault: k2hdkctest.Fault{Op: k2hdkc.OpAddSubKey, Key: regexp.MustCompile("^rq/inflight")}},
+		{name: "CasSet", fault: k2hdkctest.Fault{Op: k2hdkc.OpCasSet, Key: regexp.MustCompile("^rq/count/")}},
+		{name: "RemoveSubKey", fault: k2hdkctest.Fault{Op: k2hdkc.OpRemoveSubKey, Key: regexp.MustCompile("^rq/ready")}, delivered: true},
+		{name: "Get", fault: k2hdkctest.Fault{Op: k2hdkc.OpGet, Key: regexp.MustCompile("^rq/msg/")}},
+		{name: "Bury", fault: k2hdkctest.Fault{Op: k2hdkc.OpAddSubKey, Key: regexp.MustCompile("^rq/dead/ready")}},
+	}
+	for _, d := range testData {
+		s := k2hdkctest.NewServer()
+		in := k2hdkctest.NewInjector(s)
+		c := in.NewClient()
+		ctx := context.Background()
+
+		maxDeliveries := 5
+		if d.name == "Bury" {
+			maxDeliveries = 1
+		}
+		q := New(c, "rq", WithVisibilityTimeout(10*time.Millisecond), WithMaxDeliveries(maxDeliveries), WithScanInterval(time.Millisecond))
+		id, err := q.Push(ctx, []byte("hello"))
+		if err != nil {
+			t.Fatalf("%v: q.Push(ctx, hello) = %v", d.name, err)
+		}
+		if d.name == "Bury" {
+			// the 2nd delivery buries the message.
+			m, err := q.Pop(ctx)
+			if err != nil {
+				t.Fatalf("%v: q.Pop(ctx) = %v", d.name, err)
+			}
+			if err := q.Nack(ctx, m); err != nil {
+				t.Fatalf("%v: q.Nack(ctx, m) = %v", d.name, err)
+			}
+		}
+		d.fault.Fail = true
+		d.fault.Times = 1
+		d.fault.SubCode = k2hdkc.SubCodeTimeout
+		in.Add(d.fault)
+		if m, err := q.Pop(ctx); d.delivered {
+			if err != nil || m.ID != id {
+				t.Errorf("%v: q.Pop(ctx) = (%v, %v), want %v", d.name, m, err, id)
+			}
+			// the message left in the ready list isn't delivered while it's in flight.
+			if m, err := q.Pop(ctx); err != ErrEmpty {
+				t.Errorf("%v: q.Pop(ctx) while in flight = (%v, %v), want ErrEmpty", d.name, m, err)
+			}
+		} else if err == nil {
+			t.Errorf("%v: q.Pop(ctx) = %v, want an error", d.name, m)
+		}
+		in.Clear()
+		time.Sleep(20 * time.Millisecond)
+		if d.name == "Bury" {
+			// the message back in the queue is buried again.
+			if m, err := q.Pop(ctx); err != ErrEmpty {
+				t.Errorf("%v: q.Pop(ctx) after the failure = (%v, %v), want ErrEmpty", d.name, m, err)
+			}
+			q = q.DeadLetter()
+		}
+		m, err := q.Pop(ctx)
+		if err != nil || m.ID != id || string(m.Body) != "hello" {
+			t.Errorf("%v: q.Pop(ctx) after the failure = (%v, %v), want %v", d.name, m, err, id)
+		} else if err := q.Ack(ctx, m); err != nil {
+			t.Errorf("%v: q.Ack(ctx, m) = %v", d.name, err)
+		}
+		c.Close()
+	}
+}
+
+// TestQueueConcurrent tests consumers racing for messages take each delivery once.
+func TestQueueConcurrent(t *testing.T) {
+	s := k2hdkctest.NewServer()
+	c := s.NewClient()
+	defer c.Close()
+	ctx := context.Background()
+
+	q := New(c, "rq")
+	want := make(map[string]bool)
+	for i := 0; i < 20; i++ {
+		id, err := q.Push(ctx, []byte(strconv.Itoa(i)))
+		if err != nil {
+			t.Fatalf("q.Push(ctx, %v) = %v", i, err)
+		}
+		want[id] = true
+	}
+	var (
+		wg  sync.WaitGroup
+		mu  sync.Mutex
+		got = make(map[string]int)
+	)
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				m, err := q.Pop(ctx)
+				if err == ErrEmpty {
+					return
+				}
+				if err != nil {
+					t.Errorf("q.Pop(ctx) = %v", err)
+					return
+				}
+				mu.Lock()
+				got[m.ID]++
+				mu.Unlock()
+				if err := q.Ack(ctx, m); err != nil {
+					t.Errorf("q.Ack(ctx, m) = %v", err)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	for id := range want {
+		if got[id] != 1 {
+			t.Errorf("the message %v is delivered %v times, want once", id, got[id])
+		}
+	}
+	if len(got) != len(want) {
+		t.Errorf("%v messages are delivered, want %v", len(got), len(want))
+	}
+}
+
+// Local Variables:
+// c-basic-offset: 4
+// tab-width: 4
+// indent-tabs-mode: t
+// End:
+// vim600: noexpandtab sw=4 ts=4 fdm=marker
+// vim<600: noexpandtab sw=4 ts=4
@@ -18,70 +18,139 @@
 package k2hdkc
 
 import (
-	// #cgo CFLAGS: -g -O2 -Wall -Wextra -Wno-unused-variable -Wno-unused-parameter -I. -I/usr/include/k2hdkc
-	// #cgo LDFLAGS: -L/usr/lib -lk2hdkc
-	// #include <stdlib.h>
-	// #include "k2hdkc.h"
-	"C"
-)
-import (
+	"context"
 	"errors"
 	"fmt"
-	"unsafe"
+	"sync"
+	"sync/atomic"
+	"time"
 )
 
-const defaultMaxSession = 1
-
 // Session keeps configurations and is responsible for creating request handlers with a k2hdkc cluster and closing them.
 type Session struct {
-	handler C.k2hdkc_chmpx_h // uint64_t
-	client  *Client
+	conn     Conn // connection opened by the Backend of the client.
+	client   *Client
+	lastUsed time.Time // the time when the session was given back to the pool.
+	mu       sync.Mutex
+	inflight chan struct{} // closed when the last C call on the handler returns.
+	id       uint64        // unique in the process.
 }
 
+// sessionID is the id of the last session opened.
+var sessionID uint64
+
 // String returns a text representation of the object.
 func (s *Session) String() string {
-	return fmt.Sprintf("[%v, %v]", s.handler, s.client)
+	return fmt.Sprintf("[%v, %v, %v]", s.id, s.conn, s.client)
+}
+
+// ID returns the id of the session, which is unique in the process.
+func (s *Session) ID() uint64 {
+	return s.id
 }
 
 // NewSession returns a new chmpx session with a k2hdkc cluster.
 func NewSession(c *Client) (*Session, error) {
-	if unSupportedOs {
-		return nil, errors.New("k2hdkc currently works on linux only")
-	}
-	if unSupportedEndian {
-		return nil, errors.New("k2hdkc_go currently works on little endian alignment only")
-	}
-	if isNotExistLibK2hdkc {
-		return nil, errors.New("Please install the k2hdkc package at first")
-	}
 	if c == nil {
 		return nil, errors.New("client is nil")
 	}
-	file := C.CString(c.file)
-	cuk := C.CString(c.cuk)
-	defer C.free(unsafe.Pointer(file))
-	handler := C.k2hdkc_open_chmpx_full(file, C.short(c.port), cuk, C._Bool(c.rejoin), C._Bool(c.rejoinRetry), C._Bool(c.cleanup))
-	if handler == C.K2HDKC_INVALID_HANDLE {
-		return nil, fmt.Errorf("k2hdkc_open_chmpx_ex() = %v", handler)
+	b := c.backend
+	if b == nil {
+		b = defaultBackend
+	}
+	if b == nil {
+		return nil, ErrNoBackend
+	}
+	conn, err := b.Open(c)
+	c.metrics.sessionOpened(err)
+	if err != nil {
+		return nil, err
 	}
 	return &Session{
-		client:  c,
-		handler: handler,
+		client:   c,
+		conn:     conn,
+		lastUsed: time.Now(),
+		id:       atomic.AddUint64(&sessionID, 1),
 	}, nil
 }
 
 // Close closes a chmpx session with the k2hdkc cluster.
 // NOTICE You must call Close() to avoid leaking file descriptor.
 func (s *Session) Close() error {
+	return s.close()
+}
+
+// close closes the connection only. Sessions kept in the pool of a Client are closed by this method.
+// It waits for a call abandoned by ExecuteContext before closing the connection.
+func (s *Session) close() error {
+	s.wait(context.Background())
+	if s.conn == nil {
+		return nil
+	}
+	err := s.conn.Close()
+	s.conn = nil
 	if s.client != nil {
-		if result := C.k2hdkc_close_chmpx_ex(s.handler, C._Bool(s.client.cleanup)); !result {
-			s.client.log.Warnf("C.k2hdkc_close_chmpx_ex() = %v", result)
-			return fmt.Errorf("C.k2hdkc_close_chmpx_ex() = %v", result)
-		}
-		s.client.Close()
+		s.client.metrics.sessionClosed()
+	}
+	if err != nil && s.client != nil {
+		s.client.Logger().Log(context.Background(), LevelWarn, "failed to close a connection", Field{FieldSession, s.id}, Field{FieldError, err})
+	}
+	return err
+}
+
+// valid returns true if the connection is open.
+func (s *Session) valid() bool {
+	return s.conn != nil
+}
+
+// do sends the request on the connection.
+func (s *Session) do(req *Request) *Response {
+	if s.conn == nil {
+		return &Response{OK: false, Code: ResCodeError, SubCode: SubCodeInvalid}
+	}
+	return s.conn.Do(req)
+}
+
+// Busy returns true if a C call abandoned by ExecuteContext is still running on the session.
+// A busy session must not be used until the call returns.
+func (s *Session) Busy() bool {
+	s.mu.Lock()
+	ch := s.inflight
+	s.mu.Unlock()
+	if ch == nil {
+		return false
+	}
+	select {
+	case <-ch:
+		return false
+	default:
+		return true
+	}
+}
+
+// begin marks the session busy until the returned channel is closed.
+func (s *Session) begin() chan struct{} {
+	ch := make(chan struct{})
+	s.mu.Lock()
+	s.inflight = ch
+	s.mu.Unlock()
+	return ch
+}
+
+// wait blocks until the running C call returns or the context is done.
+func (s *Session) wait(ctx context.Context) error {
+	s.mu.Lock()
+	ch := s.inflight
+	s.mu.Unlock()
+	if ch == nil {
+		return nil
+	}
+	select {
+	case <-ch:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
 	}
-	s.handler = C.K2HDKC_INVALID_HANDLE
-	return nil
 }
 
 // Local Variables:
@@ -17,19 +17,11 @@
 
 package k2hdkc
 
-import (
-	// #cgo CFLAGS: -g -O2 -Wall -Wextra -Wno-unused-variable -Wno-unused-parameter -I. -I/usr/include/k2hdkc
-	// #cgo LDFLAGS: -L/usr/lib -lk2hdkc
-	// #include <stdlib.h>
-	// #include "k2hdkc.h"
-	// #include "k2hmacro.h"
-	"C"
-)
 import (
 	"bytes"
+	"context"
 	"errors"
 	"fmt"
-	"unsafe"
 )
 
 // Set holds arguments for C.k2hdkc_pm_set_value_wa and a pointer of SetResult.
@@ -49,9 +41,9 @@ func (r *Set) String() string {
 
 // SetResult holds the result of Set.Execute().
 type SetResult struct {
-	ok         C._Bool
-	resCode    C.dkcres_type_t // response
-	subResCode C.dkcres_type_t // response(details)
+	ok         bool
+	resCode    ResCode // response
+	subResCode SubCode // response(details)
 }
 
 // String returns a text representation of the object.
@@ -60,6 +52,7 @@ func (r *SetResult) String() string {
 }
 
 // NewSet returns a new Set.
+// A string value is saved with the terminating NUL like StringCodec and a []byte value as it is like RawCodec.
 func NewSet(k interface{}, v interface{}) (*Set, error) {
 	// key
 	var key []byte
@@ -137,35 +130,26 @@ func (r *Set) Execute(s *Session) (bool, error) {
 	if r.key == nil || len(r.key) == 0 || r.val == nil || r.result == nil {
 		return false, fmt.Errorf("required members nil, r.key %v, r.val %v, r.result %v", r.key, r.val, r.result)
 	}
-	cKey := C.CBytes(r.key)
-	defer C.free(cKey)
-	cVal := C.CBytes(r.val)
-	defer C.free(cVal)
-	cPass := C.CString(r.pass)
-	defer C.free(unsafe.Pointer(cPass))
-	var expire *C.time_t
-	// WARNING: You can't set zero expire.
-	if r.expire != 0 {
-		expire = (*C.time_t)(&r.expire)
-	}
-	r.result.ok = C.k2hdkc_pm_set_value_wa(
-		s.handler,
-		(*C.uchar)(cKey),
-		C.size_t(len(r.key)),
-		(*C.uchar)(cVal),
-		C.size_t(len(r.val)),
-		C._Bool(r.rmSubKeyList),
-		cPass,
-		expire)
-	r.result.resCode = C.k2hdkc_get_res_code(s.handler)
-	r.result.subResCode = C.k2hdkc_get_res_subcode(s.handler)
-
+	res := s.do(&Request{
+		Op:           OpSet,
+		Key:          r.key,
+		Val:          r.val,
+		RmSubKeyList: r.rmSubKeyList,
+		Pass:         r.pass,
+		Expire:       r.expire,
+	})
+	r.result.ok, r.result.resCode, r.result.subResCode = res.OK, res.Code, res.SubCode
 	if r.result.ok == false {
-		return false, errors.New("C.k2hdkc_pm_set_value_wa returned false")
+		return false, resError("C.k2hdkc_pm_set_value_wa", res)
 	}
 	return true, nil
 }
 
+// ExecuteContext calls Execute in a goroutine and returns the context error if the context is done before Execute returns.
+func (r *Set) ExecuteContext(ctx context.Context, s *Session) (bool, error) {
+	return executeContext(ctx, s, r)
+}
+
 // Result returns the pointer of SetResult that has the result of Execute method.
 func (r *Set) Result() *SetResult {
 	if r.result != nil {
@@ -184,9 +168,17 @@ func (r *SetResult) Bool() bool {
 
 // Error returns the errno of C.k2hdkc_pm_set_value_wa in string format.
 func (r *SetResult) Error() string {
-	return fmt.Sprintf("%v %v",
-		C.GoString(C.str_dkcres_result_type(r.resCode)),
-		C.GoString(C.str_dkcres_subcode_type(r.subResCode)))
+	return fmt.Sprintf("%v %v", r.resCode, r.subResCode)
+}
+
+// Code returns the result code of the request.
+func (r *SetResult) Code() ResCode {
+	return r.resCode
+}
+
+// SubCode returns the sub code of the request.
+func (r *SetResult) SubCode() SubCode {
+	return r.subResCode
 }
 
 // Local Variables:
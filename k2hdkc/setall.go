@@ -17,19 +17,11 @@
 
 package k2hdkc
 
-import (
-	// #cgo CFLAGS: -g -O2 -Wall -Wextra -Wno-unused-variable -Wno-unused-parameter -I. -I/usr/include/k2hdkc
-	// #cgo LDFLAGS: -L/usr/lib -lk2hdkc
-	// #include <stdlib.h>
-	// #include "k2hdkc.h"
-	// #include "k2hmacro.h"
-	"C"
-)
 import (
 	"bytes"
+	"context"
 	"errors"
 	"fmt"
-	"unsafe"
 )
 
 // SetAll holds arguments for C.k2hdkc_pm_set_all_wa and a pointer of SetAllResult.
@@ -49,9 +41,9 @@ func (r *SetAll) String() string {
 
 // SetAllResult holds the result of SetAll.Execute().
 type SetAllResult struct {
-	ok         C._Bool
-	resCode    C.dkcres_type_t // response
-	subResCode C.dkcres_type_t // response(details)
+	ok         bool
+	resCode    ResCode // response
+	subResCode SubCode // response(details)
 }
 
 // String returns a text representation of the object.
@@ -111,7 +103,7 @@ func NewSetAll(k interface{}, v interface{}, sk interface{}) (*SetAll, error) {
 			skeys = append(skeys, buf.Bytes())
 		}
 	case []string:
-		skeys = make([][]byte, len(sk.([]string)))
+		skeys = make([][]byte, 0, len(sk.([]string)))
 		for _, s := range sk.([]string) {
 			if len(s) > 0 {
 				var buf bytes.Buffer
@@ -132,7 +124,7 @@ func NewSetAll(k interface{}, v interface{}, sk interface{}) (*SetAll, error) {
 	}
 	c := &SetAll{
 		key:    key,
-		val:    key,
+		val:    val,
 		skeys:  skeys,
 		pass:   "",
 		expire: 0,
@@ -141,6 +133,16 @@ func NewSetAll(k interface{}, v interface{}, sk interface{}) (*SetAll, error) {
 	return c, nil
 }
 
+// SetEncPass sets the pass member.
+func (r *SetAll) SetEncPass(s string) {
+	r.pass = s
+}
+
+// SetExpire sets the expire member.
+func (r *SetAll) SetExpire(t int64) {
+	r.expire = t
+}
+
 // Execute calls the C.k2hdkc_pm_set_all_wa function.
 func (r *SetAll) Execute(s *Session) (bool, error) {
 
@@ -148,46 +150,26 @@ func (r *SetAll) Execute(s *Session) (bool, error) {
 	if r.key == nil || len(r.key) == 0 || r.val == nil || r.skeys == nil || r.result == nil {
 		return false, fmt.Errorf("some required members nil, r.key %v, r.val %v, r.skeys %v, r.result %v", r.key, r.val, r.skeys, r.result)
 	}
-	pack := make([]C.PK2HDKCKEYPCK, len(r.skeys))
-	for i, skey := range r.skeys {
-		key := (*C.uchar)(C.CBytes(skey))
-		defer C.free(unsafe.Pointer(key))
-		length := C.size_t(len(skey))
-		pack[i] = &C.K2HKEYPCK{length: length, pkey: key}
-	}
-
-	cKey := C.CBytes(r.key)
-	defer C.free(unsafe.Pointer(cKey))
-	cVal := C.CBytes(r.val)
-	defer C.free(unsafe.Pointer(cVal))
-	// pass(default is nil) is optional. Go nil is eqaul to C NULL.
-	// The pass argment of NULL is acceptable for the k2hdkc C API.
-	cPass := C.CString(r.pass)
-	defer C.free(unsafe.Pointer(cPass))
-	var expire *C.time_t
-	// WARNING: You can't set zero expire.
-	if r.expire != 0 {
-		expire = (*C.time_t)(&r.expire)
-	}
-	r.result.ok = C.k2hdkc_pm_set_all_wa(
-		s.handler,
-		(*C.uchar)(cKey),
-		(C.size_t)(len(r.key)),
-		(*C.uchar)(cVal),
-		(C.size_t)(len(r.val)),
-		pack[0],
-		(C.int)(len(pack)),
-		cPass,
-		expire)
-	r.result.resCode = C.k2hdkc_get_res_code(s.handler)
-	r.result.subResCode = C.k2hdkc_get_res_subcode(s.handler)
-
+	res := s.do(&Request{
+		Op:      OpSetAll,
+		Key:     r.key,
+		Val:     r.val,
+		SubKeys: r.skeys,
+		Pass:    r.pass,
+		Expire:  r.expire,
+	})
+	r.result.ok, r.result.resCode, r.result.subResCode = res.OK, res.Code, res.SubCode
 	if r.result.ok == false {
-		return false, errors.New("C.k2hdkc_pm_set_all_wa returned false")
+		return false, resError("C.k2hdkc_pm_set_all_wa", res)
 	}
 	return true, nil
 }
 
+// ExecuteContext calls Execute in a goroutine and returns the context error if the context is done before Execute returns.
+func (r *SetAll) ExecuteContext(ctx context.Context, s *Session) (bool, error) {
+	return executeContext(ctx, s, r)
+}
+
 // Result returns the pointer of SetAllResult that has the result of Execute method.
 func (r *SetAll) Result() *SetAllResult {
 	if r.result != nil {
@@ -206,9 +188,17 @@ func (r *SetAllResult) Bool() bool {
 
 // Error returns the errno of C.k2hdkc_pm_set_all_wa in string format.
 func (r *SetAllResult) Error() string {
-	return fmt.Sprintf("%v %v",
-		C.GoString(C.str_dkcres_result_type(r.resCode)),
-		C.GoString(C.str_dkcres_subcode_type(r.subResCode)))
+	return fmt.Sprintf("%v %v", r.resCode, r.subResCode)
+}
+
+// Code returns the result code of the request.
+func (r *SetAllResult) Code() ResCode {
+	return r.resCode
+}
+
+// SubCode returns the sub code of the request.
+func (r *SetAllResult) SubCode() SubCode {
+	return r.subResCode
 }
 
 // Local Variables:
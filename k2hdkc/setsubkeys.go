@@ -17,19 +17,11 @@
 
 package k2hdkc
 
-import (
-	// #cgo CFLAGS: -g -O2 -Wall -Wextra -Wno-unused-variable -Wno-unused-parameter -I. -I/usr/include/k2hdkc
-	// #cgo LDFLAGS: -L/usr/lib -lk2hdkc
-	// #include <stdlib.h>
-	// #include "k2hdkc.h"
-	// #include "k2hmacro.h"
-	"C"
-)
 import (
 	"bytes"
+	"context"
 	"errors"
 	"fmt"
-	"unsafe"
 )
 
 // SetSubKeys holds arguments for C.k2hdkc_pm_set_subkeys and a pointer of SetSubKeysResult.
@@ -46,9 +38,9 @@ func (r *SetSubKeys) String() string {
 
 // SetSubKeysResult holds the result of SetSubKeys.Execute().
 type SetSubKeysResult struct {
-	ok         C._Bool
-	resCode    C.dkcres_type_t // response
-	subResCode C.dkcres_type_t // response(details)
+	ok         bool
+	resCode    ResCode // response
+	subResCode SubCode // response(details)
 }
 
 // String returns a text representation of the object.
@@ -125,37 +117,19 @@ func (r *SetSubKeys) Execute(s *Session) (bool, error) {
 	if r.key == nil || len(r.key) == 0 || r.skeys == nil || r.result == nil {
 		return false, fmt.Errorf("some required members nil, r.key %v, r.skeys %v, r.result %v", r.key, r.skeys, r.result)
 	}
-
-	cKey := C.CBytes(r.key)
-	defer C.free(unsafe.Pointer(cKey))
-
-	pack := make([]C.PK2HDKCKEYPCK, len(r.skeys))
-	for i, skey := range r.skeys {
-		key := (*C.uchar)(C.CBytes(skey)) // key will be freed after calling bC.k2hdkc_pm_set_subkeys
-		length := C.size_t(len(skey))
-		pack[i] = &C.K2HKEYPCK{length: length, pkey: key}
-	}
-	r.result.ok = C.k2hdkc_pm_set_subkeys(
-		s.handler,
-		(*C.uchar)(cKey),
-		(C.size_t)(len(r.key)),
-		pack[0],
-		(C.int)(len(pack)))
-	r.result.resCode = C.k2hdkc_get_res_code(s.handler)
-	r.result.subResCode = C.k2hdkc_get_res_subcode(s.handler)
-
-	// frees keys in keypack
-	for i := range r.skeys {
-		key := pack[i].pkey
-		defer C.free(unsafe.Pointer(key))
-	}
-
+	res := s.do(&Request{Op: OpSetSubKeys, Key: r.key, SubKeys: r.skeys})
+	r.result.ok, r.result.resCode, r.result.subResCode = res.OK, res.Code, res.SubCode
 	if r.result.ok == false {
-		return false, fmt.Errorf("C.k2hdkc_pm_set_subkeys %v", r.result.ok)
+		return false, resError("C.k2hdkc_pm_set_subkeys", res)
 	}
 	return true, nil
 }
 
+// ExecuteContext calls Execute in a goroutine and returns the context error if the context is done before Execute returns.
+func (r *SetSubKeys) ExecuteContext(ctx context.Context, s *Session) (bool, error) {
+	return executeContext(ctx, s, r)
+}
+
 // Result returns the result of Execute method.
 func (r *SetSubKeys) Result() *SetSubKeysResult {
 	if r.result != nil {
@@ -174,9 +148,17 @@ func (r *SetSubKeysResult) Bool() bool {
 
 // Error returns the errno of C.k2hdkc_pm_set_subkeys() in string format.
 func (r *SetSubKeysResult) Error() string {
-	return fmt.Sprintf("%v %v",
-		C.GoString(C.str_dkcres_result_type(r.resCode)),
-		C.GoString(C.str_dkcres_subcode_type(r.subResCode)))
+	return fmt.Sprintf("%v %v", r.resCode, r.subResCode)
+}
+
+// Code returns the result code of the request.
+func (r *SetSubKeysResult) Code() ResCode {
+	return r.resCode
+}
+
+// SubCode returns the sub code of the request.
+func (r *SetSubKeysResult) SubCode() SubCode {
+	return r.subResCode
 }
 
 // Local Variables:
@@ -0,0 +1,396 @@
+//
+// k2hdkc_go
+//
+// Copyright 2018 Yahoo Japan Corporation.
+//
+// Go driver for k2hdkc that is a highly available and scalable distributed
+// KVS clustering system. For k2hdkc, see
+// https://github.com/yahoojapan/k2hdkc for the details.
+//
+// For the full copyright and license information, please view
+// the license file that was distributed with this source code.
+//
+// AUTHOR:   Hirotaka Wakabayashi
+// CREATE:   Fri, 16 Oct 2026
+// REVISION:
+//
+
+// Package structmap saves Go structs on a k2hdkc cluster as a parent key and subkeys.
+//
+// A struct saved to a key uses these keys.
+//
+//	key            the struct. its subkeys are the keys of the fields.
+//	key/name       a field. its value is encoded with the codec.
+//	key/name       a nested struct field, which is saved like the struct.
+//	key/name       a slice or array field. its subkeys are the keys of the elements.
+//	key/name/i     the i-th element, which is saved like a field.
+//
+// The name of a field is given by the k2hdkc struct tag.
+//
+//	Name  string   `k2hdkc:"name"`        // saved to key/name.
+//	Addr  Address  `k2hdkc:"addr,value"`  // saved to key/addr as one encoded value.
+//	Temp  string   `k2hdkc:"-"`           // not saved.
+//
+// Exported fields without the tag use the field name. A struct implementing
+// json.Marshaler or encoding.TextMarshaler, like time.Time, is saved as one value.
+// A nil pointer is not saved and a field whose key doesn't exist isn't changed by Load.
+package structmap
+
+import (
+	"context"
+	"encoding"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/yahoojapan/k2hdkc_go/k2hdkc"
+)
+
+// tagName is the key of the struct tag.
+const tagName = "k2hdkc"
+
+var (
+	textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+	jsonMarshalerType = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+)
+
+// Option sets an optional parameter of a Mapper.
+type Option func(*Mapper)
+
+// WithCodec sets the codec of the field values. The codec of the client is used by default.
+func WithCodec(c k2hdkc.Codec) Option {
+	return func(m *Mapper) {
+		m.codec = c
+	}
+}
+
+// WithSeparator sets the separator of the parent key and the field name. The default is "/".
+func WithSeparator(sep string) Option {
+	return func(m *Mapper) {
+		if sep != "" {
+			m.sep = sep
+		}
+	}
+}
+
+// Mapper saves and loads structs with a Client. A Mapper is safe for concurrent use by multiple goroutines,
+// but saving the same key concurrently leaves the fields of either struct.
+type Mapper struct {
+	client *k2hdkc.Client
+	codec  k2hdkc.Codec
+	sep    string
+}
+
+// New returns the pointer to a Mapper.
+func New(c *k2hdkc.Client, opts ...Option) *Mapper {
+	m := &Mapper{
+		client: c,
+		sep:    "/",
+	}
+	for _, opt := range opts {
+		if opt != nil {
+			opt(m)
+		}
+	}
+	return m
+}
+
+// field is a field of a struct to be saved.
+type field struct {
+	index   int
+	name    string
+	asValue bool // true if the field is saved as one encoded value.
+}
+
+// fields returns the fields of the struct type to be saved.
+func fields(t reflect.Type) ([]field, error) {
+	var fs []field
+	names := make(map[string]bool)
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		if sf.PkgPath != "" {
+			continue // unexported
+		}
+		tag := sf.Tag.Get(tagName)
+		if tag == "-" {
+			continue
+		}
+		f := field{index: i, name: sf.Name}
+		if tag != "" {
+			parts := strings.Split(tag, ",")
+			if parts[0] != "" {
+				f.name = parts[0]
+			}
+			for _, opt := range parts[1:] {
+				switch opt {
+				case "value":
+					f.asValue = true
+				default:
+					return nil, fmt.Errorf("structmap: unknown option %q in the tag of %v.%v", opt, t, sf.Name)
+				}
+			}
+		}
+		if names[f.name] {
+			return nil, fmt.Errorf("structmap: duplicate field name %q in %v", f.name, t)
+		}
+		names[f.name] = true
+		fs = append(fs, f)
+	}
+	return fs, nil
+}
+
+// isNode returns true if values of the type are saved as a parent key and subkeys.
+func isNode(t reflect.Type) bool {
+	switch t.Kind() {
+	case reflect.Struct:
+		return !t.Implements(textMarshalerType) && !reflect.PtrTo(t).Implements(textMarshalerType) &&
+			!t.Implements(jsonMarshalerType) && !reflect.PtrTo(t).Implements(jsonMarshalerType)
+	case reflect.Slice, reflect.Array:
+		return t.Elem().Kind() != reflect.Uint8
+	}
+	return false
+}
+
+// opts returns the options of the value commands.
+func (m *Mapper) opts() []k2hdkc.Option {
+	if m.codec != nil {
+		return []k2hdkc.Option{k2hdkc.WithCodec(m.codec)}
+	}
+	return nil
+}
+
+// childKey returns the key of the field or the element.
+func (m *Mapper) childKey(key string, name string) string {
+	return key + m.sep + name
+}
+
+// Save saves the struct v, or a pointer to it, to the key and the subkeys of the fields.
+// Subkeys of fields which no longer exist, like elements of a shorter slice, are deleted.
+func (m *Mapper) Save(ctx context.Context, key string, v interface{}) error {
+	rv := reflect.ValueOf(v)
+	for rv.Kind() == reflect.Ptr {
+		if rv.IsNil() {
+			return fmt.Errorf("structmap: Save(%q) of a nil %T", key, v)
+		}
+		rv = rv.Elem()
+	}
+	if rv.Kind() != reflect.Struct {
+		return fmt.Errorf("structmap: Save(%q) of %T, want a struct", key, v)
+	}
+	return m.saveNode(ctx, key, rv)
+}
+
+// saveValue saves the value to the key. It returns false if nothing is saved for a nil pointer.
+func (m *Mapper) saveValue(ctx context.Context, key string, rv reflect.Value, asValue bool) (bool, error) {
+	for rv.Kind() == reflect.Ptr || rv.Kind() == reflect.Interface {
+		if rv.IsNil() {
+			return false, nil
+		}
+		if rv.Kind() == reflect.Ptr && !asValue && !isNode(rv.Elem().Type()) {
+			break // the codec encodes the pointer.
+		}
+		rv = rv.Elem()
+	}
+	if asValue || !isNode(rv.Type()) {
+		if err := m.client.SetObjectContext(ctx, key, rv.Interface(), m.opts()...); err != nil {
+			return false, err
+		}
+		return true, nil
+	}
+	return true, m.saveNode(ctx, key, rv)
+}
+
+// saveNode saves the children of the struct, slice or array and sets the key with the subkeys of them.
+func (m *Mapper) saveNode(ctx context.Context, key string, rv reflect.Value) error {
+	var children []string
+	switch rv.Kind() {
+	case reflect.Struct:
+		fs, err := fields(rv.Type())
+		if err != nil {
+			return err
+		}
+		for _, f := range fs {
+			ck := m.childKey(key, f.name)
+			ok, err := m.saveValue(ctx, ck, rv.Field(f.index), f.asValue)
+			if err != nil {
+				return err
+			}
+			if ok {
+				children = append(children, ck)
+			}
+		}
+	default:
+		for i := 0; i < rv.Len(); i++ {
+			ck := m.childKey(key, strconv.Itoa(i))
+			ok, err := m.saveValue(ctx, ck, rv.Index(i), false)
+			if err != nil {
+				return err
+			}
+			if ok {
+				children = append(children, ck)
+			}
+		}
+	}
+	if err := m.deleteStale(ctx, key, children); err != nil {
+		return err
+	}
+	// the value of a parent key is the type, since an empty value can't be set.
+	val := []byte(rv.Type().String())
+	if len(children) == 0 {
+		return m.client.SetValueContext(ctx, key, val, k2hdkc.WithRmSubKeyList())
+	}
+	return m.client.SetAllContext(ctx, key, val, children)
+}
+
+// deleteStale deletes the old children of the key which aren't in the children.
+func (m *Mapper) deleteStale(ctx context.Context, key string, children []string) error {
+	old, err := m.subKeys(ctx, key)
+	if err != nil {
+		return err
+	}
+	keep := make(map[string]bool, len(children))
+	for _, ck := range children {
+		keep[ck] = true
+	}
+	for _, ck := range old {
+		if !keep[ck] {
+			if err := m.deleteTree(ctx, ck); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// subKeys returns the subkeys of the key under the key. It returns nil if the key doesn't exist.
+func (m *Mapper) subKeys(ctx context.Context, key string) ([]string, error) {
+	sks, err := m.client.GetSubKeyListContext(ctx, key)
+	if errors.Is(err, k2hdkc.ErrNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	prefix := key + m.sep
+	var children []string
+	for _, sk := range sks {
+		// subkeys out of the key aren't children.
+		if strings.HasPrefix(sk, prefix) {
+			children = append(children, sk)
+		}
+	}
+	return children, nil
+}
+
+// Load loads the struct saved to the key into dst, which must be a pointer to a struct.
+// ErrNotFound returns if the key doesn't exist.
+func (m *Mapper) Load(ctx context.Context, key string, dst interface{}) error {
+	rv := reflect.ValueOf(dst)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() || rv.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("structmap: Load(%q) into %T, want a non-nil pointer to a struct", key, dst)
+	}
+	if _, err := m.client.GetValueContext(ctx, key); err != nil {
+		return err
+	}
+	return m.loadNode(ctx, key, rv.Elem())
+}
+
+// loadValue loads the value saved to the key into rv.
+func (m *Mapper) loadValue(ctx context.Context, key string, rv reflect.Value, asValue bool) error {
+	if rv.Kind() == reflect.Ptr && (asValue || isNode(rv.Type().Elem())) {
+		if rv.IsNil() {
+			rv.Set(reflect.New(rv.Type().Elem()))
+		}
+		return m.loadValue(ctx, key, rv.Elem(), asValue)
+	}
+	if asValue || !isNode(rv.Type()) {
+		return m.client.GetObjectContext(ctx, key, rv.Addr().Interface(), m.opts()...)
+	}
+	return m.loadNode(ctx, key, rv)
+}
+
+// loadNode loads the children of the key into the struct, slice or array.
+func (m *Mapper) loadNode(ctx context.Context, key string, rv reflect.Value) error {
+	sks, err := m.subKeys(ctx, key)
+	if err != nil {
+		return err
+	}
+	switch rv.Kind() {
+	case reflect.Struct:
+		fs, err := fields(rv.Type())
+		if err != nil {
+			return err
+		}
+		exists := make(map[string]bool, len(sks))
+		for _, sk := range sks {
+			exists[sk] = true
+		}
+		for _, f := range fs {
+			ck := m.childKey(key, f.name)
+			if !exists[ck] {
+				continue
+			}
+			if err := m.loadValue(ctx, ck, rv.Field(f.index), f.asValue); err != nil {
+				return err
+			}
+		}
+		return nil
+	case reflect.Slice:
+		n := 0
+		for _, sk := range sks {
+			if i, ok := m.index(key, sk); ok && i+1 > n {
+				n = i + 1
+			}
+		}
+		rv.Set(reflect.MakeSlice(rv.Type(), n, n))
+	}
+	for _, sk := range sks {
+		i, ok := m.index(key, sk)
+		if !ok || i >= rv.Len() {
+			continue
+		}
+		if err := m.loadValue(ctx, sk, rv.Index(i), false); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// index returns the index of the element key of the slice key.
+func (m *Mapper) index(key string, sk string) (int, bool) {
+	i, err := strconv.Atoi(strings.TrimPrefix(sk, key+m.sep))
+	return i, err == nil && i >= 0
+}
+
+// Delete deletes the key and all the subkeys under it.
+func (m *Mapper) Delete(ctx context.Context, key string) error {
+	return m.deleteTree(ctx, key)
+}
+
+// deleteTree deletes the children of the key and the key.
+func (m *Mapper) deleteTree(ctx context.Context, key string) error {
+	sks, err := m.subKeys(ctx, key)
+	if err != nil {
+		return err
+	}
+	for _, sk := range sks {
+		if err := m.deleteTree(ctx, sk); err != nil {
+			return err
+		}
+	}
+	if err := m.client.RemoveContext(ctx, key); err != nil && !errors.Is(err, k2hdkc.ErrNotFound) {
+		return err
+	}
+	return nil
+}
+
+// Local Variables:
+// c-basic-offset: 4
+// tab-width: 4
+// indent-tabs-mode: t
+// End:
+// vim600: noexpandtab sw=4 ts=4 fdm=marker
+// vim<600: noexpandtab sw=4 ts=4
@@ -0,0 +1,168 @@
+//
+// k2hdkc_go
+//
+// Copyright 2018 Yahoo Japan Corporation.
+//
+// Go driver for k2hdkc that is a highly available and scalable distributed
+// KVS clustering system. For k2hdkc, see
+// https://github.com/yahoojapan/k2hdkc for the details.
+//
+// For the full copyright and license information, please view
+// the license file that was distributed with this source code.
+//
+// AUTHOR:   Hirotaka Wakabayashi
+// CREATE:   Fri, 16 Oct 2026
+// REVISION:
+//
+
+package structmap
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/yahoojapan/k2hdkc_go/k2hdkc"
+	"github.com/yahoojapan/k2hdkc_go/k2hdkc/k2hdkctest"
+)
+
+type address struct {
+	City string `k2hdkc:"city"`
+	Zip  string `k2hdkc:"zip"`
+}
+
+type user struct {
+	Name    string     `k2hdkc:"name"`
+	Age     int        `k2hdkc:"age"`
+	Home    address    `k2hdkc:"home"`
+	Work    *address   `k2hdkc:"work,value"`
+	Tags    []string   `k2hdkc:"tags"`
+	Friends []*address `k2hdkc:"friends"`
+	Created time.Time  `k2hdkc:"created"`
+	Secret  string     `k2hdkc:"-"`
+	private string
+}
+
+// TestMapperSaveLoad tests a struct is saved as subkeys and loaded back.
+func TestMapperSaveLoad(t *testing.T) {
+	s := k2hdkctest.NewServer()
+	c := s.NewClient()
+	defer c.Close()
+	ctx := context.Background()
+	m := New(c)
+
+	u := user{
+		Name:    "alice",
+		Age:     30,
+		Home:    address{City: "Tokyo", Zip: "100"},
+		Work:    &address{City: "Osaka"},
+		Tags:    []string{"a", "b", "c"},
+		Friends: []*address{{City: "Nagoya"}, nil, {City: "Fukuoka"}},
+		Created: time.Date(2026, 10, 16, 0, 0, 0, 0, time.UTC),
+		Secret:  "s",
+		private: "p",
+	}
+	if err := m.Save(ctx, "user/1", &u); err != nil {
+		t.Fatalf("m.Save(ctx, user/1, u) = %v", err)
+	}
+	r, err := c.GetSubKeys("user/1")
+	if err != nil {
+		t.Fatalf("c.GetSubKeys(user/1) = %v", err)
+	}
+	want := []string{"user/1/name", "user/1/age", "user/1/home", "user/1/work", "user/1/tags", "user/1/friends", "user/1/created"}
+	if got := r.Keys(c.KeyEncoding()); !reflect.DeepEqual(got, want) {
+		t.Errorf("subkeys = %v, want %v", got, want)
+	}
+	if r, err := c.GetSubKeys("user/1/home"); err != nil || len(r.Keys(c.KeyEncoding())) != 2 {
+		t.Errorf("c.GetSubKeys(user/1/home) = (%v, %v), want 2 subkeys", r, err)
+	}
+
+	var got user
+	if err := m.Load(ctx, "user/1", &got); err != nil {
+		t.Fatalf("m.Load(ctx, user/1, &got) = %v", err)
+	}
+	u.Secret, u.private = "", ""
+	if !reflect.DeepEqual(got, u) {
+		t.Errorf("m.Load(ctx, user/1, &got) = %+v, want %+v", got, u)
+	}
+}
+
+// TestMapperShrink tests the subkeys of removed elements are deleted.
+func TestMapperShrink(t *testing.T) {
+	s := k2hdkctest.NewServer()
+	c := s.NewClient()
+	defer c.Close()
+	ctx := context.Background()
+	m := New(c)
+
+	if err := m.Save(ctx, "u", user{Tags: []string{"a", "b", "c"}}); err != nil {
+		t.Fatalf("m.Save(ctx, u, 3 tags) = %v", err)
+	}
+	if err := m.Save(ctx, "u", user{Tags: []string{"x"}}); err != nil {
+		t.Fatalf("m.Save(ctx, u, 1 tag) = %v", err)
+	}
+	if _, err := c.GetValue("u/tags/2"); !errors.Is(err, k2hdkc.ErrNotFound) {
+		t.Errorf("c.GetValue(u/tags/2) = %v, want ErrNotFound", err)
+	}
+	var got user
+	if err := m.Load(ctx, "u", &got); err != nil || !reflect.DeepEqual(got.Tags, []string{"x"}) {
+		t.Errorf("m.Load(ctx, u, &got) = %v, tags %v, want [x]", err, got.Tags)
+	}
+}
+
+// TestMapperDelete tests the key and all the subkeys are deleted.
+func TestMapperDelete(t *testing.T) {
+	s := k2hdkctest.NewServer()
+	c := s.NewClient()
+	defer c.Close()
+	ctx := context.Background()
+	m := New(c, WithSeparator("."))
+
+	if err := m.Save(ctx, "u", user{Name: "bob", Friends: []*address{{City: "Sapporo"}}}); err != nil {
+		t.Fatalf("m.Save(ctx, u, bob) = %v", err)
+	}
+	if err := m.Delete(ctx, "u"); err != nil {
+		t.Fatalf("m.Delete(ctx, u) = %v", err)
+	}
+	for _, k := range []string{"u", "u.name", "u.friends", "u.friends.0", "u.friends.0.city"} {
+		if _, err := c.GetValue(k); !errors.Is(err, k2hdkc.ErrNotFound) {
+			t.Errorf("c.GetValue(%v) = %v, want ErrNotFound", k, err)
+		}
+	}
+	var got user
+	if err := m.Load(ctx, "u", &got); !errors.Is(err, k2hdkc.ErrNotFound) {
+		t.Errorf("m.Load(ctx, u, &got) = %v, want ErrNotFound", err)
+	}
+}
+
+// TestMapperInvalid tests invalid arguments and tags.
+func TestMapperInvalid(t *testing.T) {
+	s := k2hdkctest.NewServer()
+	c := s.NewClient()
+	defer c.Close()
+	ctx := context.Background()
+	m := New(c)
+
+	if err := m.Save(ctx, "k", "text"); err == nil {
+		t.Errorf("m.Save(ctx, k, text) = nil, want an error")
+	}
+	if err := m.Load(ctx, "k", user{}); err == nil {
+		t.Errorf("m.Load(ctx, k, user{}) = nil, want an error")
+	}
+	type bad struct {
+		A string `k2hdkc:"a,unknown"`
+	}
+	if err := m.Save(ctx, "k", bad{}); err == nil {
+		t.Errorf("m.Save(ctx, k, bad{}) = nil, want an error")
+	}
+}
+
+// Local Variables:
+// c-basic-offset: 4
+// tab-width: 4
+// indent-tabs-mode: t
+// End:
+// vim600: noexpandtab sw=4 ts=4 fdm=marker
+// vim<600: noexpandtab sw=4 ts=4
@@ -0,0 +1,113 @@
+//
+// k2hdkc_go
+//
+// Copyright 2018 Yahoo Japan Corporation.
+//
+// Go driver for k2hdkc that is a highly available and scalable distributed
+// KVS clustering system. For k2hdkc, see
+// https://github.com/yahoojapan/k2hdkc for the details.
+//
+// For the full copyright and license information, please view
+// the license file that was distributed with this source code.
+//
+// AUTHOR:   Hirotaka Wakabayashi
+// CREATE:   Fri, 16 Oct 2026
+// REVISION:
+//
+
+package k2hdkc
+
+import (
+	"context"
+)
+
+// Attribute keys of the spans.
+const (
+	SpanCommand    = "k2hdkc.command"     // type name of the command, such as Get.
+	SpanKeyLength  = "k2hdkc.key_length"  // length of the key in bytes.
+	SpanResultCode = "k2hdkc.result_code" // result code of the request.
+	SpanSubCode    = "k2hdkc.sub_code"    // sub code of the request.
+	SpanAttempt    = "k2hdkc.attempt"     // number of the attempt from 1.
+	SpanBackoff    = "k2hdkc.backoff"     // wait before the retry.
+)
+
+// Attribute is a key and a value set to a Span.
+type Attribute struct {
+	Key   string
+	Value interface{}
+}
+
+// Span is a traced operation. End must be called once.
+type Span interface {
+	SetAttributes(attrs ...Attribute)
+	End(err error)
+}
+
+// Tracer starts spans. The span of a Client method is started by the Tracer of the context
+// given to the method. It has the spans of acquiring sessions, executing the command and
+// waiting for retries as children.
+type Tracer interface {
+	Start(ctx context.Context, name string) (context.Context, Span)
+}
+
+// NoopTracer is the Tracer of a context without a Tracer. It does nothing.
+var NoopTracer Tracer = noopTracer{}
+
+type noopTracer struct{}
+
+// Start returns ctx and a Span which does nothing.
+func (noopTracer) Start(ctx context.Context, name string) (context.Context, Span) {
+	return ctx, noopSpan{}
+}
+
+type noopSpan struct{}
+
+// SetAttributes does nothing.
+func (noopSpan) SetAttributes(attrs ...Attribute) {}
+
+// End does nothing.
+func (noopSpan) End(err error) {}
+
+// tracerKey is the context key of the Tracer.
+type tracerKey struct{}
+
+// WithTracer returns a copy of ctx which holds the Tracer.
+func WithTracer(ctx context.Context, t Tracer) context.Context {
+	return context.WithValue(ctx, tracerKey{}, t)
+}
+
+// TracerFromContext returns the Tracer which ctx holds or NoopTracer.
+func TracerFromContext(ctx context.Context) Tracer {
+	if t, ok := ctx.Value(tracerKey{}).(Tracer); ok && t != nil {
+		return t
+	}
+	return NoopTracer
+}
+
+// startSpan starts a span of the command by the Tracer of ctx.
+func startSpan(ctx context.Context, name string, cmd Command) (context.Context, Span) {
+	ctx, span := TracerFromContext(ctx).Start(ctx, name)
+	if _, noop := span.(noopSpan); !noop {
+		span.SetAttributes(Attribute{SpanCommand, commandName(cmd)}, Attribute{SpanKeyLength, len(CommandKey(cmd))})
+	}
+	return ctx, span
+}
+
+// endSpan sets the result codes of the command to the span and ends it.
+// The result of a command abandoned by the context isn't read.
+func endSpan(span Span, cmd Command, err error) {
+	if _, noop := span.(noopSpan); !noop && !isContextError(err) {
+		if code, sub, ok := CommandResult(cmd); ok {
+			span.SetAttributes(Attribute{SpanResultCode, code.String()}, Attribute{SpanSubCode, sub.String()})
+		}
+	}
+	span.End(err)
+}
+
+// Local Variables:
+// c-basic-offset: 4
+// tab-width: 4
+// indent-tabs-mode: t
+// End:
+// vim600: noexpandtab sw=4 ts=4 fdm=marker
+// vim<600: noexpandtab sw=4 ts=4
@@ -0,0 +1,128 @@
+//
+// k2hdkc_go
+//
+// Copyright 2018 Yahoo Japan Corporation.
+//
+// Go driver for k2hdkc that is a highly available and scalable distributed
+// KVS clustering system. For k2hdkc, see
+// https://github.com/yahoojapan/k2hdkc for the details.
+//
+// For the full copyright and license information, please view
+// the license file that was distributed with this source code.
+//
+// AUTHOR:   Hirotaka Wakabayashi
+// CREATE:   Fri, 16 Oct 2026
+// REVISION:
+//
+
+package k2hdkc_test
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/yahoojapan/k2hdkc_go/k2hdkc"
+	"github.com/yahoojapan/k2hdkc_go/k2hdkc/k2hdkctest"
+)
+
+// recordTracer records the spans.
+type recordTracer struct {
+	mu    sync.Mutex
+	spans []*recordSpan
+}
+
+type recordSpan struct {
+	tracer *recordTracer
+	name   string
+	parent string
+	attrs  map[string]interface{}
+	err    error
+	ended  bool
+}
+
+type spanKey struct{}
+
+func (t *recordTracer) Start(ctx context.Context, name string) (context.Context, k2hdkc.Span) {
+	s := &recordSpan{tracer: t, name: name, attrs: make(map[string]interface{})}
+	if p, ok := ctx.Value(spanKey{}).(*recordSpan); ok {
+		s.parent = p.name
+	}
+	t.mu.Lock()
+	t.spans = append(t.spans, s)
+	t.mu.Unlock()
+	return context.WithValue(ctx, spanKey{}, s), s
+}
+
+func (s *recordSpan) SetAttributes(attrs ...k2hdkc.Attribute) {
+	s.tracer.mu.Lock()
+	defer s.tracer.mu.Unlock()
+	for _, a := range attrs {
+		s.attrs[a.Key] = a.Value
+	}
+}
+
+func (s *recordSpan) End(err error) {
+	s.tracer.mu.Lock()
+	defer s.tracer.mu.Unlock()
+	s.err, s.ended = err, true
+}
+
+// TestTracer tests the spans of a retried command.
+func TestTracer(t *testing.T) {
+	in := k2hdkctest.NewInjector(k2hdkctest.NewServer())
+	c := in.NewClient().SetRetryPolicy(retryPolicy())
+	defer c.Close()
+	if err := c.SetValue("trace", []byte("v")); err != nil {
+		t.Fatalf("c.SetValue(trace) = %v", err)
+	}
+	in.Add(k2hdkctest.Fault{Op: k2hdkc.OpGet, Times: 1, Fail: true, SubCode: k2hdkc.SubCodeTimeout})
+
+	tr := &recordTracer{}
+	ctx := k2hdkc.WithTracer(context.Background(), tr)
+	if _, err := c.GetValueContext(ctx, "trace"); err != nil {
+		t.Fatalf("c.GetValueContext(trace) = %v", err)
+	}
+
+	var names []string
+	for _, s := range tr.spans {
+		if !s.ended {
+			t.Errorf("span %v has not ended", s.name)
+		}
+		if s.name != "k2hdkc.Get" && s.parent != "k2hdkc.Get" {
+			t.Errorf("the parent of %v = %q", s.name, s.parent)
+		}
+		names = append(names, s.name)
+	}
+	want := "k2hdkc.Get k2hdkc.acquire k2hdkc.execute k2hdkc.retry k2hdkc.acquire k2hdkc.execute"
+	if got := strings.Join(names, " "); got != want {
+		t.Fatalf("spans = %v, want %v", got, want)
+	}
+	get, failed, retry := tr.spans[0], tr.spans[2], tr.spans[3]
+	if fmt.Sprintf("%v %v %v", get.attrs[k2hdkc.SpanCommand], get.attrs[k2hdkc.SpanKeyLength], get.attrs[k2hdkc.SpanSubCode]) != "Get 6 DKC_RES_SUBCODE_NOTHING" || get.err != nil {
+		t.Errorf("the span of Get = %v, %v", get.attrs, get.err)
+	}
+	if failed.attrs[k2hdkc.SpanSubCode] != "DKC_RES_SUBCODE_TIMEOUT" || failed.err == nil {
+		t.Errorf("the span of the failed execution = %v, %v", failed.attrs, failed.err)
+	}
+	if retry.attrs[k2hdkc.SpanAttempt] != 1 {
+		t.Errorf("the span of the retry = %v", retry.attrs)
+	}
+}
+
+// TestNoopTracer tests a context without a Tracer returns NoopTracer.
+func TestNoopTracer(t *testing.T) {
+	if k2hdkc.TracerFromContext(context.Background()) != k2hdkc.NoopTracer {
+		t.Errorf("TracerFromContext(context.Background()) is not NoopTracer")
+	}
+}
+
+// Local Variables:
+// c-basic-offset: 4
+// tab-width: 4
+// indent-tabs-mode: t
+// End:
+// vim600: noexpandtab sw=4 ts=4 fdm=marker
+// vim<600: noexpandtab sw=4 ts=4
@@ -15,6 +15,9 @@
 // REVISION:
 //
 
+//go:build cgo && !nok2hdkc
+// +build cgo,!nok2hdkc
+
 package k2hdkctest
 
 import (
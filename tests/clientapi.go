@@ -0,0 +1,142 @@
+//
+// k2hdkc_go
+//
+// Copyright 2018 Yahoo Japan Corporation.
+//
+// Go driver for k2hdkc that is a highly available and scalable distributed
+// KVS clustering system. For k2hdkc, see
+// https://github.com/yahoojapan/k2hdkc for the details.
+//
+// For the full copyright and license information, please view
+// the license file that was distributed with this source code.
+//
+// AUTHOR:   Hirotaka Wakabayashi
+// CREATE:   Fri, 16 Oct 2026
+// REVISION:
+//
+
+//go:build cgo && !nok2hdkc
+// +build cgo,!nok2hdkc
+
+package k2hdkctest
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/yahoojapan/k2hdkc_go/k2hdkc"
+)
+
+// testClientAPIValue ensures SetValue and GetValue handle binary values with options.
+func testClientAPIValue(t *testing.T) {
+	c := k2hdkc.NewClient("../cluster/slave.yaml", 8031)
+	defer c.Close()
+	if err := c.SetValue("clientapi1", []byte("v1"), k2hdkc.WithPass("pass"), k2hdkc.WithExpire(time.Minute)); err != nil {
+		t.Errorf("client.SetValue(clientapi1, v1) returned %v", err)
+	}
+	if v, err := c.GetValue("clientapi1", k2hdkc.WithPass("pass")); err != nil || !bytes.Equal(v, []byte("v1")) {
+		t.Errorf("client.GetValue(clientapi1) returned v %v err %v, want v1", v, err)
+	}
+	if err := c.Remove("clientapi1"); err != nil {
+		t.Errorf("client.Remove(clientapi1) returned %v", err)
+	}
+	if v, err := c.GetValue("clientapi1"); !errors.Is(err, k2hdkc.ErrNotFound) {
+		t.Errorf("client.GetValue(clientapi1) returned v %v err %v, want %v", v, err, k2hdkc.ErrNotFound)
+	}
+}
+
+// testClientAPISubKeys ensures the subkey methods update the subkey list.
+func testClientAPISubKeys(t *testing.T) {
+	c := k2hdkc.NewClient("../cluster/slave.yaml", 8031)
+	defer c.Close()
+	if err := c.SetAll("clientapi2", []byte("v2"), []string{"clientapi2-1"}); err != nil {
+		t.Errorf("client.SetAll(clientapi2, v2, [clientapi2-1]) returned %v", err)
+	}
+	if err := c.AddSubKey("clientapi2", "clientapi2-2", []byte("v2-2")); err != nil {
+		t.Errorf("client.AddSubKey(clientapi2, clientapi2-2, v2-2) returned %v", err)
+	}
+	if r, err := c.GetSubKeys("clientapi2"); err != nil || len(r.String()) != 2 {
+		t.Errorf("client.GetSubKeys(clientapi2) returned r %v err %v, want 2 subkeys", r, err)
+	}
+	if err := c.RemoveSubKey("clientapi2", "clientapi2-2", k2hdkc.WithNest()); err != nil {
+		t.Errorf("client.RemoveSubKey(clientapi2, clientapi2-2) returned %v", err)
+	}
+	if err := c.ClearSubKeys("clientapi2"); err != nil {
+		t.Errorf("client.ClearSubKeys(clientapi2) returned %v", err)
+	}
+	if r, err := c.GetSubKeys("clientapi2"); err != nil || len(r.String()) != 0 {
+		t.Errorf("client.GetSubKeys(clientapi2) returned r %v err %v, want no subkeys", r, err)
+	}
+	if attrs, err := c.GetAttrs("clientapi2"); err != nil {
+		t.Errorf("client.GetAttrs(clientapi2) returned attrs %v err %v", attrs, err)
+	}
+}
+
+// testClientAPIRename ensures Rename moves the value to the new key.
+func testClientAPIRename(t *testing.T) {
+	c := k2hdkc.NewClient("../cluster/slave.yaml", 8031)
+	defer c.Close()
+	if err := c.SetValue("clientapi3", []byte("v3")); err != nil {
+		t.Errorf("client.SetValue(clientapi3, v3) returned %v", err)
+	}
+	if err := c.Rename("clientapi3", "clientapi3-new"); err != nil {
+		t.Errorf("client.Rename(clientapi3, clientapi3-new) returned %v", err)
+	}
+	if v, err := c.GetValue("clientapi3-new"); err != nil || !bytes.Equal(v, []byte("v3")) {
+		t.Errorf("client.GetValue(clientapi3-new) returned v %v err %v, want v3", v, err)
+	}
+}
+
+// testClientAPICas ensures the cas methods handle uint64 values.
+func testClientAPICas(t *testing.T) {
+	c := k2hdkc.NewClient("../cluster/slave.yaml", 8031)
+	defer c.Close()
+	if err := c.CasInit("clientapi4", 1); err != nil {
+		t.Errorf("client.CasInit(clientapi4, 1) returned %v", err)
+	}
+	if err := c.CasIncrement("clientapi4"); err != nil {
+		t.Errorf("client.CasIncrement(clientapi4) returned %v", err)
+	}
+	if v, err := c.CasGet("clientapi4"); err != nil || v != 2 {
+		t.Errorf("client.CasGet(clientapi4) returned v %v err %v, want 2", v, err)
+	}
+	if err := c.CasSet("clientapi4", 1, 3); !errors.Is(err, k2hdkc.ErrCasConflict) {
+		t.Errorf("client.CasSet(clientapi4, 1, 3) returned %v, want %v", err, k2hdkc.ErrCasConflict)
+	}
+	if err := c.CasInit("clientapi4", 256, k2hdkc.WithCasType(k2hdkc.CasType8)); err == nil {
+		t.Errorf("client.CasInit(clientapi4, 256, CasType8) returned nil, want an overflow error")
+	}
+}
+
+// testClientAPIQueue ensures the queue methods push and pop values in order.
+func testClientAPIQueue(t *testing.T) {
+	c := k2hdkc.NewClient("../cluster/slave.yaml", 8031)
+	defer c.Close()
+	for _, v := range []string{"v1", "v2"} {
+		if err := c.QueuePush("clientapi5", []byte(v), k2hdkc.WithFifo()); err != nil {
+			t.Errorf("client.QueuePush(clientapi5, %v) returned %v", v, err)
+		}
+	}
+	if k, v, err := c.QueuePop("clientapi5", k2hdkc.WithFifo()); err != nil || k != nil || !bytes.Equal(v, []byte("v1")) {
+		t.Errorf("client.QueuePop(clientapi5) returned k %v v %v err %v, want v1", k, v, err)
+	}
+	if err := c.QueueRemove("clientapi5", 1); err != nil {
+		t.Errorf("client.QueueRemove(clientapi5, 1) returned %v", err)
+	}
+	if err := c.KeyQueuePush("clientapi6", "k1", []byte("v1")); err != nil {
+		t.Errorf("client.KeyQueuePush(clientapi6, k1, v1) returned %v", err)
+	}
+	if k, v, err := c.QueuePop("clientapi6", k2hdkc.WithKeyQueue()); err != nil || len(k) == 0 || !bytes.Equal(v, []byte("v1")) {
+		t.Errorf("client.QueuePop(clientapi6, WithKeyQueue) returned k %v v %v err %v, want k1 v1", k, v, err)
+	}
+}
+
+// Local Variables:
+// c-basic-offset: 4
+// tab-width: 4
+// indent-tabs-mode: t
+// End:
+// vim600: noexpandtab sw=4 ts=4 fdm=marker
+// vim<600: noexpandtab sw=4 ts=4
@@ -0,0 +1,98 @@
+//
+// k2hdkc_go
+//
+// Copyright 2018 Yahoo Japan Corporation.
+//
+// Go driver for k2hdkc that is a highly available and scalable distributed
+// KVS clustering system. For k2hdkc, see
+// https://github.com/yahoojapan/k2hdkc for the details.
+//
+// For the full copyright and license information, please view
+// the license file that was distributed with this source code.
+//
+// AUTHOR:   Hirotaka Wakabayashi
+// CREATE:   Fri, 16 Oct 2026
+// REVISION:
+//
+
+//go:build cgo && !nok2hdkc
+// +build cgo,!nok2hdkc
+
+package k2hdkctest
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/yahoojapan/k2hdkc_go/k2hdkc"
+)
+
+// testContextDeadlineExceeded ensures commands return context.DeadlineExceeded after the deadline.
+func testContextDeadlineExceeded(t *testing.T) {
+	c := k2hdkc.NewClient("../cluster/slave.yaml", 8031)
+	defer c.Close()
+	ctx, cancel := context.WithDeadline(context.Background(), time.Now().Add(-time.Second))
+	defer cancel()
+	if r, err := c.SetContext(ctx, "context1", "v1"); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("client.SetContext(ctx, context1, v1) returned r %v err %v, want %v", r, err, context.DeadlineExceeded)
+	}
+	cmd, err := k2hdkc.NewGet("context1")
+	if err != nil {
+		t.Errorf("NewGet(context1) returned %v", err)
+	}
+	if r, err := c.SendContext(ctx, cmd); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("client.SendContext(ctx, cmd) returned r %v err %v, want %v", r, err, context.DeadlineExceeded)
+	}
+}
+
+// testContextCanceled ensures ExecuteContext returns context.Canceled with a canceled context.
+func testContextCanceled(t *testing.T) {
+	c := k2hdkc.NewClient("../cluster/slave.yaml", 8031)
+	defer c.Close()
+	s, err := k2hdkc.NewSession(c)
+	if err != nil {
+		t.Errorf("NewSession(c) returned %v", err)
+		return
+	}
+	defer s.Close()
+	cmd, err := k2hdkc.NewSet("context2", "v2")
+	if err != nil {
+		t.Errorf("NewSet(context2, v2) returned %v", err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if ok, err := cmd.ExecuteContext(ctx, s); ok || !errors.Is(err, context.Canceled) {
+		t.Errorf("cmd.ExecuteContext(ctx, s) returned ok %v err %v, want %v", ok, err, context.Canceled)
+	}
+	if s.Busy() {
+		t.Errorf("s.Busy() returned true, want false")
+	}
+}
+
+// testContextTimeout ensures commands succeed before the deadline.
+func testContextTimeout(t *testing.T) {
+	c := k2hdkc.NewClient("../cluster/slave.yaml", 8031)
+	defer c.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if r, err := c.SetContext(ctx, "context3", "v3"); r == nil || err != nil {
+		t.Errorf("client.SetContext(ctx, context3, v3) returned r %v err %v", r, err)
+	}
+	r, err := c.GetContext(ctx, "context3")
+	if r == nil || err != nil || r.String() != "v3" {
+		t.Errorf("client.GetContext(ctx, context3) returned r %v err %v", r, err)
+	}
+	if stats := c.PoolStats(); stats.Abandoned != 0 || stats.Busy != 0 {
+		t.Errorf("c.PoolStats() = %v, want no abandoned session", stats)
+	}
+}
+
+// Local Variables:
+// c-basic-offset: 4
+// tab-width: 4
+// indent-tabs-mode: t
+// End:
+// vim600: noexpandtab sw=4 ts=4 fdm=marker
+// vim<600: noexpandtab sw=4 ts=4
@@ -0,0 +1,60 @@
+//
+// k2hdkc_go
+//
+// Copyright 2018 Yahoo Japan Corporation.
+//
+// Go driver for k2hdkc that is a highly available and scalable distributed
+// KVS clustering system. For k2hdkc, see
+// https://github.com/yahoojapan/k2hdkc for the details.
+//
+// For the full copyright and license information, please view
+// the license file that was distributed with this source code.
+//
+// AUTHOR:   Hirotaka Wakabayashi
+// CREATE:   Fri, 16 Oct 2026
+// REVISION:
+//
+
+//go:build cgo && !nok2hdkc
+// +build cgo,!nok2hdkc
+
+package k2hdkctest
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/yahoojapan/k2hdkc_go/k2hdkc"
+)
+
+// testErrorsNoData ensures a get command of a missing key returns SubCodeNoData.
+func testErrorsNoData(t *testing.T) {
+	key := "errors1"
+	if ok, err := clearIfExists(key); !ok {
+		t.Errorf("clearIfExists(%q) = (%v, %v)", key, ok, err)
+	}
+	c := k2hdkc.NewClient("../cluster/slave.yaml", 8031)
+	defer c.Close()
+	r, err := c.Get(key)
+	if err != nil {
+		t.Errorf("client.Get(%q) returned %v", key, err)
+		return
+	}
+	if code := r.Code(); code != k2hdkc.ResCodeSuccess {
+		t.Errorf("Result().Code() = %v, want %v", code, k2hdkc.ResCodeSuccess)
+	}
+	if code := r.SubCode(); code != k2hdkc.SubCodeNoData {
+		t.Errorf("Result().SubCode() = %v, want %v", code, k2hdkc.SubCodeNoData)
+	}
+	if err := r.SubCode().Err(); !errors.Is(err, k2hdkc.ErrNotFound) {
+		t.Errorf("Result().SubCode().Err() = %v, want %v", err, k2hdkc.ErrNotFound)
+	}
+}
+
+// Local Variables:
+// c-basic-offset: 4
+// tab-width: 4
+// indent-tabs-mode: t
+// End:
+// vim600: noexpandtab sw=4 ts=4 fdm=marker
+// vim<600: noexpandtab sw=4 ts=4
@@ -15,6 +15,9 @@
 // REVISION:
 //
 
+//go:build cgo && !nok2hdkc
+// +build cgo,!nok2hdkc
+
 package k2hdkctest
 
 import (
@@ -111,11 +114,20 @@ func TestClearSubKeysTypeByteAPI(t *testing.T)          { testClearSubKeysTypeBy
 func TestClearSubKeysTypeStringEmptyAPI(t *testing.T)   { testClearSubKeysTypeStringEmpty(t) }
 func TestClearSubKeysKeyTypeUnknownAPI(t *testing.T)    { testClearSubKeysKeyTypeUnknown(t) }
 func TestClientAPI(t *testing.T)                        { testClient(t) }
+func TestClientAPIValueAPI(t *testing.T)                { testClientAPIValue(t) }
+func TestClientAPISubKeysAPI(t *testing.T)              { testClientAPISubKeys(t) }
+func TestClientAPIRenameAPI(t *testing.T)               { testClientAPIRename(t) }
+func TestClientAPICasAPI(t *testing.T)                  { testClientAPICas(t) }
+func TestClientAPIQueueAPI(t *testing.T)                { testClientAPIQueue(t) }
 func TestClientCreateSessionAPI(t *testing.T)           { testClientCreateSession(t) }
 func TestClientCreateSessionErrorAPI(t *testing.T)      { testClientCreateSessionError(t) }
 func TestClientSetMethodsAPI(t *testing.T)              { testClientSetMethods(t) }
 func TestClientSetAndGetAPI(t *testing.T)               { testClientSetAndGet(t) }
 func TestClientSetSubKeysAndGetSubKeysAPI(t *testing.T) { testClientSetSubKeysAndGetSubKeys(t) }
+func TestContextDeadlineExceededAPI(t *testing.T)       { testContextDeadlineExceeded(t) }
+func TestContextCanceledAPI(t *testing.T)               { testContextCanceled(t) }
+func TestContextTimeoutAPI(t *testing.T)                { testContextTimeout(t) }
+func TestErrorsNoDataAPI(t *testing.T)                  { testErrorsNoData(t) }
 func TestGetAttrsTypeByteAPI(t *testing.T)              { testGetAttrsTypeByte(t) }
 func TestGetAPI(t *testing.T)                           { testGet(t) }
 func TestGetTypeStringEmptyAPI(t *testing.T)            { testGetTypeStringEmpty(t) }
@@ -123,6 +135,12 @@ func TestGetKeyTypeUnknownAPI(t *testing.T)             { testGetKeyTypeUnknown(
 func TestGetSubKeysAPI(t *testing.T)                    { testGetSubKeys(t) }
 func TestGetSubKeysTypeStringEmptyAPI(t *testing.T)     { testGetSubKeysTypeStringEmpty(t) }
 func TestGetSubKeysKeyTypeUnknownAPI(t *testing.T)      { testGetSubKeysKeyTypeUnknown(t) }
+func TestGetManyMissingAPI(t *testing.T)                { testGetManyMissing(t) }
+func TestPoolReuseAPI(t *testing.T)                     { testPoolReuse(t) }
+func TestPoolConcurrentAPI(t *testing.T)                { testPoolConcurrent(t) }
+func TestPoolIdleTimeAPI(t *testing.T)                  { testPoolIdleTime(t) }
+func TestPoolValidatorAPI(t *testing.T)                 { testPoolValidator(t) }
+func TestPoolClosedAPI(t *testing.T)                    { testPoolClosed(t) }
 func TestQueuePopAPI(t *testing.T)                      { testQueuePop(t) }
 func TestQueuePushAPI(t *testing.T)                     { testQueuePush(t) }
 func TestQueueRemoveAPI(t *testing.T)                   { testQueueRemove(t) }
@@ -0,0 +1,59 @@
+//
+// k2hdkc_go
+//
+// Copyright 2018 Yahoo Japan Corporation.
+//
+// Go driver for k2hdkc that is a highly available and scalable distributed
+// KVS clustering system. For k2hdkc, see
+// https://github.com/yahoojapan/k2hdkc for the details.
+//
+// For the full copyright and license information, please view
+// the license file that was distributed with this source code.
+//
+// AUTHOR:   Hirotaka Wakabayashi
+// CREATE:   Fri, 16 Oct 2026
+// REVISION:
+//
+
+//go:build cgo && !nok2hdkc
+// +build cgo,!nok2hdkc
+
+package k2hdkctest
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/yahoojapan/k2hdkc_go/k2hdkc"
+)
+
+// testGetManyMissing ensures GetMany reports missing keys apart from values and failures.
+func testGetManyMissing(t *testing.T) {
+	key := "many2"
+	if ok, err := clearIfExists(key); !ok {
+		t.Errorf("clearIfExists(%q) = (%v, %v)", key, ok, err)
+	}
+	c := k2hdkc.NewClient("../cluster/slave.yaml", 8031)
+	defer c.Close()
+	if err := c.SetValue("many1", []byte("v1")); err != nil {
+		t.Errorf("client.SetValue(many1, v1) returned %v", err)
+	}
+	vals, missing, errs := c.GetMany([]string{"many1", key})
+	if len(vals) != 1 || string(vals["many1"]) != "v1" {
+		t.Errorf("client.GetMany() returned vals %v, want many1 only", vals)
+	}
+	if !reflect.DeepEqual(missing, []string{key}) {
+		t.Errorf("client.GetMany() returned missing %v, want [%v]", missing, key)
+	}
+	if len(errs) != 0 {
+		t.Errorf("client.GetMany() returned errs %v, want none", errs)
+	}
+}
+
+// Local Variables:
+// c-basic-offset: 4
+// tab-width: 4
+// indent-tabs-mode: t
+// End:
+// vim600: noexpandtab sw=4 ts=4 fdm=marker
+// vim<600: noexpandtab sw=4 ts=4
@@ -0,0 +1,117 @@
+//
+// k2hdkc_go
+//
+// Copyright 2018 Yahoo Japan Corporation.
+//
+// Go driver for k2hdkc that is a highly available and scalable distributed
+// KVS clustering system. For k2hdkc, see
+// https://github.com/yahoojapan/k2hdkc for the details.
+//
+// For the full copyright and license information, please view
+// the license file that was distributed with this source code.
+//
+// AUTHOR:   Hirotaka Wakabayashi
+// CREATE:   Fri, 16 Oct 2026
+// REVISION:
+//
+
+//go:build cgo && !nok2hdkc
+// +build cgo,!nok2hdkc
+
+package k2hdkctest
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/yahoojapan/k2hdkc_go/k2hdkc"
+)
+
+// testPoolReuse ensures the Client reuses sessions between requests.
+func testPoolReuse(t *testing.T) {
+	c := k2hdkc.NewClient("../cluster/slave.yaml", 8031)
+	defer c.Close()
+	c.SetMaxSessions(2)
+	for i := 0; i < 10; i++ {
+		if r, err := c.Set("pool1", "v1"); r == nil || err != nil {
+			t.Errorf("client.Set(pool1, v1) returned r %v err %v", r, err)
+		}
+	}
+	stats := c.PoolStats()
+	if stats.Opened != 1 || stats.Open != 1 || stats.Idle != 1 || stats.InUse != 0 {
+		t.Errorf("c.PoolStats() = %v, want one idle session", stats)
+	}
+}
+
+// testPoolConcurrent ensures the number of sessions never exceeds the max.
+func testPoolConcurrent(t *testing.T) {
+	c := k2hdkc.NewClient("../cluster/slave.yaml", 8031)
+	defer c.Close()
+	c.SetMinSessions(1).SetMaxSessions(3)
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			k := fmt.Sprintf("pool_concurrent%d", i)
+			if r, err := c.Set(k, "v"); r == nil || err != nil {
+				t.Errorf("client.Set(%v, v) returned r %v err %v", k, r, err)
+			}
+		}(i)
+	}
+	wg.Wait()
+	if stats := c.PoolStats(); stats.Open > 3 || stats.InUse != 0 {
+		t.Errorf("c.PoolStats() = %v, want Open <= 3", stats)
+	}
+}
+
+// testPoolIdleTime ensures idle sessions are closed after the max idle time.
+func testPoolIdleTime(t *testing.T) {
+	c := k2hdkc.NewClient("../cluster/slave.yaml", 8031)
+	defer c.Close()
+	c.SetMaxIdleTime(time.Second)
+	if r, err := c.Set("pool2", "v2"); r == nil || err != nil {
+		t.Errorf("client.Set(pool2, v2) returned r %v err %v", r, err)
+	}
+	time.Sleep(3 * time.Second)
+	if stats := c.PoolStats(); stats.Open != 0 || stats.Closed != 1 {
+		t.Errorf("c.PoolStats() = %v, want no open session", stats)
+	}
+}
+
+// testPoolValidator ensures the validator rejects sessions.
+func testPoolValidator(t *testing.T) {
+	c := k2hdkc.NewClient("../cluster/slave.yaml", 8031)
+	defer c.Close()
+	c.SetSessionValidator(func(s *k2hdkc.Session) bool { return false })
+	for i := 0; i < 3; i++ {
+		if r, err := c.Set("pool3", "v3"); r == nil || err != nil {
+			t.Errorf("client.Set(pool3, v3) returned r %v err %v", r, err)
+		}
+	}
+	if stats := c.PoolStats(); stats.Opened != 3 || stats.Closed != 2 {
+		t.Errorf("c.PoolStats() = %v, want 3 opened and 2 closed", stats)
+	}
+}
+
+// testPoolClosed ensures requests fail after closing the client.
+func testPoolClosed(t *testing.T) {
+	c := k2hdkc.NewClient("../cluster/slave.yaml", 8031)
+	if r, err := c.Set("pool4", "v4"); r == nil || err != nil {
+		t.Errorf("client.Set(pool4, v4) returned r %v err %v", r, err)
+	}
+	c.Close()
+	if r, err := c.Set("pool4", "v4"); err == nil {
+		t.Errorf("client.Set(pool4, v4) returned r %v err %v, want an error", r, err)
+	}
+}
+
+// Local Variables:
+// c-basic-offset: 4
+// tab-width: 4
+// indent-tabs-mode: t
+// End:
+// vim600: noexpandtab sw=4 ts=4 fdm=marker
+// vim<600: noexpandtab sw=4 ts=4